@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+// runAPIKey — подкоманда «subscriptions apikey -name NAME [-roles admin,finance] [-user UUID] [-tenant ID]».
+// Выпускает API-ключ в обход HTTP, например первый ключ администратора, и печатает его в stdout.
+func runAPIKey(ctx context.Context, svc *usecase.Service, args []string) int {
+	fs := flag.NewFlagSet("apikey", flag.ContinueOnError)
+	name := fs.String("name", "", "key name shown in the key list")
+	roles := fs.String("roles", "", "comma-separated roles: admin, finance")
+	user := fs.String("user", "", "ID of the user the key acts for")
+	tenant := fs.String("tenant", "", "tenant the key belongs to (default \"default\")")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: subscriptions apikey -name NAME [-roles admin,finance] [-user UUID] [-tenant ID]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	in := usecase.APIKeyInput{Name: *name, TenantID: *tenant}
+	if *roles != "" {
+		in.Roles = strings.Split(*roles, ",")
+	}
+	if *user != "" {
+		id, err := uuid.Parse(*user)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "apikey: -user:", err)
+			return 2
+		}
+		in.UserID = &id
+	}
+	k, secret, err := svc.CreateAPIKey(ctx, in)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "apikey:", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "created API key %s (%s); it is shown only once\n", k.ID, k.Name)
+	fmt.Println(secret)
+	return 0
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/oziev02/subscriptions-service/internal/adapters/importfile"
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+// runImport — подкоманда «subscriptions import [-format csv|ndjson] [-dry-run] [-tenant ID] FILE|-».
+// Возвращает код выхода: 0 — все строки импортированы, 1 — есть строки с ошибками, 2 — импорт не выполнен.
+func runImport(ctx context.Context, svc *usecase.Service, args []string) int {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := fs.String("format", "", "csv or ndjson; by default inferred from the file extension")
+	dryRun := fs.Bool("dry-run", false, "validate rows without saving them")
+	tenant := fs.String("tenant", domain.DefaultTenant, "tenant the subscriptions belong to")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: subscriptions import [-format csv|ndjson] [-dry-run] [-tenant ID] FILE|-")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	path := fs.Arg(0)
+	if err := domain.ValidateTenantID(*tenant); err != nil {
+		fmt.Fprintln(os.Stderr, "import:", err)
+		return 2
+	}
+	ctx = usecase.WithTenant(ctx, *tenant)
+
+	var f importfile.Format
+	var err error
+	switch {
+	case *format != "":
+		f, err = importfile.ParseFormat(*format)
+	case path == "-":
+		err = fmt.Errorf("-format is required when reading from stdin")
+	default:
+		f, err = importfile.FormatFromPath(path)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "import:", err)
+		return 2
+	}
+
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "import:", err)
+			return 2
+		}
+		defer file.Close()
+		in = file
+	}
+	src, err := importfile.NewSource(f, in)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "import:", err)
+		return 2
+	}
+
+	ctx = usecase.WithAuditMeta(ctx, usecase.AuditMeta{Actor: "import-cli"})
+	rep, err := svc.Import(ctx, src, *dryRun)
+	for _, e := range rep.Errors {
+		fmt.Fprintf(os.Stderr, "line %d: %v\n", e.Line, e.Err)
+	}
+	if rep.Failed > len(rep.Errors) {
+		fmt.Fprintf(os.Stderr, "... and %d more errors\n", rep.Failed-len(rep.Errors))
+	}
+	verb := "imported"
+	if *dryRun {
+		verb = "valid"
+	}
+	fmt.Printf("rows: %d, %s: %d, failed: %d\n", rep.Rows, verb, rep.Imported, rep.Failed)
+	switch {
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "import:", err)
+		return 2
+	case rep.Failed > 0:
+		return 1
+	}
+	return 0
+}
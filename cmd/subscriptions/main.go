@@ -13,9 +13,12 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/oziev02/subscriptions-service/internal/adapters/httpapi"
+	"github.com/oziev02/subscriptions-service/internal/adapters/repo/memory"
 	"github.com/oziev02/subscriptions-service/internal/adapters/repo/postgres"
 	"github.com/oziev02/subscriptions-service/internal/pkg/config"
+	"github.com/oziev02/subscriptions-service/internal/pkg/jwt"
 	"github.com/oziev02/subscriptions-service/internal/pkg/logger"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
 )
 
 func main() {
@@ -32,14 +35,33 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	pool, err := postgres.NewPool(ctx, cfg.DB.DSN)
-	if err != nil {
-		log.Fatal("db connect", zap.Error(err))
+	repos, closeRepos := openRepos(ctx, cfg, log)
+	defer closeRepos()
+
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		code := runImport(ctx, usecase.NewService(repos), os.Args[2:])
+		closeRepos()
+		_ = log.Sync()
+		os.Exit(code)
+	}
+	if len(os.Args) > 1 && os.Args[1] == "apikey" {
+		code := runAPIKey(ctx, usecase.NewService(repos), os.Args[2:])
+		closeRepos()
+		_ = log.Sync()
+		os.Exit(code)
 	}
-	defer pool.Close()
 
-	repo := postgres.NewSubscriptionRepo(pool, log)
-	api := httpapi.NewServer(cfg, log, repo)
+	auth, err := authenticators(cfg.Auth, usecase.NewService(repos))
+	if err != nil {
+		log.Fatal("auth", zap.Error(err))
+	}
+	if len(auth) == 0 {
+		log.Warn("authentication is disabled, set AUTH_METHODS to enable it")
+	}
+	api := httpapi.NewServer(cfg, log, repos, auth...)
+	if cfg.Purge.Retention > 0 && cfg.Purge.Interval > 0 {
+		go runPurge(ctx, log, usecase.NewService(repos), cfg.Purge)
+	}
 
 	srv := &http.Server{
 		Addr:              fmt.Sprintf(":%d", cfg.HTTP.Port),
@@ -65,3 +87,74 @@ func main() {
 	log.Info("bye")
 	_ = os.Stderr.Sync()
 }
+
+// openRepos подключает хранилище из конфигурации; возвращённая функция освобождает его ресурсы.
+func openRepos(ctx context.Context, cfg *config.Config, log *zap.Logger) (usecase.Repos, func()) {
+	switch cfg.Storage {
+	case "memory":
+		log.Warn("using in-memory storage, data will be lost on restart")
+		return memory.NewRepos(), func() {}
+	case "postgres":
+		pool, err := postgres.NewPool(ctx, cfg.DB.DSN)
+		if err != nil {
+			log.Fatal("db connect", zap.Error(err))
+		}
+		if bypass, err := postgres.BypassesRLS(ctx, pool); err != nil {
+			log.Fatal("db role", zap.Error(err))
+		} else if bypass {
+			log.Warn("database role bypasses row-level security, connect as a role without SUPERUSER and BYPASSRLS")
+		}
+		return usecase.Repos{
+			Subscriptions: postgres.NewSubscriptionRepo(pool, log),
+			Services:      postgres.NewServiceRepo(pool),
+			Users:         postgres.NewUserRepo(pool),
+			Orgs:          postgres.NewOrgRepo(pool),
+			Teams:         postgres.NewTeamRepo(pool),
+			APIKeys:       postgres.NewAPIKeyRepo(pool),
+			Rates:         postgres.NewExchangeRateRepo(pool),
+		}, pool.Close
+	default:
+		log.Fatal("unknown storage", zap.String("storage", cfg.Storage))
+		return usecase.Repos{}, nil
+	}
+}
+
+// authenticators собирает проверки учётных данных из AUTH_METHODS в указанном порядке.
+func authenticators(cfg config.AuthConfig, svc *usecase.Service) ([]httpapi.Authenticator, error) {
+	var res []httpapi.Authenticator
+	for _, m := range cfg.Methods {
+		switch m {
+		case "api_key":
+			res = append(res, httpapi.NewAPIKeyAuth(svc))
+		case "jwt":
+			v, err := jwt.NewVerifier(cfg.JWT)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, httpapi.NewJWTAuth(v, svc))
+		default:
+			return nil, fmt.Errorf("unknown auth method %q", m)
+		}
+	}
+	return res, nil
+}
+
+// runPurge периодически окончательно удаляет подписки, срок хранения которых после удаления истёк.
+func runPurge(ctx context.Context, log *zap.Logger, svc *usecase.Service, cfg config.PurgeConfig) {
+	ctx = usecase.WithAuditMeta(ctx, usecase.AuditMeta{Actor: "purge-job"})
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+	for {
+		n, err := svc.PurgeDeleted(ctx, cfg.Retention)
+		if err != nil {
+			log.Error("purge deleted subscriptions", zap.Error(err))
+		} else if n > 0 {
+			log.Info("purged deleted subscriptions", zap.Int("count", n))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package httpapi
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+type activeResp struct {
+	UserID string       `json:"user_id"`
+	Month  string       `json:"month"`
+	Mode   string       `json:"mode"`
+	Items  []subDTO     `json:"items"`
+	Costs  []summaryDTO `json:"costs"`
+}
+
+// activeAt отвечает на вопрос «за что пользователь платил в месяце at».
+func (s *Server) activeAt(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "user_id"))
+	if err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	var mode *string
+	if q := r.URL.Query().Get("mode"); q != "" {
+		mode = &q
+	}
+	snap, err := s.uc.ActiveAt(r.Context(), userID, r.URL.Query().Get("at"), mode)
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toActiveDTO(userID, snap))
+}
+
+func toActiveDTO(userID uuid.UUID, snap usecase.ActiveSnapshot) activeResp {
+	resp := activeResp{
+		UserID: userID.String(),
+		Month:  snap.Month.String(),
+		Mode:   string(snap.Mode),
+		Items:  make([]subDTO, 0, len(snap.Subscriptions)),
+		Costs:  make([]summaryDTO, 0, len(snap.Costs)),
+	}
+	for _, sub := range snap.Subscriptions {
+		resp.Items = append(resp.Items, toDTO(sub))
+	}
+	for _, c := range snap.Costs {
+		resp.Costs = append(resp.Costs, summaryDTO{Total: c.Total, Currency: c.Currency.String()})
+	}
+	return resp
+}
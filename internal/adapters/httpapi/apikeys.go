@@ -0,0 +1,96 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+type apiKeyReq struct {
+	Name   string     `json:"name"`
+	UserID *uuid.UUID `json:"user_id,omitempty"`
+	Roles  []string   `json:"roles,omitempty"`
+}
+
+type apiKeyDTO struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Prefix    string   `json:"prefix"`
+	UserID    string   `json:"user_id,omitempty"`
+	TenantID  string   `json:"tenant_id"`
+	Roles     []string `json:"roles"`
+	CreatedAt string   `json:"created_at"`
+	RevokedAt *string  `json:"revoked_at,omitempty"`
+	Key       string   `json:"key,omitempty"` // только в ответе на создание
+}
+
+func toAPIKeyDTO(k *domain.APIKey) apiKeyDTO {
+	out := apiKeyDTO{
+		ID:        k.ID.String(),
+		Name:      k.Name,
+		Prefix:    k.Prefix,
+		TenantID:  k.TenantID,
+		Roles:     k.Roles,
+		CreatedAt: k.CreatedAt.Format(time.RFC3339),
+	}
+	if out.Roles == nil {
+		out.Roles = []string{}
+	}
+	if k.UserID != nil {
+		out.UserID = k.UserID.String()
+	}
+	if k.RevokedAt != nil {
+		at := k.RevokedAt.Format(time.RFC3339)
+		out.RevokedAt = &at
+	}
+	return out
+}
+
+func (s *Server) listAPIKeys(w http.ResponseWriter, r *http.Request) {
+	res, err := s.uc.ListAPIKeys(r.Context())
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	items := make([]apiKeyDTO, 0, len(res))
+	for _, k := range res {
+		items = append(items, toAPIKeyDTO(k))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+// createAPIKey выпускает ключ; сам ключ возвращается только в этом ответе.
+func (s *Server) createAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req apiKeyReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	k, secret, err := s.uc.CreateAPIKey(r.Context(), usecase.APIKeyInput{Name: req.Name, UserID: req.UserID, Roles: req.Roles})
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	out := toAPIKeyDTO(k)
+	out.Key = secret
+	writeJSON(w, http.StatusCreated, out)
+}
+
+func (s *Server) revokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	if err := s.uc.RevokeAPIKey(r.Context(), id); err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,80 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+// actorHeader — заголовок, которым вызывающий представляется в журнале аудита, если аутентификация отключена.
+const actorHeader = "X-Actor"
+
+// auditMeta передаёт в usecase исполнителя и request ID (из middleware.RequestID) для журнала аудита.
+// Исполнитель аутентифицированного запроса — его Principal, а не заголовок X-Actor.
+func auditMeta(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor := r.Header.Get(actorHeader)
+		if p, ok := usecase.PrincipalFrom(r.Context()); ok {
+			actor = p.Subject
+		}
+		ctx := usecase.WithAuditMeta(r.Context(), usecase.AuditMeta{
+			Actor:     actor,
+			RequestID: middleware.GetReqID(r.Context()),
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+type auditRecordDTO struct {
+	ID        int64           `json:"id"`
+	Operation string          `json:"operation"`
+	Actor     string          `json:"actor"`
+	RequestID string          `json:"request_id"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	CreatedAt string          `json:"created_at"`
+}
+
+func toAuditDTO(rec domain.AuditRecord) auditRecordDTO {
+	out := auditRecordDTO{
+		ID:        rec.ID,
+		Operation: string(rec.Operation),
+		Actor:     rec.Actor,
+		RequestID: rec.RequestID,
+		Before:    rec.Before,
+		After:     rec.After,
+		CreatedAt: rec.CreatedAt.Format(time.RFC3339),
+	}
+	if len(out.Before) == 0 {
+		out.Before = json.RawMessage("null")
+	}
+	if len(out.After) == 0 {
+		out.After = json.RawMessage("null")
+	}
+	return out
+}
+
+func (s *Server) history(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	recs, err := s.uc.History(r.Context(), id)
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	items := make([]auditRecordDTO, 0, len(recs))
+	for _, rec := range recs {
+		items = append(items, toAuditDTO(rec))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
@@ -0,0 +1,115 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/pkg/jwt"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+// apiKeyHeader — заголовок с API-ключом; ключ можно передать и как Authorization: Bearer.
+const apiKeyHeader = "X-API-Key"
+
+// Authenticator проверяет учётные данные одного вида. ok=false — таких данных в запросе нет,
+// ошибка — данные есть, но недействительны.
+type Authenticator interface {
+	Authenticate(r *http.Request) (p domain.Principal, ok bool, err error)
+}
+
+// APIKeyAuth принимает API-ключи, выпущенные через /v1/api-keys.
+type APIKeyAuth struct {
+	uc *usecase.Service
+}
+
+func NewAPIKeyAuth(uc *usecase.Service) *APIKeyAuth {
+	return &APIKeyAuth{uc: uc}
+}
+
+func (a *APIKeyAuth) Authenticate(r *http.Request) (domain.Principal, bool, error) {
+	key := r.Header.Get(apiKeyHeader)
+	if tok := bearerToken(r); key == "" && strings.HasPrefix(tok, usecase.APIKeyPrefix) {
+		key = tok
+	}
+	if key == "" {
+		return domain.Principal{}, false, nil
+	}
+	p, err := a.uc.AuthenticateAPIKey(r.Context(), key)
+	return p, err == nil, err
+}
+
+// JWTAuth принимает JWT из Authorization: Bearer. Субъект токена, похожий на UUID, считается
+// ID пользователя и должен быть существующим пользователем, как у API-ключей; роли берутся
+// из утверждения roles, арендатор — из tenant_id.
+type JWTAuth struct {
+	v  *jwt.Verifier
+	uc *usecase.Service
+}
+
+func NewJWTAuth(v *jwt.Verifier, uc *usecase.Service) *JWTAuth {
+	return &JWTAuth{v: v, uc: uc}
+}
+
+func (a *JWTAuth) Authenticate(r *http.Request) (domain.Principal, bool, error) {
+	tok := bearerToken(r)
+	if tok == "" || strings.HasPrefix(tok, usecase.APIKeyPrefix) {
+		return domain.Principal{}, false, nil
+	}
+	c, err := a.v.Verify(tok, time.Now())
+	if err != nil {
+		return domain.Principal{}, false, domain.Errorf(domain.ErrUnauthenticated, "invalid token: %w", err)
+	}
+	if c.TenantID != "" {
+		if err := domain.ValidateTenantID(c.TenantID); err != nil {
+			return domain.Principal{}, false, domain.Errorf(domain.ErrUnauthenticated, "invalid token: %w", err)
+		}
+	}
+	p := domain.Principal{Subject: c.Subject, TenantID: c.TenantID, Roles: c.Roles}
+	if id, err := uuid.Parse(c.Subject); err == nil {
+		p.UserID = &id
+	}
+	if err := a.uc.CheckPrincipal(r.Context(), p); err != nil {
+		return domain.Principal{}, false, err
+	}
+	return p, true, nil
+}
+
+func bearerToken(r *http.Request) string {
+	tok, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return strings.TrimSpace(tok)
+}
+
+// authenticate кладёт в контекст вызывающего, чьи учётные данные принял первый подходящий
+// аутентификатор; запросы без действительных учётных данных получают 401.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(s.auth) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		for _, a := range s.auth {
+			p, ok, err := a.Authenticate(r)
+			if err != nil {
+				s.unauthorized(w, r, err)
+				return
+			}
+			if ok {
+				next.ServeHTTP(w, r.WithContext(usecase.WithPrincipal(r.Context(), p)))
+				return
+			}
+		}
+		s.unauthorized(w, r, domain.Errorf(domain.ErrUnauthenticated, "credentials are required"))
+	})
+}
+
+func (s *Server) unauthorized(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, domain.ErrUnauthenticated) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="subscriptions"`)
+	}
+	s.writeErr(w, r, err)
+}
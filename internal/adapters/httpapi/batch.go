@@ -0,0 +1,129 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+type batchReq struct {
+	Mode       string       `json:"mode"` // atomic (по умолчанию) | best_effort
+	Operations []batchOpReq `json:"operations"`
+}
+
+// batchOpReq — операция пакета: create передаёт subscription, update — id и patch (JSON Merge Patch),
+// delete — id. if_match — ETag, как в заголовке If-Match.
+type batchOpReq struct {
+	Op           string          `json:"op"`
+	ID           *uuid.UUID      `json:"id"`
+	IfMatch      string          `json:"if_match"`
+	Subscription *createReq      `json:"subscription"`
+	Patch        json.RawMessage `json:"patch"`
+}
+
+func (req batchOpReq) op() (usecase.BatchOp, error) {
+	op := usecase.BatchOp{Kind: usecase.BatchOpKind(req.Op)}
+	var err error
+	if op.IfMatch, err = parseIfMatch(req.IfMatch); err != nil {
+		return op, err
+	}
+	switch op.Kind {
+	case usecase.BatchCreate:
+		if req.Subscription == nil {
+			return op, domain.Errorf(domain.ErrValidation, "subscription is required for create")
+		}
+		op.Create = req.Subscription.input()
+		return op, nil
+	case usecase.BatchUpdate, usecase.BatchDelete:
+		if req.ID == nil {
+			return op, domain.Errorf(domain.ErrValidation, "id is required for %s", req.Op)
+		}
+		op.ID = *req.ID
+	default:
+		return op, domain.Errorf(domain.ErrValidation, "op must be one of: create, update, delete")
+	}
+	if op.Kind == usecase.BatchUpdate {
+		if op.Update, err = mergePatchInput(req.Patch); err != nil {
+			return op, err
+		}
+	}
+	return op, nil
+}
+
+type batchResultDTO struct {
+	Index        int        `json:"index"`
+	Status       int        `json:"status"`
+	Subscription *subDTO    `json:"subscription,omitempty"`
+	ETag         string     `json:"etag,omitempty"`
+	Error        *errorResp `json:"error,omitempty"`
+}
+
+type batchResp struct {
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Results   []batchResultDTO `json:"results"`
+}
+
+// batch применяет пакет операций. Ответ всегда 200 с результатом каждой операции, если сам запрос корректен.
+func (s *Server) batch(w http.ResponseWriter, r *http.Request) {
+	var req batchReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	in := usecase.BatchInput{Atomic: true}
+	switch req.Mode {
+	case "", "atomic":
+	case "best_effort":
+		in.Atomic = false
+	default:
+		s.writeErr(w, r, domain.Errorf(domain.ErrValidation, "mode must be one of: atomic, best_effort"))
+		return
+	}
+	for i, opReq := range req.Operations {
+		op, err := opReq.op()
+		if err != nil {
+			s.writeErr(w, r, &domain.Error{Kind: domain.ErrValidation, Err: fmt.Errorf("operations[%d]: %w", i, err)})
+			return
+		}
+		in.Ops = append(in.Ops, op)
+	}
+	results, err := s.uc.Batch(r.Context(), in)
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+
+	resp := batchResp{Results: make([]batchResultDTO, 0, len(results))}
+	for i, res := range results {
+		item := batchResultDTO{Index: i}
+		if res.Err != nil {
+			status, body := s.errorBody(r, res.Err)
+			item.Status, item.Error = status, &body
+			resp.Failed++
+		} else {
+			item.Status = batchStatus(in.Ops[i].Kind)
+			dto := toDTO(res.Subscription)
+			item.Subscription, item.ETag = &dto, etag(res.Subscription)
+			resp.Succeeded++
+		}
+		resp.Results = append(resp.Results, item)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func batchStatus(kind usecase.BatchOpKind) int {
+	switch kind {
+	case usecase.BatchCreate:
+		return http.StatusCreated
+	case usecase.BatchDelete:
+		return http.StatusNoContent
+	default:
+		return http.StatusOK
+	}
+}
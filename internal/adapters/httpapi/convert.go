@@ -3,37 +3,129 @@ package httpapi
 import (
 	"time"
 
+	"github.com/google/uuid"
+
 	"github.com/oziev02/subscriptions-service/internal/domain"
 	"github.com/oziev02/subscriptions-service/internal/usecase"
 )
 
 type subDTO struct {
-	ID          string  `json:"id"`
-	ServiceName string  `json:"service_name"`
-	Price       int     `json:"price"`
-	UserID      string  `json:"user_id"`
-	StartDate   string  `json:"start_date"`
-	EndDate     *string `json:"end_date,omitempty"`
-	CreatedAt   string  `json:"created_at"`
-	UpdatedAt   string  `json:"updated_at"`
+	ID            string  `json:"id"`
+	TenantID      string  `json:"tenant_id"`
+	ServiceName   string  `json:"service_name"`
+	ServiceID     string  `json:"service_id,omitempty"`
+	Price         int     `json:"price"`
+	Currency      string  `json:"currency"`
+	BillingPeriod string  `json:"billing_period"`
+	UserID        string  `json:"user_id"`
+	StartDate     string  `json:"start_date"`
+	EndDate       *string `json:"end_date,omitempty"`
+	CreatedAt     string  `json:"created_at"`
+	UpdatedAt     string  `json:"updated_at"`
+	DeletedAt     *string `json:"deleted_at,omitempty"`
 }
 
 func toDTO(s *domain.Subscription) subDTO {
+	start := s.Start.String()
 	var end *string
 	if s.End != nil {
 		v := s.End.String()
 		end = &v
 	}
+	if s.DayPrecision() {
+		start = s.StartDate.String()
+		if s.EndDate != nil {
+			v := s.EndDate.String()
+			end = &v
+		}
+	}
+	var deleted *string
+	if s.DeletedAt != nil {
+		v := s.DeletedAt.Format(time.RFC3339)
+		deleted = &v
+	}
+	var serviceID string
+	if s.ServiceID != uuid.Nil {
+		serviceID = s.ServiceID.String()
+	}
 	return subDTO{
-		ID:          s.ID.String(),
-		ServiceName: s.ServiceName,
-		Price:       s.Price,
-		UserID:      s.UserID.String(),
-		StartDate:   s.Start.String(),
-		EndDate:     end,
-		CreatedAt:   s.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:   s.UpdatedAt.Format(time.RFC3339),
+		ID:            s.ID.String(),
+		TenantID:      s.TenantID,
+		ServiceName:   s.ServiceName,
+		ServiceID:     serviceID,
+		Price:         s.Price,
+		Currency:      s.Currency.String(),
+		BillingPeriod: s.BillingPeriod.String(),
+		UserID:        s.UserID.String(),
+		StartDate:     start,
+		EndDate:       end,
+		CreatedAt:     s.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:     s.UpdatedAt.Format(time.RFC3339),
+		DeletedAt:     deleted,
 	}
 }
 
-var _ = usecase.CreateInput{}
+type monthlyCostDTO struct {
+	Month         string `json:"month"`
+	Total         int64  `json:"total"`
+	Subscriptions int    `json:"subscriptions"`
+}
+
+type summaryDTO struct {
+	Total    int64  `json:"total"`
+	Currency string `json:"currency"`
+}
+
+type monthlySummaryDTO struct {
+	Total    int64            `json:"total"`
+	Currency string           `json:"currency"`
+	Months   []monthlyCostDTO `json:"months"`
+}
+
+func toMonthlyDTO(res usecase.MonthlyResult) monthlySummaryDTO {
+	out := monthlySummaryDTO{Currency: res.Currency.String(), Months: make([]monthlyCostDTO, 0, len(res.Months))}
+	for _, mc := range res.Months {
+		out.Total += mc.Total
+		out.Months = append(out.Months, monthlyCostDTO{
+			Month:         mc.Month.String(),
+			Total:         mc.Total,
+			Subscriptions: mc.Subscriptions,
+		})
+	}
+	return out
+}
+
+type groupCostDTO struct {
+	Key               string `json:"key"`
+	Total             int64  `json:"total"`
+	Months            int    `json:"months"`
+	SubscriptionCount int    `json:"subscription_count"`
+}
+
+type groupedSummaryDTO struct {
+	GroupBy  string         `json:"group_by"`
+	Currency string         `json:"currency"`
+	Groups   []groupCostDTO `json:"groups"`
+}
+
+func toGroupedDTO(groupBy string, res usecase.GroupedResult) groupedSummaryDTO {
+	out := groupedSummaryDTO{GroupBy: groupBy, Currency: res.Currency.String(), Groups: make([]groupCostDTO, 0, len(res.Groups))}
+	for _, g := range res.Groups {
+		out.Groups = append(out.Groups, groupCostDTO{
+			Key:               g.Key,
+			Total:             g.Total,
+			Months:            g.Months,
+			SubscriptionCount: g.Subscriptions,
+		})
+	}
+	return out
+}
+
+type priceChangeDTO struct {
+	EffectiveFrom string `json:"effective_from"`
+	Price         int    `json:"price"`
+}
+
+func toPriceDTO(pc domain.PriceChange) priceChangeDTO {
+	return priceChangeDTO{EffectiveFrom: pc.EffectiveFrom.String(), Price: pc.Price}
+}
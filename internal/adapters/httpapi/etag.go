@@ -0,0 +1,44 @@
+package httpapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+)
+
+// etag — сильный ETag подписки, построенный по её версии.
+func etag(s *domain.Subscription) string {
+	return `"` + strconv.FormatInt(s.Version, 10) + `"`
+}
+
+// ifMatch возвращает версию из заголовка If-Match; 0 — заголовка нет или он равен "*".
+// Слабые ETag по RFC 9110 для If-Match не совпадают ни с чем.
+func ifMatch(r *http.Request) (int64, error) {
+	return parseIfMatch(r.Header.Get("If-Match"))
+}
+
+func parseIfMatch(h string) (int64, error) {
+	h = strings.TrimSpace(h)
+	if h == "" || h == "*" {
+		return 0, nil
+	}
+	if strings.HasPrefix(h, "W/") {
+		return 0, domain.ErrVersionMismatch
+	}
+	v, err := strconv.Unquote(h)
+	if err != nil || !strings.HasPrefix(h, `"`) {
+		return 0, domain.Errorf(domain.ErrValidation, "If-Match must be a quoted ETag")
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 1 {
+		return 0, domain.ErrVersionMismatch
+	}
+	return n, nil
+}
+
+func writeSub(w http.ResponseWriter, status int, s *domain.Subscription) {
+	w.Header().Set("ETag", etag(s))
+	writeJSON(w, status, toDTO(s))
+}
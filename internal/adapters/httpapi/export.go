@@ -0,0 +1,116 @@
+package httpapi
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+
+	"github.com/oziev02/subscriptions-service/internal/adapters/importfile"
+	"github.com/oziev02/subscriptions-service/internal/domain"
+)
+
+// exportColumns — колонки CSV выгрузки; файл можно загрузить обратно через импорт.
+var exportColumns = []string{"id", "service_name", "price", "currency", "billing_period", "user_id",
+	"start_date", "end_date", "created_at", "updated_at", "deleted_at", "version"}
+
+// exportFlush — через сколько строк выгрузка отправляется клиенту.
+const exportFlush = 500
+
+// export выгружает все подписки, подходящие под фильтр списка, в CSV или NDJSON. Ответ пишется
+// потоком; если хранилище отказало после начала выгрузки, соединение обрывается, и клиент
+// получает неполный файл без завершающего блока chunked-кодирования.
+func (s *Server) export(w http.ResponseWriter, r *http.Request) {
+	f, err := listFilter(r)
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	format := importfile.CSV
+	if q := r.URL.Query().Get("format"); q != "" {
+		if format, err = importfile.ParseFormat(q); err != nil {
+			s.writeErr(w, r, err)
+			return
+		}
+	}
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{}) // выгрузка может идти дольше WriteTimeout сервера
+
+	bw := bufio.NewWriter(w)
+	cw := csv.NewWriter(bw)
+	enc := json.NewEncoder(bw)
+	started, n := false, 0
+	start := func() error {
+		started = true
+		name := "subscriptions-" + time.Now().UTC().Format("20060102-150405") + "." + string(format)
+		w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+		if format == importfile.CSV {
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			return cw.Write(exportColumns)
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		return nil
+	}
+	err = s.uc.Export(r.Context(), f, func(sub *domain.Subscription) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		var err error
+		if format == importfile.CSV {
+			err = cw.Write(exportRecord(sub))
+		} else {
+			err = enc.Encode(toDTO(sub))
+		}
+		if err != nil {
+			return err
+		}
+		if n++; n%exportFlush == 0 {
+			cw.Flush()
+			if err := bw.Flush(); err != nil {
+				return err
+			}
+			if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil && !started {
+		err = start()
+	}
+	if err == nil {
+		cw.Flush()
+		err = bw.Flush()
+	}
+	switch {
+	case err == nil:
+	case !started:
+		s.writeErr(w, r, err)
+	default:
+		s.log.Error("export aborted",
+			zap.String("request_id", middleware.GetReqID(r.Context())),
+			zap.Int("rows", n),
+			zap.Error(err))
+		panic(http.ErrAbortHandler)
+	}
+}
+
+func exportRecord(sub *domain.Subscription) []string {
+	dto := toDTO(sub)
+	deref := func(p *string) string {
+		if p == nil {
+			return ""
+		}
+		return *p
+	}
+	return []string{dto.ID, dto.ServiceName, strconv.Itoa(dto.Price), dto.Currency, dto.BillingPeriod, dto.UserID,
+		dto.StartDate, deref(dto.EndDate), dto.CreatedAt, dto.UpdatedAt, deref(dto.DeletedAt), strconv.FormatInt(sub.Version, 10)}
+}
@@ -2,15 +2,85 @@ package httpapi
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
 )
 
+type errorResp struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+}
+
 func writeJSON(w http.ResponseWriter, status int, v any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	_ = json.NewEncoder(w).Encode(v)
 }
 
-func writeErr(w http.ResponseWriter, status int, err error) {
-	writeJSON(w, status, map[string]string{"error": err.Error()})
+// errorStatus — единственное место, где доменные ошибки превращаются в HTTP-статус и код.
+func errorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, domain.ErrValidation):
+		return http.StatusBadRequest, "validation_error"
+	case errors.Is(err, domain.ErrUnauthenticated):
+		return http.StatusUnauthorized, "unauthenticated"
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden, "forbidden"
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound, "not_found"
+	case errors.Is(err, domain.ErrConflict):
+		return http.StatusConflict, "conflict"
+	case errors.Is(err, domain.ErrPrecondition):
+		return http.StatusPreconditionFailed, "precondition_failed"
+	case errors.Is(err, domain.ErrUnavailable):
+		return http.StatusServiceUnavailable, "unavailable"
+	default:
+		return http.StatusInternalServerError, "internal"
+	}
+}
+
+func (s *Server) writeErr(w http.ResponseWriter, r *http.Request, err error) {
+	status, body := s.errorBody(r, err)
+	writeJSON(w, status, body)
+}
+
+// errorBody строит тело ответа об ошибке; 5xx логируются, сообщение 500 скрывается.
+func (s *Server) errorBody(r *http.Request, err error) (int, errorResp) {
+	status, code := errorStatus(err)
+	msg := err.Error()
+	if status >= http.StatusInternalServerError {
+		s.log.Error("request failed",
+			zap.String("request_id", middleware.GetReqID(r.Context())),
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+			zap.Error(err))
+		if status == http.StatusInternalServerError {
+			msg = "internal error"
+		}
+	}
+	return status, errorResp{Error: msg, Code: code}
+}
+
+// queryBool разбирает необязательный логический параметр запроса; без параметра — false.
+func queryBool(r *http.Request, name string) (bool, error) {
+	q := r.URL.Query().Get(name)
+	if q == "" {
+		return false, nil
+	}
+	v, err := strconv.ParseBool(q)
+	if err != nil {
+		return false, domain.Errorf(domain.ErrValidation, "%s must be a boolean", name)
+	}
+	return v, nil
+}
+
+// badRequest помечает ошибку разбора запроса как ошибку валидации.
+func badRequest(err error) error {
+	return &domain.Error{Kind: domain.ErrValidation, Err: err}
 }
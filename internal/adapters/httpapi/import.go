@@ -0,0 +1,74 @@
+package httpapi
+
+import (
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/oziev02/subscriptions-service/internal/adapters/importfile"
+	"github.com/oziev02/subscriptions-service/internal/domain"
+)
+
+type importErrorDTO struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+	Code  string `json:"code"`
+}
+
+type importResp struct {
+	Rows     int              `json:"rows"`
+	Imported int              `json:"imported"`
+	Failed   int              `json:"failed"`
+	DryRun   bool             `json:"dry_run"`
+	Errors   []importErrorDTO `json:"errors"`
+}
+
+// importSubs создаёт подписки из CSV или NDJSON в теле запроса. Формат задаётся параметром format
+// или заголовком Content-Type. Строки с ошибками пропускаются и перечисляются в ответе.
+func (s *Server) importSubs(w http.ResponseWriter, r *http.Request) {
+	format, err := importFormat(r)
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	dryRun, err := queryBool(r, "dry_run")
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	// Тело читается потоком и может идти дольше ReadTimeout сервера, а ответ пишется после импорта.
+	rc := http.NewResponseController(w)
+	_ = rc.SetReadDeadline(time.Time{})
+	_ = rc.SetWriteDeadline(time.Time{})
+	src, err := importfile.NewSource(format, r.Body)
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	rep, err := s.uc.Import(r.Context(), src, dryRun)
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+
+	resp := importResp{Rows: rep.Rows, Imported: rep.Imported, Failed: rep.Failed, DryRun: dryRun,
+		Errors: make([]importErrorDTO, 0, len(rep.Errors))}
+	for _, e := range rep.Errors {
+		_, body := s.errorBody(r, e.Err)
+		resp.Errors = append(resp.Errors, importErrorDTO{Line: e.Line, Error: body.Error, Code: body.Code})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func importFormat(r *http.Request) (importfile.Format, error) {
+	if f := r.URL.Query().Get("format"); f != "" {
+		return importfile.ParseFormat(f)
+	}
+	switch mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt {
+	case "text/csv":
+		return importfile.CSV, nil
+	case "application/x-ndjson", "application/jsonl":
+		return importfile.NDJSON, nil
+	}
+	return "", domain.Errorf(domain.ErrValidation, "format must be one of: csv, ndjson")
+}
@@ -0,0 +1,179 @@
+package httpapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+// listResp — страница списка. next_cursor передаётся в параметре cursor для получения следующей
+// страницы и отсутствует на последней; total есть только при with_total=true.
+type listResp struct {
+	Items      []subDTO `json:"items"`
+	Limit      int      `json:"limit"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+	Total      *int     `json:"total,omitempty"`
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request) {
+	f, err := listFilter(r)
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	page, err := s.uc.List(r.Context(), f)
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toListResp(page))
+}
+
+func toListResp(page usecase.ListPage) listResp {
+	resp := listResp{Items: make([]subDTO, 0, len(page.Items)), Limit: page.Limit, NextCursor: page.NextCursor, Total: page.Total}
+	for _, s := range page.Items {
+		resp.Items = append(resp.Items, toDTO(s))
+	}
+	return resp
+}
+
+// listFilter разбирает параметры фильтра списка из запроса.
+func listFilter(r *http.Request) (usecase.ListFilter, error) {
+	var f usecase.ListFilter
+	q := r.URL.Query()
+	// user_id можно повторить или перечислить через запятую.
+	for _, v := range q["user_id"] {
+		for _, uid := range strings.Split(v, ",") {
+			id, err := uuid.Parse(strings.TrimSpace(uid))
+			if err != nil {
+				return f, badRequest(err)
+			}
+			f.UserIDs = append(f.UserIDs, id)
+		}
+	}
+	if sn := q.Get("service_name"); sn != "" {
+		f.ServiceName = &sn
+	}
+	if sn := q.Get("service_name_exact"); sn != "" {
+		f.ServiceNameExact = &sn
+	}
+	if v := q.Get("service_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return f, badRequest(err)
+		}
+		f.ServiceID = &id
+	}
+	if l := q.Get("limit"); l != "" {
+		if n, err := strconv.Atoi(l); err == nil {
+			f.Limit = n
+		}
+	}
+	if o := q.Get("offset"); o != "" {
+		if n, err := strconv.Atoi(o); err == nil {
+			f.Offset = n
+		}
+	}
+
+	var err error
+	for _, p := range []struct {
+		name string
+		dst  **int
+	}{{"price_min", &f.PriceMin}, {"price_max", &f.PriceMax}} {
+		if *p.dst, err = queryInt(r, p.name); err != nil {
+			return f, err
+		}
+	}
+	for _, p := range []struct {
+		name    string
+		dst     **domain.Date
+		lastDay bool
+	}{
+		{"start_from", &f.StartFrom, false}, {"start_to", &f.StartTo, true},
+		{"end_from", &f.EndFrom, false}, {"end_to", &f.EndTo, true},
+	} {
+		if *p.dst, err = queryDate(r, p.name, p.lastDay); err != nil {
+			return f, err
+		}
+	}
+	if m := q.Get("active_in"); m != "" {
+		ym, err := domain.ParseYearMonth(m)
+		if err != nil {
+			return f, domain.Errorf(domain.ErrValidation, "active_in must be MM-YYYY")
+		}
+		f.ActiveIn = &ym
+	}
+	if f.CreatedFrom, err = queryTime(r, "created_from"); err != nil {
+		return f, err
+	}
+	if f.CreatedTo, err = queryTime(r, "created_to"); err != nil {
+		return f, err
+	}
+	if f.OpenEnded, err = queryBool(r, "open_ended"); err != nil {
+		return f, err
+	}
+	if f.Sort, err = usecase.ParseListSort(q.Get("sort")); err != nil {
+		return f, err
+	}
+	if c := q.Get("cursor"); c != "" {
+		if f.Cursor, err = usecase.DecodeCursor(c); err != nil {
+			return f, err
+		}
+	}
+	if f.WithTotal, err = queryBool(r, "with_total"); err != nil {
+		return f, err
+	}
+	f.IncludeDeleted, err = queryBool(r, "include_deleted")
+	return f, err
+}
+
+func queryInt(r *http.Request, name string) (*int, error) {
+	q := r.URL.Query().Get(name)
+	if q == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(q)
+	if err != nil {
+		return nil, domain.Errorf(domain.ErrValidation, "%s must be an integer", name)
+	}
+	return &n, nil
+}
+
+// queryDate разбирает границу диапазона дат: DD-MM-YYYY или MM-YYYY. Месяц означает его первый день,
+// а для верхней границы (lastDay) — последний.
+func queryDate(r *http.Request, name string, lastDay bool) (*domain.Date, error) {
+	q := r.URL.Query().Get(name)
+	if q == "" {
+		return nil, nil
+	}
+	ym, d, err := domain.ParseDateOrYearMonth(q)
+	if err != nil {
+		return nil, domain.Errorf(domain.ErrValidation, "%s must be MM-YYYY or DD-MM-YYYY", name)
+	}
+	if d == nil {
+		day := ym.FirstDay()
+		if lastDay {
+			day = ym.LastDay()
+		}
+		d = &day
+	}
+	return d, nil
+}
+
+func queryTime(r *http.Request, name string) (*time.Time, error) {
+	q := r.URL.Query().Get(name)
+	if q == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, q)
+	if err != nil {
+		return nil, domain.Errorf(domain.ErrValidation, "%s must be an RFC 3339 timestamp", name)
+	}
+	return &t, nil
+}
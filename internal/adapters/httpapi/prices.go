@@ -0,0 +1,66 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+type priceChangeReq struct {
+	Price         int    `json:"price"`
+	EffectiveFrom string `json:"effective_from"`
+}
+
+func (s *Server) listPrices(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	res, err := s.uc.PriceSchedule(r.Context(), id)
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	items := make([]priceChangeDTO, 0, len(res))
+	for _, pc := range res {
+		items = append(items, toPriceDTO(pc))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+func (s *Server) changePrice(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	var req priceChangeReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	pc, err := s.uc.ChangePrice(r.Context(), id, usecase.PriceChangeInput{Price: req.Price, EffectiveFrom: req.EffectiveFrom})
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toPriceDTO(*pc))
+}
+
+func (s *Server) deletePrice(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	if err := s.uc.DeletePriceChange(r.Context(), id, chi.URLParam(r, "effective_from")); err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,90 @@
+package httpapi
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+type rateReq struct {
+	From  string      `json:"from"`
+	To    string      `json:"to"`
+	Month string      `json:"month"`
+	Rate  json.Number `json:"rate"`
+}
+
+// uploadRates принимает курсы в JSON ({"rates": [...]}) или CSV с заголовком from,to,month,rate.
+func (s *Server) uploadRates(w http.ResponseWriter, r *http.Request) {
+	var in []usecase.RateInput
+	var err error
+	mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mt == "text/csv" {
+		in, err = readRatesCSV(r.Body)
+	} else {
+		in, err = readRatesJSON(r.Body)
+	}
+	if err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	n, err := s.uc.UploadRates(r.Context(), in)
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"uploaded": n})
+}
+
+func readRatesJSON(body io.Reader) ([]usecase.RateInput, error) {
+	var req struct {
+		Rates []rateReq `json:"rates"`
+	}
+	if err := json.NewDecoder(body).Decode(&req); err != nil {
+		return nil, err
+	}
+	in := make([]usecase.RateInput, 0, len(req.Rates))
+	for _, rr := range req.Rates {
+		in = append(in, usecase.RateInput{From: rr.From, To: rr.To, Month: rr.Month, Rate: rr.Rate.String()})
+	}
+	return in, nil
+}
+
+func readRatesCSV(body io.Reader) ([]usecase.RateInput, error) {
+	cr := csv.NewReader(body)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	col := make(map[string]int, len(header))
+	for i, h := range header {
+		col[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, name := range []string{"from", "to", "month", "rate"} {
+		if _, ok := col[name]; !ok {
+			return nil, errors.New("csv header must contain from,to,month,rate")
+		}
+	}
+	var in []usecase.RateInput
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return in, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		in = append(in, usecase.RateInput{
+			From:  rec[col["from"]],
+			To:    rec[col["to"]],
+			Month: rec[col["month"]],
+			Rate:  rec[col["rate"]],
+		})
+	}
+}
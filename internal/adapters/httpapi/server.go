@@ -16,187 +16,280 @@ import (
 )
 
 type Server struct {
-	cfg *config.Config
-	log *zap.Logger
-	uc  *usecase.Service
+	cfg  *config.Config
+	log  *zap.Logger
+	uc   *usecase.Service
+	auth []Authenticator
 }
 
-func NewServer(cfg *config.Config, log *zap.Logger, repo usecase.SubscriptionRepo) *Server {
-	return &Server{cfg: cfg, log: log, uc: usecase.NewService(repo)}
+// NewServer собирает HTTP API. Без auth запросы не аутентифицируются.
+func NewServer(cfg *config.Config, log *zap.Logger, repos usecase.Repos, auth ...Authenticator) *Server {
+	return &Server{cfg: cfg, log: log, uc: usecase.NewService(repos), auth: auth}
 }
 
 func (s *Server) Router() http.Handler {
 	r := chi.NewRouter()
-	r.Use(middleware.RequestID, middleware.RealIP, middleware.Logger, middleware.Recoverer, middleware.Timeout(60e9))
-	r.Get("/v1/health", func(w http.ResponseWriter, r *http.Request) {
+	r.Use(middleware.RequestID, middleware.RealIP, middleware.Logger, middleware.Recoverer)
+	timeout := middleware.Timeout(60e9)
+	r.With(timeout).Get("/v1/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("ok"))
 	})
-
-	r.Route("/v1/subscriptions", func(r chi.Router) {
-		r.Get("/", s.list)
-		r.Post("/", s.create)
-		r.Get("/summary", s.summary)
-		r.Route("/{id}", func(r chi.Router) {
-			r.Get("/", s.get)
-			r.Put("/", s.update)
-			r.Delete("/", s.delete)
-		})
-	})
 	// serve swagger spec
-	r.Get("/swagger.yaml", func(w http.ResponseWriter, r *http.Request) {
+	r.With(timeout).Get("/swagger.yaml", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/yaml")
 		http.ServeFile(w, r, "./docs/openapi.yaml")
 	})
+
+	r.With(s.authenticate, auditMeta).Route("/v1/subscriptions", func(r chi.Router) {
+		// Загрузка и выгрузка файлов идут потоком и не ограничены по времени.
+		r.Post("/import", s.importSubs)
+		r.Get("/export", s.export)
+		r.Group(func(r chi.Router) {
+			r.Use(timeout)
+			r.Get("/", s.list)
+			r.Post("/", s.create)
+			r.Get("/summary", s.summary)
+			r.Get("/summary/monthly", s.monthlySummary)
+			r.Route("/{id}", func(r chi.Router) {
+				r.Get("/", s.get)
+				r.Put("/", s.replace)
+				r.Patch("/", s.patch)
+				r.Delete("/", s.delete)
+				r.Post("/restore", s.restore)
+				r.Get("/prices", s.listPrices)
+				r.Post("/prices", s.changePrice)
+				r.Delete("/prices/{effective_from}", s.deletePrice)
+				r.Get("/history", s.history)
+			})
+		})
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(timeout, s.authenticate, auditMeta)
+		r.Post("/v1/subscriptions:batch", s.batch)
+		r.Route("/v1/organizations", func(r chi.Router) {
+			r.Get("/", s.listOrgs)
+			r.Post("/", s.createOrg)
+			r.Route("/{org_id}", func(r chi.Router) {
+				r.Get("/", s.getOrg)
+				r.Put("/", s.updateOrg)
+				r.Delete("/", s.deleteOrg)
+				r.Get("/teams", s.listTeams)
+				r.Post("/teams", s.createTeam)
+				r.Get("/cost-tree", s.orgCostTree)
+			})
+		})
+		r.Route("/v1/teams/{team_id}", func(r chi.Router) {
+			r.Get("/", s.getTeam)
+			r.Put("/", s.updateTeam)
+			r.Delete("/", s.deleteTeam)
+			r.Get("/cost-tree", s.teamCostTree)
+		})
+		r.Route("/v1/users", func(r chi.Router) {
+			r.Get("/", s.listUsers)
+			r.Post("/", s.createUser)
+			r.Route("/{user_id}", func(r chi.Router) {
+				r.Get("/", s.getUser)
+				r.Put("/", s.updateUser)
+				r.Delete("/", s.deleteUser)
+				r.Get("/subscriptions", s.userSubscriptions)
+				r.Get("/subscriptions/active", s.activeAt)
+			})
+		})
+		r.Route("/v1/services", func(r chi.Router) {
+			r.Get("/", s.listServices)
+			r.Post("/", s.createService)
+			r.Get("/{id}", s.getService)
+			r.Put("/{id}", s.updateService)
+			r.Delete("/{id}", s.deleteService)
+		})
+		r.Post("/v1/exchange-rates", s.uploadRates)
+		r.Route("/v1/api-keys", func(r chi.Router) {
+			r.Get("/", s.listAPIKeys)
+			r.Post("/", s.createAPIKey)
+			r.Delete("/{id}", s.revokeAPIKey)
+		})
+	})
 	return r
 }
 
 type createReq struct {
-	ServiceName string    `json:"service_name"`
-	Price       int       `json:"price"`
-	UserID      uuid.UUID `json:"user_id"`
-	StartDate   string    `json:"start_date"`
-	EndDate     *string   `json:"end_date,omitempty"`
+	ServiceName   string     `json:"service_name"`
+	ServiceID     *uuid.UUID `json:"service_id,omitempty"`
+	Price         int        `json:"price"`
+	Currency      string     `json:"currency,omitempty"`
+	BillingPeriod string     `json:"billing_period,omitempty"`
+	UserID        uuid.UUID  `json:"user_id"`
+	StartDate     string     `json:"start_date"`
+	EndDate       *string    `json:"end_date,omitempty"`
+}
+
+func (req createReq) input() usecase.CreateInput {
+	return usecase.CreateInput{
+		ServiceName:   req.ServiceName,
+		ServiceID:     req.ServiceID,
+		Price:         req.Price,
+		Currency:      req.Currency,
+		BillingPeriod: req.BillingPeriod,
+		UserID:        req.UserID,
+		StartDate:     req.StartDate,
+		EndDate:       req.EndDate,
+	}
 }
 
 func (s *Server) create(w http.ResponseWriter, r *http.Request) {
 	var req createReq
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeErr(w, http.StatusBadRequest, err)
+		s.writeErr(w, r, badRequest(err))
 		return
 	}
-	out, err := s.uc.Create(r.Context(), usecase.CreateInput{
-		ServiceName: req.ServiceName,
-		Price:       req.Price,
-		UserID:      req.UserID,
-		StartDate:   req.StartDate,
-		EndDate:     req.EndDate,
-	})
+	out, err := s.uc.Create(r.Context(), req.input())
 	if err != nil {
-		writeErr(w, http.StatusBadRequest, err)
+		s.writeErr(w, r, err)
 		return
 	}
-	writeJSON(w, http.StatusCreated, toDTO(out))
+	writeSub(w, http.StatusCreated, out)
 }
 
 func (s *Server) get(w http.ResponseWriter, r *http.Request) {
 	id, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
-		writeErr(w, http.StatusBadRequest, err)
+		s.writeErr(w, r, badRequest(err))
 		return
 	}
-	res, err := s.uc.Get(r.Context(), id)
+	includeDeleted, err := queryBool(r, "include_deleted")
 	if err != nil {
-		writeErr(w, http.StatusNotFound, err)
+		s.writeErr(w, r, err)
 		return
 	}
-	writeJSON(w, http.StatusOK, toDTO(res))
-}
-
-type updateReq struct {
-	ServiceName *string `json:"service_name,omitempty"`
-	Price       *int    `json:"price,omitempty"`
-	StartDate   *string `json:"start_date,omitempty"`
-	EndDate     *string `json:"end_date"` // may be null or ""
+	res, err := s.uc.Get(r.Context(), id, includeDeleted)
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	writeSub(w, http.StatusOK, res)
 }
 
-func (s *Server) update(w http.ResponseWriter, r *http.Request) {
+func (s *Server) delete(w http.ResponseWriter, r *http.Request) {
 	id, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
-		writeErr(w, http.StatusBadRequest, err)
+		s.writeErr(w, r, badRequest(err))
 		return
 	}
-	var req updateReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeErr(w, http.StatusBadRequest, err)
+	version, err := ifMatch(r)
+	if err != nil {
+		s.writeErr(w, r, err)
 		return
 	}
-	res, err := s.uc.Update(r.Context(), id, usecase.UpdateInput{
-		ServiceName: req.ServiceName,
-		Price:       req.Price,
-		StartDate:   req.StartDate,
-		EndDate:     req.EndDate,
-		EndDateSet:  true,
-	})
-	if err != nil {
-		writeErr(w, http.StatusBadRequest, err)
+	if err := s.uc.Delete(r.Context(), id, version); err != nil {
+		s.writeErr(w, r, err)
 		return
 	}
-	writeJSON(w, http.StatusOK, toDTO(res))
+	w.WriteHeader(http.StatusNoContent)
 }
 
-func (s *Server) delete(w http.ResponseWriter, r *http.Request) {
+func (s *Server) restore(w http.ResponseWriter, r *http.Request) {
 	id, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
-		writeErr(w, http.StatusBadRequest, err)
+		s.writeErr(w, r, badRequest(err))
 		return
 	}
-	if err := s.uc.Delete(r.Context(), id); err != nil {
-		writeErr(w, http.StatusNotFound, err)
+	res, err := s.uc.Restore(r.Context(), id)
+	if err != nil {
+		s.writeErr(w, r, err)
 		return
 	}
-	w.WriteHeader(http.StatusNoContent)
+	writeSub(w, http.StatusOK, res)
 }
 
-func (s *Server) list(w http.ResponseWriter, r *http.Request) {
-	var f usecase.ListFilter
-	if uid := r.URL.Query().Get("user_id"); uid != "" {
-		id, err := uuid.Parse(uid)
+func (s *Server) summary(w http.ResponseWriter, r *http.Request) {
+	in, err := summaryInput(r)
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	if gb := r.URL.Query().Get("group_by"); gb != "" {
+		limit := 0
+		if l := r.URL.Query().Get("limit"); l != "" {
+			if limit, err = strconv.Atoi(l); err != nil {
+				s.writeErr(w, r, badRequest(err))
+				return
+			}
+		}
+		res, err := s.uc.GroupedSummary(r.Context(), in, usecase.SummaryGroupBy(gb), limit)
 		if err != nil {
-			writeErr(w, http.StatusBadRequest, err)
+			s.writeErr(w, r, err)
 			return
 		}
-		f.UserID = &id
-	}
-	if sn := r.URL.Query().Get("service_name"); sn != "" {
-		f.ServiceName = &sn
-	}
-	if l := r.URL.Query().Get("limit"); l != "" {
-		if n, err := strconv.Atoi(l); err == nil {
-			f.Limit = n
-		}
+		writeJSON(w, http.StatusOK, toGroupedDTO(gb, res))
+		return
 	}
-	if o := r.URL.Query().Get("offset"); o != "" {
-		if n, err := strconv.Atoi(o); err == nil {
-			f.Offset = n
-		}
+	sum, err := s.uc.Summary(r.Context(), in)
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
 	}
-	res, err := s.uc.List(r.Context(), f)
+	writeJSON(w, http.StatusOK, summaryDTO{Total: sum.Total, Currency: sum.Currency.String()})
+}
+
+func (s *Server) monthlySummary(w http.ResponseWriter, r *http.Request) {
+	in, err := summaryInput(r)
 	if err != nil {
-		writeErr(w, http.StatusInternalServerError, err)
+		s.writeErr(w, r, err)
 		return
 	}
-	items := make([]any, 0, len(res))
-	for _, s := range res {
-		items = append(items, toDTO(s))
+	res, err := s.uc.MonthlySummary(r.Context(), in)
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+	writeJSON(w, http.StatusOK, toMonthlyDTO(res))
 }
 
-func (s *Server) summary(w http.ResponseWriter, r *http.Request) {
-	from := r.URL.Query().Get("from")
-	to := r.URL.Query().Get("to")
-	if from == "" || to == "" {
-		writeErr(w, http.StatusBadRequest, errors.New("from/to are required"))
-		return
+func summaryInput(r *http.Request) (usecase.SummaryInput, error) {
+	in := usecase.SummaryInput{
+		From: r.URL.Query().Get("from"),
+		To:   r.URL.Query().Get("to"),
+	}
+	if in.From == "" || in.To == "" {
+		return in, badRequest(errors.New("from/to are required"))
 	}
-	var uid *uuid.UUID
 	if q := r.URL.Query().Get("user_id"); q != "" {
 		id, err := uuid.Parse(q)
 		if err != nil {
-			writeErr(w, http.StatusBadRequest, err)
-			return
+			return in, badRequest(err)
 		}
-		uid = &id
+		in.UserID = &id
 	}
-	var sn *string
 	if q := r.URL.Query().Get("service_name"); q != "" {
-		sn = &q
+		in.ServiceName = &q
 	}
-	sum, err := s.uc.Summary(r.Context(), from, to, uid, sn)
-	if err != nil {
-		writeErr(w, http.StatusBadRequest, err)
-		return
+	if q := r.URL.Query().Get("service_id"); q != "" {
+		id, err := uuid.Parse(q)
+		if err != nil {
+			return in, badRequest(err)
+		}
+		in.ServiceID = &id
+	}
+	if q := r.URL.Query().Get("team_id"); q != "" {
+		id, err := uuid.Parse(q)
+		if err != nil {
+			return in, badRequest(err)
+		}
+		in.TeamID = &id
+	}
+	if q := r.URL.Query().Get("currency"); q != "" {
+		in.Currency = &q
+	}
+	if q := r.URL.Query().Get("target_currency"); q != "" {
+		in.TargetCurrency = &q
+	}
+	if q := r.URL.Query().Get("mode"); q != "" {
+		in.Mode = &q
+	}
+	var err error
+	if in.IncludeDeleted, err = queryBool(r, "include_deleted"); err != nil {
+		return in, err
 	}
-	writeJSON(w, http.StatusOK, map[string]int64{"total": sum})
+	return in, nil
 }
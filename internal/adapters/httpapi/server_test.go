@@ -0,0 +1,986 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/oziev02/subscriptions-service/internal/adapters/repo/memory"
+	"github.com/oziev02/subscriptions-service/internal/pkg/config"
+	"github.com/oziev02/subscriptions-service/internal/pkg/jwt"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	api := NewServer(&config.Config{}, zap.NewNop(), memory.NewRepos())
+	srv := httptest.NewServer(api.Router())
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// mustUser создаёт пользователя с заданным ID, чтобы на него можно было оформлять подписки.
+func mustUser(t *testing.T, srv *httptest.Server, id string) string {
+	t.Helper()
+	if code := doJSON(t, http.MethodPost, srv.URL+"/v1/users", map[string]any{"id": id, "name": "User " + id[:8]}, nil); code != http.StatusCreated {
+		t.Fatalf("create user: status %d", code)
+	}
+	return id
+}
+
+func doJSON(t *testing.T, method, url string, body any, out any) int {
+	t.Helper()
+	return doJSONWith(t, nil, method, url, body, out)
+}
+
+// doJSONWith — doJSON с дополнительными заголовками запроса.
+func doJSONWith(t *testing.T, header http.Header, method, url string, body any, out any) int {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req, err := http.NewRequest(method, url, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestCreateGetSummary(t *testing.T) {
+	srv := newTestServer(t)
+	userID := mustUser(t, srv, "60601fee-2bf1-4721-ae6f-7636e79a0cba")
+
+	var created subDTO
+	code := doJSON(t, http.MethodPost, srv.URL+"/v1/subscriptions", map[string]any{
+		"service_name": "Yandex Plus",
+		"price":        400,
+		"user_id":      userID,
+		"start_date":   "07-2025",
+	}, &created)
+	if code != http.StatusCreated {
+		t.Fatalf("create: status %d", code)
+	}
+
+	var got subDTO
+	if code := doJSON(t, http.MethodGet, srv.URL+"/v1/subscriptions/"+created.ID, nil, &got); code != http.StatusOK {
+		t.Fatalf("get: status %d", code)
+	}
+	if got.ServiceName != "Yandex Plus" || got.StartDate != "07-2025" {
+		t.Fatalf("unexpected subscription: %+v", got)
+	}
+
+	var sum summaryDTO
+	code = doJSON(t, http.MethodGet, srv.URL+"/v1/subscriptions/summary?from=07-2025&to=09-2025&user_id="+userID, nil, &sum)
+	if code != http.StatusOK {
+		t.Fatalf("summary: status %d", code)
+	}
+	if sum.Total != 1200 || sum.Currency != "RUB" {
+		t.Fatalf("summary = %+v, want 1200 RUB", sum)
+	}
+}
+
+func TestSummaryRefusesToMixCurrencies(t *testing.T) {
+	srv := newTestServer(t)
+	userID := mustUser(t, srv, uuid.NewString())
+	for _, c := range []struct {
+		price    int
+		currency string
+	}{{400, "RUB"}, {10, "usd"}} {
+		code := doJSON(t, http.MethodPost, srv.URL+"/v1/subscriptions", map[string]any{
+			"service_name": "Service " + c.currency,
+			"price":        c.price,
+			"currency":     c.currency,
+			"user_id":      userID,
+			"start_date":   "07-2025",
+		}, nil)
+		if code != http.StatusCreated {
+			t.Fatalf("create %s: status %d", c.currency, code)
+		}
+	}
+
+	base := srv.URL + "/v1/subscriptions/summary?from=07-2025&to=08-2025&user_id=" + userID
+	var errBody errorResp
+	if code := doJSON(t, http.MethodGet, base, nil, &errBody); code != http.StatusBadRequest {
+		t.Fatalf("mixed currencies: status %d, body %+v", code, errBody)
+	}
+	var sum summaryDTO
+	if code := doJSON(t, http.MethodGet, base+"&currency=USD", nil, &sum); code != http.StatusOK {
+		t.Fatalf("usd summary: status %d", code)
+	}
+	if sum.Total != 20 || sum.Currency != "USD" {
+		t.Fatalf("summary = %+v, want 20 USD", sum)
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	srv := newTestServer(t)
+	cases := []struct {
+		method, path string
+		body         any
+		status       int
+		code         string
+	}{
+		{http.MethodGet, "/v1/subscriptions/" + uuid.NewString(), nil, http.StatusNotFound, "not_found"},
+		{http.MethodDelete, "/v1/subscriptions/" + uuid.NewString(), nil, http.StatusNotFound, "not_found"},
+		{http.MethodPut, "/v1/subscriptions/" + uuid.NewString(), map[string]any{"service_name": "X", "price": 1, "start_date": "07-2025"}, http.StatusNotFound, "not_found"},
+		{http.MethodPatch, "/v1/subscriptions/" + uuid.NewString(), map[string]any{"price": 1}, http.StatusNotFound, "not_found"},
+		{http.MethodGet, "/v1/subscriptions/not-a-uuid", nil, http.StatusBadRequest, "validation_error"},
+		{http.MethodPost, "/v1/subscriptions", map[string]any{"service_name": "X", "price": -1, "user_id": uuid.NewString(), "start_date": "07-2025"}, http.StatusBadRequest, "validation_error"},
+		{http.MethodGet, "/v1/subscriptions/summary?from=07-2025", nil, http.StatusBadRequest, "validation_error"},
+	}
+	for _, c := range cases {
+		var resp errorResp
+		if status := doJSON(t, c.method, srv.URL+c.path, c.body, &resp); status != c.status || resp.Code != c.code {
+			t.Errorf("%s %s: got %d %q, want %d %q", c.method, c.path, status, resp.Code, c.status, c.code)
+		}
+	}
+}
+
+func TestHistorySurvivesDelete(t *testing.T) {
+	srv := newTestServer(t)
+
+	var created subDTO
+	doJSON(t, http.MethodPost, srv.URL+"/v1/subscriptions", map[string]any{
+		"service_name": "Netflix",
+		"price":        500,
+		"user_id":      mustUser(t, srv, uuid.NewString()),
+		"start_date":   "01-2025",
+	}, &created)
+	put := map[string]any{"service_name": "Netflix", "price": 600, "start_date": "01-2025"}
+	if code := doJSON(t, http.MethodPut, srv.URL+"/v1/subscriptions/"+created.ID, put, nil); code != http.StatusOK {
+		t.Fatalf("replace: status %d", code)
+	}
+	if code := doJSON(t, http.MethodPatch, srv.URL+"/v1/subscriptions/"+created.ID, map[string]any{"price": 700}, nil); code != http.StatusOK {
+		t.Fatalf("patch: status %d", code)
+	}
+	if code := doJSON(t, http.MethodDelete, srv.URL+"/v1/subscriptions/"+created.ID, nil, nil); code != http.StatusNoContent {
+		t.Fatalf("delete: status %d", code)
+	}
+
+	var hist struct {
+		Items []struct {
+			Operation string          `json:"operation"`
+			Actor     string          `json:"actor"`
+			RequestID string          `json:"request_id"`
+			Before    *subDTO         `json:"before"`
+			After     json.RawMessage `json:"after"`
+		} `json:"items"`
+	}
+	if code := doJSON(t, http.MethodGet, srv.URL+"/v1/subscriptions/"+created.ID+"/history", nil, &hist); code != http.StatusOK {
+		t.Fatalf("history: status %d", code)
+	}
+	if len(hist.Items) != 4 {
+		t.Fatalf("history has %d records, want 4", len(hist.Items))
+	}
+	for i, op := range []string{"create", "update", "update", "delete"} {
+		rec := hist.Items[i]
+		if rec.Operation != op || rec.Actor != usecase.AnonymousActor || rec.RequestID == "" {
+			t.Fatalf("record %d = %+v", i, rec)
+		}
+	}
+	if hist.Items[1].Before.Price != 500 || hist.Items[2].Before.Price != 600 || hist.Items[3].Before.Price != 700 || string(hist.Items[3].After) != "null" {
+		t.Fatalf("unexpected snapshots: %+v", hist.Items)
+	}
+
+	if code := doJSON(t, http.MethodGet, srv.URL+"/v1/subscriptions/"+uuid.NewString()+"/history", nil, nil); code != http.StatusNotFound {
+		t.Fatalf("history of unknown subscription: status %d", code)
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	srv := newTestServer(t)
+
+	var created subDTO
+	doJSON(t, http.MethodPost, srv.URL+"/v1/subscriptions", map[string]any{
+		"service_name": "Netflix",
+		"price":        500,
+		"user_id":      mustUser(t, srv, uuid.NewString()),
+		"start_date":   "01-2025",
+	}, &created)
+	url := srv.URL + "/v1/subscriptions/" + created.ID
+
+	send := func(method, ifMatch, body string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, url, bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	if resp := send(http.MethodGet, "", ""); resp.Header.Get("ETag") != `"1"` {
+		t.Fatalf("get: ETag = %q", resp.Header.Get("ETag"))
+	}
+	resp := send(http.MethodPut, `"1"`, `{"service_name": "Netflix", "price": 600, "start_date": "01-2025"}`)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") != `"2"` {
+		t.Fatalf("put: status %d, ETag %q", resp.StatusCode, resp.Header.Get("ETag"))
+	}
+	if resp := send(http.MethodPut, `"1"`, `{"service_name": "Netflix", "price": 700, "start_date": "01-2025"}`); resp.StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("stale put: status %d", resp.StatusCode)
+	}
+	resp = send(http.MethodPatch, `"2"`, `{"price": 700}`)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") != `"3"` {
+		t.Fatalf("patch: status %d, ETag %q", resp.StatusCode, resp.Header.Get("ETag"))
+	}
+	if resp := send(http.MethodPatch, `"2"`, `{"price": 800}`); resp.StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("stale patch: status %d", resp.StatusCode)
+	}
+	if resp := send(http.MethodDelete, `"2"`, ""); resp.StatusCode != http.StatusPreconditionFailed {
+		t.Fatalf("stale delete: status %d", resp.StatusCode)
+	}
+	if resp := send(http.MethodDelete, `"3"`, ""); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("delete: status %d", resp.StatusCode)
+	}
+}
+
+func TestPatchMergesAndPutReplaces(t *testing.T) {
+	srv := newTestServer(t)
+
+	var created subDTO
+	doJSON(t, http.MethodPost, srv.URL+"/v1/subscriptions", map[string]any{
+		"service_name": "Netflix",
+		"price":        10,
+		"currency":     "USD",
+		"user_id":      mustUser(t, srv, uuid.NewString()),
+		"start_date":   "01-2025",
+		"end_date":     "12-2025",
+	}, &created)
+	url := srv.URL + "/v1/subscriptions/" + created.ID
+
+	var got subDTO
+	if code := doJSON(t, http.MethodPatch, url, map[string]any{"price": 12}, &got); code != http.StatusOK {
+		t.Fatalf("patch price: status %d", code)
+	}
+	if got.Price != 12 || got.Currency != "USD" || got.EndDate == nil || *got.EndDate != "12-2025" {
+		t.Fatalf("patch touched absent fields: %+v", got)
+	}
+	got = subDTO{}
+	if code := doJSON(t, http.MethodPatch, url, map[string]any{"end_date": nil}, &got); code != http.StatusOK || got.EndDate != nil {
+		t.Fatalf("patch end_date=null: status %d, %+v", code, got)
+	}
+
+	for _, body := range []map[string]any{
+		{"service_name": nil},
+		{"user_id": uuid.NewString()},
+	} {
+		if code := doJSON(t, http.MethodPatch, url, body, nil); code != http.StatusBadRequest {
+			t.Errorf("patch %v: status %d, want 400", body, code)
+		}
+	}
+
+	if code := doJSON(t, http.MethodPut, url, map[string]any{"price": 1}, nil); code != http.StatusBadRequest {
+		t.Fatalf("partial put: status %d, want 400", code)
+	}
+	got = subDTO{}
+	code := doJSON(t, http.MethodPut, url, map[string]any{"service_name": "Netflix", "price": 900, "start_date": "02-2025"}, &got)
+	if code != http.StatusOK || got.Currency != "RUB" || got.StartDate != "02-2025" || got.EndDate != nil {
+		t.Fatalf("put: status %d, %+v", code, got)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	srv := newTestServer(t)
+	user := mustUser(t, srv, uuid.NewString())
+	create := func(name string, price int) map[string]any {
+		return map[string]any{"op": "create", "subscription": map[string]any{
+			"service_name": name, "price": price, "user_id": user, "start_date": "01-2025",
+		}}
+	}
+	type result struct {
+		Status       int        `json:"status"`
+		Subscription *subDTO    `json:"subscription"`
+		ETag         string     `json:"etag"`
+		Error        *errorResp `json:"error"`
+	}
+	type response struct {
+		Succeeded, Failed int
+		Results           []result
+	}
+	batch := func(body map[string]any) response {
+		t.Helper()
+		var resp response
+		if code := doJSON(t, http.MethodPost, srv.URL+"/v1/subscriptions:batch", body, &resp); code != http.StatusOK {
+			t.Fatalf("batch: status %d", code)
+		}
+		return resp
+	}
+	listed := func() int {
+		var list struct{ Items []subDTO }
+		doJSON(t, http.MethodGet, srv.URL+"/v1/subscriptions?user_id="+user, nil, &list)
+		return len(list.Items)
+	}
+
+	ops := []any{create("Netflix", 500), create("Broken", -1)}
+	resp := batch(map[string]any{"operations": ops})
+	if resp.Failed != 2 || resp.Results[0].Error.Code != "conflict" || resp.Results[1].Status != http.StatusBadRequest {
+		t.Fatalf("atomic batch: %+v", resp)
+	}
+	if n := listed(); n != 0 {
+		t.Fatalf("atomic batch left %d subscriptions", n)
+	}
+
+	resp = batch(map[string]any{"mode": "best_effort", "operations": ops})
+	if resp.Succeeded != 1 || resp.Results[0].Status != http.StatusCreated || resp.Results[0].ETag != `"1"` {
+		t.Fatalf("best-effort batch: %+v", resp)
+	}
+	id := resp.Results[0].Subscription.ID
+
+	ops = []any{
+		map[string]any{"op": "update", "id": id, "if_match": `"1"`, "patch": map[string]any{"price": 600}},
+		create("Spotify", 200),
+	}
+	resp = batch(map[string]any{"operations": ops})
+	if resp.Succeeded != 2 || resp.Results[0].Subscription.Price != 600 || resp.Results[0].ETag != `"2"` {
+		t.Fatalf("update batch: %+v", resp)
+	}
+
+	ops = []any{map[string]any{"op": "delete", "id": id, "if_match": `"1"`}}
+	resp = batch(map[string]any{"operations": ops})
+	if resp.Results[0].Status != http.StatusPreconditionFailed {
+		t.Fatalf("stale delete: %+v", resp.Results[0])
+	}
+	ops = []any{map[string]any{"op": "delete", "id": id}}
+	resp = batch(map[string]any{"operations": ops})
+	if resp.Results[0].Status != http.StatusNoContent || listed() != 1 {
+		t.Fatalf("delete batch: %+v", resp.Results[0])
+	}
+	// версия удалённой подписки совпадает с той, что отдаёт карточка
+	if res := resp.Results[0]; res.ETag != `"3"` || res.Subscription == nil || res.Subscription.DeletedAt == nil {
+		t.Fatalf("delete result: %+v", res)
+	}
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/v1/subscriptions/"+id+"?include_deleted=true", nil)
+	got, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got.Body.Close()
+	if got.Header.Get("ETag") != resp.Results[0].ETag {
+		t.Fatalf("ETag after delete = %q, batch reported %q", got.Header.Get("ETag"), resp.Results[0].ETag)
+	}
+}
+
+func TestImportCSV(t *testing.T) {
+	srv := newTestServer(t)
+	mustUser(t, srv, "60601fee-2bf1-4721-ae6f-7636e79a0cba")
+	body := `service_name,price,user_id,start_date,end_date
+Netflix,599,60601fee-2bf1-4721-ae6f-7636e79a0cba,07-2025,
+Spotify,abc,60601fee-2bf1-4721-ae6f-7636e79a0cba,07-2025,
+Kinopoisk,299,60601fee-2bf1-4721-ae6f-7636e79a0cba,13-2025,
+Okko,199,60601fee-2bf1-4721-ae6f-7636e79a0cba,07-2025,12-2025
+`
+	post := func(query string) importResp {
+		t.Helper()
+		resp, err := http.Post(srv.URL+"/v1/subscriptions/import"+query, "text/csv", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status %d", resp.StatusCode)
+		}
+		var rep importResp
+		if err := json.NewDecoder(resp.Body).Decode(&rep); err != nil {
+			t.Fatal(err)
+		}
+		return rep
+	}
+	count := func() int {
+		t.Helper()
+		var list struct{ Items []subDTO }
+		doJSON(t, http.MethodGet, srv.URL+"/v1/subscriptions", nil, &list)
+		return len(list.Items)
+	}
+
+	rep := post("?dry_run=true")
+	if rep.Rows != 4 || rep.Imported != 2 || rep.Failed != 2 || !rep.DryRun || count() != 0 {
+		t.Fatalf("dry run: %+v, stored %d", rep, count())
+	}
+	rep = post("")
+	if rep.Imported != 2 || rep.Failed != 2 || count() != 2 {
+		t.Fatalf("import: %+v, stored %d", rep, count())
+	}
+	if len(rep.Errors) != 2 || rep.Errors[0].Line != 3 || rep.Errors[1].Line != 4 || rep.Errors[0].Code != "validation_error" {
+		t.Fatalf("errors: %+v", rep.Errors)
+	}
+}
+
+// Импорт медленно передаваемого файла не обрывается по ReadTimeout сервера.
+func TestImportOutlivesReadTimeout(t *testing.T) {
+	api := NewServer(&config.Config{}, zap.NewNop(), memory.NewRepos())
+	srv := httptest.NewUnstartedServer(api.Router())
+	srv.Config.ReadTimeout = 100 * time.Millisecond
+	srv.Start()
+	t.Cleanup(srv.Close)
+	user := mustUser(t, srv, "60601fee-2bf1-4721-ae6f-7636e79a0cba")
+
+	pr, pw := io.Pipe()
+	go func() {
+		_, _ = io.WriteString(pw, "service_name,price,user_id,start_date,end_date\n")
+		for i := 0; i < 4; i++ {
+			time.Sleep(60 * time.Millisecond)
+			_, _ = fmt.Fprintf(pw, "Service %d,100,%s,07-2025,\n", i, user)
+		}
+		_ = pw.Close()
+	}()
+	resp, err := http.Post(srv.URL+"/v1/subscriptions/import", "text/csv", pr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var rep importResp
+	if err := json.NewDecoder(resp.Body).Decode(&rep); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || rep.Imported != 4 {
+		t.Fatalf("status %d, report %+v", resp.StatusCode, rep)
+	}
+}
+
+func TestExport(t *testing.T) {
+	srv := newTestServer(t)
+	users := []string{mustUser(t, srv, "60601fee-2bf1-4721-ae6f-7636e79a0cba"), mustUser(t, srv, "030c11ca-1800-49ee-891e-a8b085a3b82d")}
+	for i := 0; i < 1200; i++ {
+		code := doJSON(t, http.MethodPost, srv.URL+"/v1/subscriptions", map[string]any{
+			"service_name": "Service " + strconv.Itoa(i), "price": 100 + i, "user_id": users[i%2], "start_date": "07-2025",
+		}, nil)
+		if code != http.StatusCreated {
+			t.Fatalf("create: status %d", code)
+		}
+	}
+
+	resp, err := http.Get(srv.URL + "/v1/subscriptions/export?format=csv&user_id=" + users[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/csv; charset=utf-8" ||
+		!strings.HasPrefix(resp.Header.Get("Content-Disposition"), `attachment; filename="subscriptions-`) {
+		t.Fatalf("status %d, headers %v", resp.StatusCode, resp.Header)
+	}
+	recs, err := csv.NewReader(resp.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 601 || recs[0][0] != "id" {
+		t.Fatalf("got %d records, header %v", len(recs), recs[0])
+	}
+	for _, rec := range recs[1:] {
+		if rec[5] != users[0] {
+			t.Fatalf("filter ignored: %v", rec)
+		}
+	}
+
+	resp, err = http.Get(srv.URL + "/v1/subscriptions/export?format=ndjson&service_name=Service%2011")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	n := 0
+	for dec := json.NewDecoder(resp.Body); dec.More(); n++ {
+		var sub subDTO
+		if err := dec.Decode(&sub); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n != 111 { // Service 11, 110..119, 1100..1199
+		t.Fatalf("ndjson export has %d rows, want 111", n)
+	}
+
+	if code := doJSON(t, http.MethodGet, srv.URL+"/v1/subscriptions/export?format=xlsx", nil, nil); code != http.StatusBadRequest {
+		t.Fatalf("unknown format: status %d", code)
+	}
+}
+
+// Выгрузку в обоих форматах можно загрузить обратно через импорт.
+func TestExportImportRoundTrip(t *testing.T) {
+	srv := newTestServer(t)
+	user := mustUser(t, srv, "60601fee-2bf1-4721-ae6f-7636e79a0cba")
+	for _, body := range []map[string]any{
+		{"service_name": "Netflix", "price": 500, "user_id": user, "start_date": "07-2025"},
+		{"service_name": "Spotify", "price": 10, "currency": "USD", "billing_period": "yearly", "user_id": user, "start_date": "03-01-2025", "end_date": "02-01-2026"},
+	} {
+		if code := doJSON(t, http.MethodPost, srv.URL+"/v1/subscriptions", body, nil); code != http.StatusCreated {
+			t.Fatalf("create: status %d", code)
+		}
+	}
+
+	exported := make(map[string][]byte)
+	for _, format := range []string{"ndjson", "csv"} {
+		resp, err := http.Get(srv.URL + "/v1/subscriptions/export?format=" + format + "&service_name=Spotify")
+		if err != nil {
+			t.Fatal(err)
+		}
+		exported[format], err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for format, body := range exported {
+		resp, err := http.Post(srv.URL+"/v1/subscriptions/import?format="+format, "", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rep importResp
+		err = json.NewDecoder(resp.Body).Decode(&rep)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK || rep.Imported != 1 || rep.Failed != 0 {
+			t.Fatalf("%s: status %d, report %+v", format, resp.StatusCode, rep)
+		}
+	}
+
+	var list struct{ Items []subDTO }
+	doJSON(t, http.MethodGet, srv.URL+"/v1/subscriptions?service_name=Spotify", nil, &list)
+	if len(list.Items) != 3 {
+		t.Fatalf("got %d Spotify subscriptions, want 3", len(list.Items))
+	}
+	orig := list.Items[0]
+	for _, got := range list.Items[1:] {
+		if got.ID == orig.ID || got.Price != orig.Price || got.Currency != orig.Currency || got.BillingPeriod != orig.BillingPeriod ||
+			got.StartDate != orig.StartDate || got.EndDate == nil || *got.EndDate != *orig.EndDate {
+			t.Fatalf("imported %+v, exported %+v", got, orig)
+		}
+	}
+
+	line := `{"service_name":"Okko","price":1,"user_id":"` + user + `","start_date":"07-2025","prise":2}`
+	resp, err := http.Post(srv.URL+"/v1/subscriptions/import?format=ndjson", "", strings.NewReader(line))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var rep importResp
+	if err := json.NewDecoder(resp.Body).Decode(&rep); err != nil || rep.Failed != 1 {
+		t.Fatalf("unknown field: report %+v, err %v", rep, err)
+	}
+}
+
+func TestListCursor(t *testing.T) {
+	srv := newTestServer(t)
+	mustUser(t, srv, "60601fee-2bf1-4721-ae6f-7636e79a0cba")
+	create := func(name string) {
+		t.Helper()
+		code := doJSON(t, http.MethodPost, srv.URL+"/v1/subscriptions", map[string]any{
+			"service_name": name, "price": 100, "user_id": "60601fee-2bf1-4721-ae6f-7636e79a0cba", "start_date": "07-2025",
+		}, nil)
+		if code != http.StatusCreated {
+			t.Fatalf("create: status %d", code)
+		}
+	}
+	for i := 0; i < 5; i++ {
+		create("Service " + strconv.Itoa(i))
+	}
+
+	var seen []string
+	url := srv.URL + "/v1/subscriptions?limit=2&with_total=true"
+	for page := 0; ; page++ {
+		var resp listResp
+		if code := doJSON(t, http.MethodGet, url, nil, &resp); code != http.StatusOK {
+			t.Fatalf("page %d: status %d", page, code)
+		}
+		if page == 0 {
+			if resp.Total == nil || *resp.Total != 5 {
+				t.Fatalf("total = %v, want 5", resp.Total)
+			}
+			create("Inserted between pages") // новее первой страницы: не должна сдвинуть следующие
+		}
+		for _, it := range resp.Items {
+			seen = append(seen, it.ServiceName)
+		}
+		if resp.NextCursor == "" {
+			break
+		}
+		url = srv.URL + "/v1/subscriptions?limit=2&cursor=" + resp.NextCursor
+	}
+	want := []string{"Service 4", "Service 3", "Service 2", "Service 1", "Service 0"}
+	if strings.Join(seen, ",") != strings.Join(want, ",") {
+		t.Fatalf("pages = %v, want %v", seen, want)
+	}
+
+	for _, q := range []string{"cursor=bogus", "cursor=" + usecase.ListCursor{Sort: usecase.DefaultListSort, Key: time.Now(), ID: uuid.New()}.Encode() + "&offset=1"} {
+		if code := doJSON(t, http.MethodGet, srv.URL+"/v1/subscriptions?"+q, nil, nil); code != http.StatusBadRequest {
+			t.Fatalf("%s: status %d, want 400", q, code)
+		}
+	}
+}
+
+func TestActiveAt(t *testing.T) {
+	srv := newTestServer(t)
+	user := mustUser(t, srv, "60601fee-2bf1-4721-ae6f-7636e79a0cba")
+	mustUser(t, srv, "030c11ca-1800-49ee-891e-a8b085a3b82d")
+	for _, sub := range []map[string]any{
+		{"service_name": "Yandex Plus", "price": 400, "user_id": user, "start_date": "01-2025", "end_date": "03-2025"},
+		{"service_name": "Netflix", "price": 10, "currency": "USD", "user_id": user, "start_date": "02-2025"},
+		{"service_name": "Spotify", "price": 200, "user_id": user, "start_date": "04-2025"},
+		{"service_name": "Okko", "price": 300, "user_id": "030c11ca-1800-49ee-891e-a8b085a3b82d", "start_date": "01-2025"},
+	} {
+		if code := doJSON(t, http.MethodPost, srv.URL+"/v1/subscriptions", sub, nil); code != http.StatusCreated {
+			t.Fatalf("create: status %d", code)
+		}
+	}
+
+	var resp activeResp
+	if code := doJSON(t, http.MethodGet, srv.URL+"/v1/users/"+user+"/subscriptions/active?at=03-2025", nil, &resp); code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	if len(resp.Items) != 2 || resp.Items[0].ServiceName != "Netflix" || resp.Items[1].ServiceName != "Yandex Plus" {
+		t.Fatalf("items = %+v", resp.Items)
+	}
+	want := []summaryDTO{{Total: 400, Currency: "RUB"}, {Total: 10, Currency: "USD"}}
+	if fmt.Sprint(resp.Costs) != fmt.Sprint(want) {
+		t.Fatalf("costs = %v, want %v", resp.Costs, want)
+	}
+
+	if code := doJSON(t, http.MethodGet, srv.URL+"/v1/users/"+user+"/subscriptions/active?at=March", nil, nil); code != http.StatusBadRequest {
+		t.Fatalf("bad month: status %d", code)
+	}
+}
+
+func TestServiceCatalog(t *testing.T) {
+	srv := newTestServer(t)
+	user := mustUser(t, srv, "60601fee-2bf1-4721-ae6f-7636e79a0cba")
+	var first, second subDTO
+	doJSON(t, http.MethodPost, srv.URL+"/v1/subscriptions",
+		map[string]any{"service_name": " Yandex  Plus", "price": 400, "user_id": user, "start_date": "01-2025"}, &first)
+	if first.ServiceName != "Yandex Plus" || first.ServiceID == "" {
+		t.Fatalf("first = %+v", first)
+	}
+
+	var svc serviceDTO
+	if code := doJSON(t, http.MethodPut, srv.URL+"/v1/services/"+first.ServiceID,
+		map[string]any{"name": "Yandex Plus", "aliases": []string{"Яндекс Плюс"}, "category": "media"}, &svc); code != http.StatusOK {
+		t.Fatalf("update service: status %d", code)
+	}
+	doJSON(t, http.MethodPost, srv.URL+"/v1/subscriptions",
+		map[string]any{"service_name": "яндекс плюс", "price": 300, "user_id": user, "start_date": "02-2025"}, &second)
+	if second.ServiceID != first.ServiceID || second.ServiceName != "Yandex Plus" {
+		t.Fatalf("alias not resolved: %+v", second)
+	}
+	if code := doJSON(t, http.MethodPost, srv.URL+"/v1/services", map[string]any{"name": "YANDEX PLUS"}, nil); code != http.StatusConflict {
+		t.Fatalf("duplicate service: status %d, want 409", code)
+	}
+
+	if code := doJSON(t, http.MethodPut, srv.URL+"/v1/services/"+first.ServiceID,
+		map[string]any{"name": "Плюс", "aliases": []string{"Yandex Plus", "Яндекс Плюс"}}, nil); code != http.StatusOK {
+		t.Fatalf("rename: status %d", code)
+	}
+	var list struct{ Items []subDTO }
+	doJSON(t, http.MethodGet, srv.URL+"/v1/subscriptions?service_id="+first.ServiceID, nil, &list)
+	if len(list.Items) != 2 || list.Items[0].ServiceName != "Плюс" || list.Items[1].ServiceName != "Плюс" {
+		t.Fatalf("renamed = %+v", list.Items)
+	}
+
+	if code := doJSON(t, http.MethodDelete, srv.URL+"/v1/services/"+first.ServiceID, nil, nil); code != http.StatusConflict {
+		t.Fatalf("delete used service: status %d, want 409", code)
+	}
+	var unused serviceDTO
+	doJSON(t, http.MethodPost, srv.URL+"/v1/services", map[string]any{"name": "Okko", "default_price": 300}, &unused)
+	if code := doJSON(t, http.MethodDelete, srv.URL+"/v1/services/"+unused.ID, nil, nil); code != http.StatusNoContent {
+		t.Fatalf("delete: status %d", code)
+	}
+}
+
+func TestUsers(t *testing.T) {
+	srv := newTestServer(t)
+	var alice userDTO
+	if code := doJSON(t, http.MethodPost, srv.URL+"/v1/users",
+		map[string]any{"name": "Alice", "email": "alice@example.com", "timezone": "Europe/Moscow"}, &alice); code != http.StatusCreated {
+		t.Fatalf("create: status %d", code)
+	}
+	for _, body := range []map[string]any{
+		{"name": ""},
+		{"name": "Bob", "email": "not an email"},
+		{"name": "Bob", "timezone": "Mars/Olympus"},
+	} {
+		if code := doJSON(t, http.MethodPost, srv.URL+"/v1/users", body, nil); code != http.StatusBadRequest {
+			t.Errorf("create %v: status %d, want 400", body, code)
+		}
+	}
+	if code := doJSON(t, http.MethodPost, srv.URL+"/v1/users", map[string]any{"name": "Bob", "email": "ALICE@example.com"}, nil); code != http.StatusConflict {
+		t.Fatalf("duplicate email: status %d, want 409", code)
+	}
+	if code := doJSON(t, http.MethodPost, srv.URL+"/v1/subscriptions",
+		map[string]any{"service_name": "Okko", "price": 300, "user_id": uuid.NewString(), "start_date": "01-2025"}, nil); code != http.StatusBadRequest {
+		t.Fatalf("unknown user: status %d, want 400", code)
+	}
+
+	now := time.Now().UTC()
+	ongoing, future := subDTO{}, subDTO{}
+	doJSON(t, http.MethodPost, srv.URL+"/v1/subscriptions",
+		map[string]any{"service_name": "Okko", "price": 300, "user_id": alice.ID, "start_date": "01-2025"}, &ongoing)
+	doJSON(t, http.MethodPost, srv.URL+"/v1/subscriptions",
+		map[string]any{"service_name": "Netflix", "price": 500, "user_id": alice.ID, "start_date": now.AddDate(0, 2, 0).Format("01-2006")}, &future)
+	var list listResp
+	if code := doJSON(t, http.MethodGet, srv.URL+"/v1/users/"+alice.ID+"/subscriptions?sort=service_name", nil, &list); code != http.StatusOK || len(list.Items) != 2 {
+		t.Fatalf("user subscriptions: status %d, %+v", code, list.Items)
+	}
+
+	if code := doJSON(t, http.MethodDelete, srv.URL+"/v1/users/"+alice.ID, nil, nil); code != http.StatusConflict {
+		t.Fatalf("delete with subscriptions: status %d, want 409", code)
+	}
+	if code := doJSON(t, http.MethodDelete, srv.URL+"/v1/users/"+alice.ID+"?policy=end", nil, nil); code != http.StatusNoContent {
+		t.Fatalf("delete policy=end: status %d", code)
+	}
+	var got subDTO
+	doJSON(t, http.MethodGet, srv.URL+"/v1/subscriptions/"+ongoing.ID, nil, &got)
+	if got.EndDate == nil || *got.EndDate != now.Format("01-2006") {
+		t.Fatalf("ongoing subscription not ended: %+v", got)
+	}
+	if code := doJSON(t, http.MethodGet, srv.URL+"/v1/subscriptions/"+future.ID, nil, nil); code != http.StatusNotFound {
+		t.Fatalf("future subscription: status %d, want 404", code)
+	}
+	if code := doJSON(t, http.MethodGet, srv.URL+"/v1/users/"+alice.ID, nil, nil); code != http.StatusNotFound {
+		t.Fatalf("deleted user: status %d, want 404", code)
+	}
+	if code := doJSON(t, http.MethodPost, srv.URL+"/v1/users", map[string]any{"name": "Alice 2", "email": "alice@example.com"}, nil); code != http.StatusCreated {
+		t.Fatalf("email of deleted user: status %d", code)
+	}
+}
+
+func TestTeamsCostTree(t *testing.T) {
+	srv := newTestServer(t)
+	var org orgDTO
+	if code := doJSON(t, http.MethodPost, srv.URL+"/v1/organizations", map[string]any{"name": "Acme"}, &org); code != http.StatusCreated {
+		t.Fatalf("create org: status %d", code)
+	}
+	var eng, backend teamDTO
+	doJSON(t, http.MethodPost, srv.URL+"/v1/organizations/"+org.ID+"/teams", map[string]any{"name": "Engineering"}, &eng)
+	if code := doJSON(t, http.MethodPost, srv.URL+"/v1/organizations/"+org.ID+"/teams",
+		map[string]any{"name": "Backend", "parent_id": eng.ID}, &backend); code != http.StatusCreated {
+		t.Fatalf("create sub-team: status %d", code)
+	}
+	if code := doJSON(t, http.MethodPut, srv.URL+"/v1/teams/"+eng.ID,
+		map[string]any{"name": "Engineering", "parent_id": backend.ID}, nil); code != http.StatusBadRequest {
+		t.Fatalf("cycle: status %d, want 400", code)
+	}
+
+	var lead, dev userDTO
+	doJSON(t, http.MethodPost, srv.URL+"/v1/users", map[string]any{"name": "Lead", "team_id": eng.ID}, &lead)
+	doJSON(t, http.MethodPost, srv.URL+"/v1/users", map[string]any{"name": "Dev", "team_id": backend.ID}, &dev)
+	for _, body := range []map[string]any{
+		{"service_name": "Okko", "price": 300, "user_id": lead.ID, "start_date": "01-2025"},
+		{"service_name": "GitHub", "price": 1000, "user_id": dev.ID, "start_date": "01-2025"},
+		{"service_name": "Netflix", "price": 500, "user_id": mustUser(t, srv, uuid.NewString()), "start_date": "01-2025"},
+	} {
+		if code := doJSON(t, http.MethodPost, srv.URL+"/v1/subscriptions", body, nil); code != http.StatusCreated {
+			t.Fatalf("create %v: status %d", body, code)
+		}
+	}
+
+	var sum summaryDTO
+	doJSON(t, http.MethodGet, srv.URL+"/v1/subscriptions/summary?from=01-2025&to=02-2025&team_id="+eng.ID, nil, &sum)
+	if sum.Total != 2600 {
+		t.Fatalf("team summary = %d, want 2600 (lead and sub-team)", sum.Total)
+	}
+
+	var tree costTreeDTO
+	if code := doJSON(t, http.MethodGet, srv.URL+"/v1/organizations/"+org.ID+"/cost-tree?from=01-2025&to=02-2025", nil, &tree); code != http.StatusOK {
+		t.Fatalf("cost tree: status %d", code)
+	}
+	if tree.Root.Total != 2600 || len(tree.Root.Children) != 1 {
+		t.Fatalf("org node: %+v", tree.Root)
+	}
+	engNode := tree.Root.Children[0]
+	if engNode.Own != 600 || engNode.Total != 2600 || engNode.Subscriptions != 2 || len(engNode.Children) != 1 || engNode.Children[0].Own != 2000 {
+		t.Fatalf("team node: %+v", engNode)
+	}
+	doJSON(t, http.MethodGet, srv.URL+"/v1/teams/"+backend.ID+"/cost-tree?from=01-2025&to=01-2025", nil, &tree)
+	if tree.Root.ID != backend.ID || tree.Root.Total != 1000 || tree.Root.Members != 1 {
+		t.Fatalf("sub-team tree: %+v", tree.Root)
+	}
+
+	if code := doJSON(t, http.MethodDelete, srv.URL+"/v1/teams/"+eng.ID, nil, nil); code != http.StatusConflict {
+		t.Fatalf("delete team with sub-teams: status %d, want 409", code)
+	}
+	if code := doJSON(t, http.MethodDelete, srv.URL+"/v1/organizations/"+org.ID, nil, nil); code != http.StatusConflict {
+		t.Fatalf("delete org with teams: status %d, want 409", code)
+	}
+}
+
+// hs256Token подписывает claims секретом secret.
+func hs256Token(secret string, claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestAuth(t *testing.T) {
+	repos := memory.NewRepos()
+	svc := usecase.NewService(repos)
+	// Первый ключ администратора выпускается в обход HTTP, как подкомандой apikey.
+	admin, adminKey, err := svc.CreateAPIKey(context.Background(), usecase.APIKeyInput{Name: "ops", Roles: []string{"admin"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := jwt.NewVerifier(jwt.Config{HS256Secret: "s3cret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := NewServer(&config.Config{}, zap.NewNop(), repos, NewAPIKeyAuth(svc), NewJWTAuth(verifier, svc))
+	srv := httptest.NewServer(api.Router())
+	t.Cleanup(srv.Close)
+	asAdmin := http.Header{"X-Api-Key": {adminKey}, "X-Actor": {"mallory"}}
+
+	if code := doJSON(t, http.MethodGet, srv.URL+"/v1/health", nil, nil); code != http.StatusOK {
+		t.Fatalf("health: status %d", code)
+	}
+	if code := doJSON(t, http.MethodGet, srv.URL+"/v1/subscriptions", nil, nil); code != http.StatusUnauthorized {
+		t.Fatalf("no credentials: status %d, want 401", code)
+	}
+	if code := doJSONWith(t, http.Header{"X-Api-Key": {"sk_unknown"}}, http.MethodGet, srv.URL+"/v1/subscriptions", nil, nil); code != http.StatusUnauthorized {
+		t.Fatalf("unknown key: status %d, want 401", code)
+	}
+
+	var user userDTO
+	doJSONWith(t, asAdmin, http.MethodPost, srv.URL+"/v1/users", map[string]any{"name": "Alice"}, &user)
+	var sub subDTO
+	if code := doJSONWith(t, asAdmin, http.MethodPost, srv.URL+"/v1/subscriptions",
+		map[string]any{"service_name": "Okko", "price": 300, "user_id": user.ID, "start_date": "01-2025"}, &sub); code != http.StatusCreated {
+		t.Fatalf("create with API key: status %d", code)
+	}
+	var hist struct{ Items []auditRecordDTO }
+	doJSONWith(t, asAdmin, http.MethodGet, srv.URL+"/v1/subscriptions/"+sub.ID+"/history", nil, &hist)
+	if len(hist.Items) != 1 || hist.Items[0].Actor != "api-key:"+admin.ID.String() {
+		t.Fatalf("audit actor: %+v", hist.Items)
+	}
+
+	var userKey apiKeyDTO
+	if code := doJSONWith(t, asAdmin, http.MethodPost, srv.URL+"/v1/api-keys", map[string]any{"name": "alice", "user_id": user.ID}, &userKey); code != http.StatusCreated || userKey.Key == "" {
+		t.Fatalf("create key: status %d, %+v", code, userKey)
+	}
+	asUser := http.Header{"Authorization": {"Bearer " + userKey.Key}}
+	if code := doJSONWith(t, asUser, http.MethodGet, srv.URL+"/v1/subscriptions", nil, nil); code != http.StatusOK {
+		t.Fatalf("bearer API key: status %d", code)
+	}
+	if code := doJSONWith(t, asUser, http.MethodPost, srv.URL+"/v1/api-keys", map[string]any{"name": "escalate", "roles": []string{"admin"}}, nil); code != http.StatusForbidden {
+		t.Fatalf("create key without admin role: status %d, want 403", code)
+	}
+	if code := doJSONWith(t, asAdmin, http.MethodDelete, srv.URL+"/v1/api-keys/"+userKey.ID, nil, nil); code != http.StatusNoContent {
+		t.Fatalf("revoke: status %d", code)
+	}
+	if code := doJSONWith(t, asUser, http.MethodGet, srv.URL+"/v1/subscriptions", nil, nil); code != http.StatusUnauthorized {
+		t.Fatalf("revoked key: status %d, want 401", code)
+	}
+
+	token := hs256Token("s3cret", map[string]any{"sub": user.ID, "exp": time.Now().Add(time.Hour).Unix()})
+	if code := doJSONWith(t, http.Header{"Authorization": {"Bearer " + token}}, http.MethodGet, srv.URL+"/v1/subscriptions/"+sub.ID, nil, nil); code != http.StatusOK {
+		t.Fatalf("JWT: status %d", code)
+	}
+	expired := hs256Token("s3cret", map[string]any{"sub": user.ID, "exp": time.Now().Add(-time.Hour).Unix()})
+	if code := doJSONWith(t, http.Header{"Authorization": {"Bearer " + expired}}, http.MethodGet, srv.URL+"/v1/subscriptions", nil, nil); code != http.StatusUnauthorized {
+		t.Fatalf("expired JWT: status %d, want 401", code)
+	}
+	forged := hs256Token("guess", map[string]any{"sub": user.ID, "exp": time.Now().Add(time.Hour).Unix()})
+	if code := doJSONWith(t, http.Header{"Authorization": {"Bearer " + forged}}, http.MethodGet, srv.URL+"/v1/subscriptions", nil, nil); code != http.StatusUnauthorized {
+		t.Fatalf("forged JWT: status %d, want 401", code)
+	}
+	stranger := hs256Token("s3cret", map[string]any{"sub": uuid.NewString(), "exp": time.Now().Add(time.Hour).Unix()})
+	if code := doJSONWith(t, http.Header{"Authorization": {"Bearer " + stranger}}, http.MethodGet, srv.URL+"/v1/subscriptions", nil, nil); code != http.StatusUnauthorized {
+		t.Fatalf("JWT of unknown user: status %d, want 401", code)
+	}
+	if code := doJSONWith(t, asAdmin, http.MethodDelete, srv.URL+"/v1/users/"+user.ID+"?policy=cascade", nil, nil); code != http.StatusNoContent {
+		t.Fatalf("delete user: status %d", code)
+	}
+	if code := doJSONWith(t, http.Header{"Authorization": {"Bearer " + token}}, http.MethodGet, srv.URL+"/v1/subscriptions", nil, nil); code != http.StatusUnauthorized {
+		t.Fatalf("JWT of deleted user: status %d, want 401", code)
+	}
+}
+
+func TestTenantIsolation(t *testing.T) {
+	repos := memory.NewRepos()
+	svc := usecase.NewService(repos)
+	keyFor := func(tenant string) http.Header {
+		_, secret, err := svc.CreateAPIKey(context.Background(), usecase.APIKeyInput{Name: tenant, TenantID: tenant, Roles: []string{"admin"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return http.Header{"X-Api-Key": {secret}}
+	}
+	acme, globex := keyFor("acme"), keyFor("globex")
+	verifier, err := jwt.NewVerifier(jwt.Config{HS256Secret: "s3cret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(NewServer(&config.Config{}, zap.NewNop(), repos, NewAPIKeyAuth(svc), NewJWTAuth(verifier, svc)).Router())
+	t.Cleanup(srv.Close)
+
+	var user userDTO
+	doJSONWith(t, acme, http.MethodPost, srv.URL+"/v1/users", map[string]any{"name": "Alice"}, &user)
+	var sub subDTO
+	if code := doJSONWith(t, acme, http.MethodPost, srv.URL+"/v1/subscriptions",
+		map[string]any{"service_name": "Okko", "price": 300, "user_id": user.ID, "start_date": "01-2025"}, &sub); code != http.StatusCreated || sub.TenantID != "acme" {
+		t.Fatalf("create: status %d, %+v", code, sub)
+	}
+
+	url := srv.URL + "/v1/subscriptions/" + sub.ID
+	for _, c := range []struct {
+		method, url string
+		body        any
+	}{
+		{http.MethodGet, url, nil},
+		{http.MethodPatch, url, map[string]any{"price": 1}},
+		{http.MethodDelete, url, nil},
+		{http.MethodGet, url + "/history", nil},
+	} {
+		if code := doJSONWith(t, globex, c.method, c.url, c.body, nil); code != http.StatusNotFound {
+			t.Errorf("%s %s from another tenant: status %d, want 404", c.method, c.url, code)
+		}
+	}
+	var list struct{ Items []subDTO }
+	doJSONWith(t, globex, http.MethodGet, srv.URL+"/v1/subscriptions", nil, &list)
+	var sum summaryDTO
+	doJSONWith(t, globex, http.MethodGet, srv.URL+"/v1/subscriptions/summary?from=01-2025&to=01-2025", nil, &sum)
+	if len(list.Items) != 0 || sum.Total != 0 {
+		t.Fatalf("another tenant sees %d subscriptions, total %d", len(list.Items), sum.Total)
+	}
+
+	token := hs256Token("s3cret", map[string]any{"sub": "svc", "tenant_id": "acme", "roles": []string{"finance"}, "exp": time.Now().Add(time.Hour).Unix()})
+	var got subDTO
+	if code := doJSONWith(t, http.Header{"Authorization": {"Bearer " + token}}, http.MethodGet, url, nil, &got); code != http.StatusOK || got.Price != 300 {
+		t.Fatalf("JWT of the same tenant: status %d, %+v", code, got)
+	}
+}
@@ -0,0 +1,133 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+type serviceReq struct {
+	Name         string   `json:"name"`
+	Aliases      []string `json:"aliases,omitempty"`
+	Category     string   `json:"category,omitempty"`
+	DefaultPrice *int     `json:"default_price,omitempty"`
+	Currency     string   `json:"currency,omitempty"`
+}
+
+func (req serviceReq) input() usecase.ServiceInput {
+	return usecase.ServiceInput{
+		Name:         req.Name,
+		Aliases:      req.Aliases,
+		Category:     req.Category,
+		DefaultPrice: req.DefaultPrice,
+		Currency:     req.Currency,
+	}
+}
+
+type serviceDTO struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Aliases      []string `json:"aliases"`
+	Category     string   `json:"category,omitempty"`
+	DefaultPrice *int     `json:"default_price,omitempty"`
+	Currency     string   `json:"currency"`
+	CreatedAt    string   `json:"created_at"`
+	UpdatedAt    string   `json:"updated_at"`
+}
+
+func toServiceDTO(svc *domain.Service) serviceDTO {
+	aliases := svc.Aliases
+	if aliases == nil {
+		aliases = []string{}
+	}
+	return serviceDTO{
+		ID:           svc.ID.String(),
+		Name:         svc.Name,
+		Aliases:      aliases,
+		Category:     svc.Category,
+		DefaultPrice: svc.DefaultPrice,
+		Currency:     svc.Currency.String(),
+		CreatedAt:    svc.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:    svc.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func (s *Server) listServices(w http.ResponseWriter, r *http.Request) {
+	res, err := s.uc.ListServices(r.Context())
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	items := make([]serviceDTO, 0, len(res))
+	for _, svc := range res {
+		items = append(items, toServiceDTO(svc))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+func (s *Server) createService(w http.ResponseWriter, r *http.Request) {
+	var req serviceReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	svc, err := s.uc.CreateService(r.Context(), req.input())
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toServiceDTO(svc))
+}
+
+func (s *Server) getService(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	svc, err := s.uc.GetService(r.Context(), id)
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toServiceDTO(svc))
+}
+
+// updateService заменяет описание сервиса целиком; при смене названия переименовываются и его подписки.
+func (s *Server) updateService(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	var req serviceReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	svc, err := s.uc.UpdateService(r.Context(), id, req.input())
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toServiceDTO(svc))
+}
+
+func (s *Server) deleteService(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	if err := s.uc.DeleteService(r.Context(), id); err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,293 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+type orgReq struct {
+	Name string `json:"name"`
+}
+
+type orgDTO struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+func toOrgDTO(o *domain.Organization) orgDTO {
+	return orgDTO{
+		ID:        o.ID.String(),
+		Name:      o.Name,
+		CreatedAt: o.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: o.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+type teamReq struct {
+	ParentID *uuid.UUID `json:"parent_id,omitempty"`
+	Name     string     `json:"name"`
+}
+
+type teamDTO struct {
+	ID        string `json:"id"`
+	OrgID     string `json:"org_id"`
+	ParentID  string `json:"parent_id,omitempty"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+func toTeamDTO(t *domain.Team) teamDTO {
+	var parentID string
+	if t.ParentID != nil {
+		parentID = t.ParentID.String()
+	}
+	return teamDTO{
+		ID:        t.ID.String(),
+		OrgID:     t.OrgID.String(),
+		ParentID:  parentID,
+		Name:      t.Name,
+		CreatedAt: t.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: t.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+type costNodeDTO struct {
+	Kind          string        `json:"kind"`
+	ID            string        `json:"id"`
+	Name          string        `json:"name"`
+	Members       int           `json:"members"`
+	Subscriptions int           `json:"subscriptions"`
+	Own           int64         `json:"own"`
+	Total         int64         `json:"total"`
+	Children      []costNodeDTO `json:"children"`
+}
+
+func toCostNodeDTO(n *usecase.CostNode) costNodeDTO {
+	children := make([]costNodeDTO, 0, len(n.Children))
+	for _, c := range n.Children {
+		children = append(children, toCostNodeDTO(c))
+	}
+	return costNodeDTO{
+		Kind:          n.Kind,
+		ID:            n.ID.String(),
+		Name:          n.Name,
+		Members:       n.Members,
+		Subscriptions: n.Subscriptions,
+		Own:           n.Own,
+		Total:         n.Total,
+		Children:      children,
+	}
+}
+
+type costTreeDTO struct {
+	Currency string      `json:"currency"`
+	From     string      `json:"from"`
+	To       string      `json:"to"`
+	Root     costNodeDTO `json:"root"`
+}
+
+func (s *Server) listOrgs(w http.ResponseWriter, r *http.Request) {
+	res, err := s.uc.ListOrgs(r.Context())
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	items := make([]orgDTO, 0, len(res))
+	for _, o := range res {
+		items = append(items, toOrgDTO(o))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+func (s *Server) createOrg(w http.ResponseWriter, r *http.Request) {
+	var req orgReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	o, err := s.uc.CreateOrg(r.Context(), usecase.OrgInput{Name: req.Name})
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toOrgDTO(o))
+}
+
+func (s *Server) getOrg(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "org_id"))
+	if err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	o, err := s.uc.GetOrg(r.Context(), id)
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toOrgDTO(o))
+}
+
+func (s *Server) updateOrg(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "org_id"))
+	if err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	var req orgReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	o, err := s.uc.UpdateOrg(r.Context(), id, usecase.OrgInput{Name: req.Name})
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toOrgDTO(o))
+}
+
+func (s *Server) deleteOrg(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "org_id"))
+	if err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	if err := s.uc.DeleteOrg(r.Context(), id); err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listTeams(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "org_id"))
+	if err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	res, err := s.uc.ListTeams(r.Context(), id)
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	items := make([]teamDTO, 0, len(res))
+	for _, t := range res {
+		items = append(items, toTeamDTO(t))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+func (s *Server) createTeam(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "org_id"))
+	if err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	var req teamReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	t, err := s.uc.CreateTeam(r.Context(), usecase.TeamInput{OrgID: id, ParentID: req.ParentID, Name: req.Name})
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toTeamDTO(t))
+}
+
+func (s *Server) getTeam(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "team_id"))
+	if err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	t, err := s.uc.GetTeam(r.Context(), id)
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toTeamDTO(t))
+}
+
+// updateTeam переименовывает команду или меняет её родителя; parent_id не указан — команда верхнего уровня.
+func (s *Server) updateTeam(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "team_id"))
+	if err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	var req teamReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	t, err := s.uc.UpdateTeam(r.Context(), id, usecase.TeamInput{ParentID: req.ParentID, Name: req.Name})
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toTeamDTO(t))
+}
+
+func (s *Server) deleteTeam(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "team_id"))
+	if err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	if err := s.uc.DeleteTeam(r.Context(), id); err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// orgCostTree — стоимость организации за период, разложенная по дереву команд.
+func (s *Server) orgCostTree(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "org_id"))
+	if err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	in, err := summaryInput(r)
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	tree, err := s.uc.OrgCostTree(r.Context(), id, in)
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, costTreeDTO{Currency: tree.Currency.String(), From: in.From, To: in.To, Root: toCostNodeDTO(tree.Root)})
+}
+
+func (s *Server) teamCostTree(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "team_id"))
+	if err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	in, err := summaryInput(r)
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	tree, err := s.uc.TeamCostTree(r.Context(), id, in)
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, costTreeDTO{Currency: tree.Currency.String(), From: in.From, To: in.To, Root: toCostNodeDTO(tree.Root)})
+}
@@ -0,0 +1,142 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+// replaceReq — тело PUT: полное состояние подписки. Отсутствующие currency и billing_period
+// принимают значения по умолчанию, отсутствующий end_date снимает дату окончания.
+type replaceReq struct {
+	ServiceName   *string `json:"service_name"`
+	Price         *int    `json:"price"`
+	Currency      string  `json:"currency"`
+	BillingPeriod string  `json:"billing_period"`
+	StartDate     *string `json:"start_date"`
+	EndDate       *string `json:"end_date"`
+}
+
+func (req replaceReq) input() (usecase.UpdateInput, error) {
+	var missing []string
+	if req.ServiceName == nil {
+		missing = append(missing, "service_name")
+	}
+	if req.Price == nil {
+		missing = append(missing, "price")
+	}
+	if req.StartDate == nil {
+		missing = append(missing, "start_date")
+	}
+	if len(missing) > 0 {
+		return usecase.UpdateInput{}, domain.Errorf(domain.ErrValidation, "missing required fields: %s", strings.Join(missing, ", "))
+	}
+	currency, period := req.Currency, req.BillingPeriod
+	if currency == "" {
+		currency = domain.DefaultCurrency.String()
+	}
+	if period == "" {
+		period = domain.DefaultBillingPeriod.String()
+	}
+	return usecase.UpdateInput{
+		ServiceName:   req.ServiceName,
+		Price:         req.Price,
+		Currency:      &currency,
+		BillingPeriod: &period,
+		StartDate:     req.StartDate,
+		EndDate:       req.EndDate,
+		EndDateSet:    true,
+	}, nil
+}
+
+// mergePatchInput разбирает тело PATCH по RFC 7396: отсутствующее поле не меняется,
+// null удаляет значение (допустимо только для end_date), неизвестные поля — ошибка.
+func mergePatchInput(body []byte) (usecase.UpdateInput, error) {
+	var in usecase.UpdateInput
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil || fields == nil {
+		return in, domain.Errorf(domain.ErrValidation, "merge patch must be a JSON object")
+	}
+	for name, raw := range fields {
+		isNull := bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+		if isNull && name != "end_date" {
+			return in, domain.Errorf(domain.ErrValidation, "%s cannot be null", name)
+		}
+		var err error
+		switch name {
+		case "service_name":
+			err = json.Unmarshal(raw, &in.ServiceName)
+		case "price":
+			err = json.Unmarshal(raw, &in.Price)
+		case "currency":
+			err = json.Unmarshal(raw, &in.Currency)
+		case "billing_period":
+			err = json.Unmarshal(raw, &in.BillingPeriod)
+		case "start_date":
+			err = json.Unmarshal(raw, &in.StartDate)
+		case "end_date":
+			in.EndDateSet = true
+			err = json.Unmarshal(raw, &in.EndDate)
+		default:
+			return in, domain.Errorf(domain.ErrValidation, "unknown or read-only field %q", name)
+		}
+		if err != nil {
+			return in, domain.Errorf(domain.ErrValidation, "invalid %s: %w", name, err)
+		}
+	}
+	return in, nil
+}
+
+func (s *Server) replace(w http.ResponseWriter, r *http.Request) {
+	var req replaceReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	in, err := req.input()
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	s.applyUpdate(w, r, in)
+}
+
+func (s *Server) patch(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	in, err := mergePatchInput(body)
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	s.applyUpdate(w, r, in)
+}
+
+func (s *Server) applyUpdate(w http.ResponseWriter, r *http.Request, in usecase.UpdateInput) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	if in.IfMatch, err = ifMatch(r); err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	res, err := s.uc.Update(r.Context(), id, in)
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	writeSub(w, http.StatusOK, res)
+}
@@ -0,0 +1,171 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+type userReq struct {
+	ID       *uuid.UUID `json:"id,omitempty"` // только при создании
+	Name     string     `json:"name"`
+	Email    string     `json:"email,omitempty"`
+	TeamID   *uuid.UUID `json:"team_id,omitempty"`
+	Timezone string     `json:"timezone,omitempty"`
+}
+
+func (req userReq) input() usecase.UserInput {
+	return usecase.UserInput{ID: req.ID, Name: req.Name, Email: req.Email, TeamID: req.TeamID, Timezone: req.Timezone}
+}
+
+type userDTO struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Email     string `json:"email,omitempty"`
+	TeamID    string `json:"team_id,omitempty"`
+	Timezone  string `json:"timezone"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+func toUserDTO(u *domain.User) userDTO {
+	var teamID string
+	if u.TeamID != nil {
+		teamID = u.TeamID.String()
+	}
+	return userDTO{
+		ID:        u.ID.String(),
+		Name:      u.Name,
+		Email:     u.Email,
+		TeamID:    teamID,
+		Timezone:  u.Timezone,
+		CreatedAt: u.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: u.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func (s *Server) listUsers(w http.ResponseWriter, r *http.Request) {
+	var f usecase.UserFilter
+	q := r.URL.Query()
+	if v := q.Get("team_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			s.writeErr(w, r, badRequest(err))
+			return
+		}
+		f.TeamIDs = []uuid.UUID{id}
+	}
+	if l := q.Get("limit"); l != "" {
+		if n, err := strconv.Atoi(l); err == nil {
+			f.Limit = n
+		}
+	}
+	if o := q.Get("offset"); o != "" {
+		if n, err := strconv.Atoi(o); err == nil {
+			f.Offset = n
+		}
+	}
+	res, err := s.uc.ListUsers(r.Context(), f)
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	items := make([]userDTO, 0, len(res))
+	for _, u := range res {
+		items = append(items, toUserDTO(u))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": items})
+}
+
+func (s *Server) createUser(w http.ResponseWriter, r *http.Request) {
+	var req userReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	u, err := s.uc.CreateUser(r.Context(), req.input())
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toUserDTO(u))
+}
+
+func (s *Server) getUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "user_id"))
+	if err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	u, err := s.uc.GetUser(r.Context(), id)
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toUserDTO(u))
+}
+
+func (s *Server) updateUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "user_id"))
+	if err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	var req userReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	u, err := s.uc.UpdateUser(r.Context(), id, req.input())
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toUserDTO(u))
+}
+
+// deleteUser удаляет пользователя; policy (refuse, end, cascade) задаёт, что станет с его подписками.
+func (s *Server) deleteUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "user_id"))
+	if err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	policy, err := usecase.ParseUserDeletePolicy(r.URL.Query().Get("policy"))
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	if err := s.uc.DeleteUser(r.Context(), id, policy); err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// userSubscriptions — список подписок пользователя с теми же фильтрами, что и /v1/subscriptions.
+func (s *Server) userSubscriptions(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "user_id"))
+	if err != nil {
+		s.writeErr(w, r, badRequest(err))
+		return
+	}
+	f, err := listFilter(r)
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	page, err := s.uc.UserSubscriptions(r.Context(), id, f)
+	if err != nil {
+		s.writeErr(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toListResp(page))
+}
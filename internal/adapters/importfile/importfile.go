@@ -0,0 +1,185 @@
+// Package importfile читает подписки для импорта из CSV и NDJSON потоком, строка за строкой.
+package importfile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+type Format string
+
+const (
+	CSV    Format = "csv"
+	NDJSON Format = "ndjson"
+)
+
+// maxLine — наибольшая длина строки NDJSON.
+const maxLine = 1 << 20
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case CSV, NDJSON:
+		return f, nil
+	case "jsonl":
+		return NDJSON, nil
+	default:
+		return "", domain.Errorf(domain.ErrValidation, "format must be one of: csv, ndjson")
+	}
+}
+
+// FormatFromPath определяет формат по расширению файла.
+func FormatFromPath(path string) (Format, error) {
+	return ParseFormat(strings.TrimPrefix(filepath.Ext(path), "."))
+}
+
+// NewSource возвращает источник строк в формате f. Для CSV сразу читается и проверяется заголовок.
+func NewSource(f Format, r io.Reader) (usecase.ImportSource, error) {
+	switch f {
+	case CSV:
+		return newCSVSource(r)
+	case NDJSON:
+		sc := bufio.NewScanner(r)
+		sc.Buffer(make([]byte, 0, 64*1024), maxLine)
+		return &ndjsonSource{sc: sc}, nil
+	default:
+		return nil, domain.Errorf(domain.ErrValidation, "unsupported import format %q", f)
+	}
+}
+
+// csvColumns — колонки CSV; обязательны service_name, price, user_id и start_date.
+var csvColumns = []string{"service_name", "price", "currency", "billing_period", "user_id", "start_date", "end_date"}
+
+type csvSource struct {
+	cr  *csv.Reader
+	col map[string]int
+}
+
+func newCSVSource(r io.Reader) (*csvSource, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, domain.Errorf(domain.ErrValidation, "read csv header: %w", err)
+	}
+	col := make(map[string]int, len(header))
+	for i, h := range header {
+		col[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, name := range []string{"service_name", "price", "user_id", "start_date"} {
+		if _, ok := col[name]; !ok {
+			return nil, domain.Errorf(domain.ErrValidation, "csv header must contain %s", strings.Join(csvColumns, ","))
+		}
+	}
+	return &csvSource{cr: cr, col: col}, nil
+}
+
+func (s *csvSource) Next() (usecase.ImportRow, error) {
+	rec, err := s.cr.Read()
+	if err == io.EOF {
+		return usecase.ImportRow{}, io.EOF
+	}
+	var perr *csv.ParseError
+	if errors.As(err, &perr) {
+		return usecase.ImportRow{Line: perr.StartLine}, domain.Errorf(domain.ErrValidation, "%w", perr.Err)
+	}
+	if err != nil {
+		return usecase.ImportRow{}, err
+	}
+	line, _ := s.cr.FieldPos(0)
+	row := usecase.ImportRow{Line: line}
+	field := func(name string) string {
+		if i, ok := s.col[name]; ok && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+	in := usecase.CreateInput{
+		ServiceName:   field("service_name"),
+		Currency:      field("currency"),
+		BillingPeriod: field("billing_period"),
+		StartDate:     field("start_date"),
+	}
+	if in.Price, err = strconv.Atoi(field("price")); err != nil {
+		return row, domain.Errorf(domain.ErrValidation, "invalid price %q", field("price"))
+	}
+	if in.UserID, err = uuid.Parse(field("user_id")); err != nil {
+		return row, domain.Errorf(domain.ErrValidation, "invalid user_id %q", field("user_id"))
+	}
+	if end := field("end_date"); end != "" {
+		in.EndDate = &end
+	}
+	row.Input = in
+	return row, nil
+}
+
+// ndjsonRow — строка NDJSON, те же поля, что и в теле POST /v1/subscriptions. Служебные поля
+// записи выгрузки (GET /v1/subscriptions/export) принимаются и не используются: подписка создаётся
+// заново, поэтому выгрузку можно загрузить обратно. Прочие неизвестные поля — ошибка строки.
+type ndjsonRow struct {
+	ServiceName   string    `json:"service_name"`
+	Price         int       `json:"price"`
+	Currency      string    `json:"currency"`
+	BillingPeriod string    `json:"billing_period"`
+	UserID        uuid.UUID `json:"user_id"`
+	StartDate     string    `json:"start_date"`
+	EndDate       *string   `json:"end_date"`
+
+	ID        json.RawMessage `json:"id"`
+	TenantID  json.RawMessage `json:"tenant_id"`
+	ServiceID json.RawMessage `json:"service_id"`
+	CreatedAt json.RawMessage `json:"created_at"`
+	UpdatedAt json.RawMessage `json:"updated_at"`
+	DeletedAt json.RawMessage `json:"deleted_at"`
+	Version   json.RawMessage `json:"version"`
+}
+
+type ndjsonSource struct {
+	sc   *bufio.Scanner
+	line int
+}
+
+func (s *ndjsonSource) Next() (usecase.ImportRow, error) {
+	for s.sc.Scan() {
+		s.line++
+		b := bytes.TrimSpace(s.sc.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+		row := usecase.ImportRow{Line: s.line}
+		var r ndjsonRow
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&r); err != nil {
+			return row, domain.Errorf(domain.ErrValidation, "invalid json: %w", err)
+		}
+		row.Input = usecase.CreateInput{
+			ServiceName:   r.ServiceName,
+			Price:         r.Price,
+			Currency:      r.Currency,
+			BillingPeriod: r.BillingPeriod,
+			UserID:        r.UserID,
+			StartDate:     r.StartDate,
+			EndDate:       r.EndDate,
+		}
+		return row, nil
+	}
+	if err := s.sc.Err(); err != nil {
+		return usecase.ImportRow{}, fmt.Errorf("read ndjson line %d: %w", s.line+1, err)
+	}
+	return usecase.ImportRow{}, io.EOF
+}
@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+// APIKeyRepo хранит API-ключи в памяти и повторяет поведение postgres.APIKeyRepo.
+type APIKeyRepo struct {
+	mu   sync.RWMutex
+	keys map[uuid.UUID]domain.APIKey
+}
+
+func NewAPIKeyRepo() *APIKeyRepo {
+	return &APIKeyRepo{keys: make(map[uuid.UUID]domain.APIKey)}
+}
+
+func (r *APIKeyRepo) Create(ctx context.Context, k *domain.APIKey) error {
+	if k.TenantID != usecase.TenantFrom(ctx) {
+		return errOtherTenant("API key")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, other := range r.keys {
+		if id == k.ID || bytes.Equal(other.Hash, k.Hash) {
+			return domain.Errorf(domain.ErrConflict, "API key already exists")
+		}
+	}
+	r.keys[k.ID] = *k
+	return nil
+}
+
+func (r *APIKeyRepo) GetByHash(ctx context.Context, hash []byte) (*domain.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, k := range r.keys {
+		if bytes.Equal(k.Hash, hash) {
+			return &k, nil
+		}
+	}
+	return nil, errAPIKeyNotFound()
+}
+
+func (r *APIKeyRepo) List(ctx context.Context) ([]*domain.APIKey, error) {
+	tenant := usecase.TenantFrom(ctx)
+	r.mu.RLock()
+	res := make([]*domain.APIKey, 0, len(r.keys))
+	for _, k := range r.keys {
+		if k.TenantID == tenant {
+			res = append(res, &k)
+		}
+	}
+	r.mu.RUnlock()
+	slices.SortFunc(res, func(a, b *domain.APIKey) int { return b.CreatedAt.Compare(a.CreatedAt) })
+	return res, nil
+}
+
+func (r *APIKeyRepo) Revoke(ctx context.Context, id uuid.UUID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k, ok := r.keys[id]
+	if !ok || k.TenantID != usecase.TenantFrom(ctx) {
+		return errAPIKeyNotFound()
+	}
+	if k.RevokedAt == nil {
+		k.RevokedAt = &at
+		r.keys[id] = k
+	}
+	return nil
+}
+
+func errAPIKeyNotFound() error { return domain.Errorf(domain.ErrNotFound, "API key not found") }
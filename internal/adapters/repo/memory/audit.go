@@ -0,0 +1,29 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+// appendAudit вызывается под r.mu вместе с изменением, поэтому запись и изменение атомарны.
+func (r *SubscriptionRepo) appendAudit(rec domain.AuditRecord) {
+	rec.ID = int64(len(r.audit) + 1)
+	r.audit = append(r.audit, rec)
+}
+
+func (r *SubscriptionRepo) History(ctx context.Context, subscriptionID uuid.UUID) ([]domain.AuditRecord, error) {
+	tenant := usecase.TenantFrom(ctx)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var res []domain.AuditRecord
+	for _, rec := range r.audit {
+		if rec.SubscriptionID == subscriptionID && rec.TenantID == tenant {
+			res = append(res, rec)
+		}
+	}
+	return res, nil
+}
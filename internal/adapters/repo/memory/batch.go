@@ -0,0 +1,43 @@
+package memory
+
+import (
+	"context"
+	"maps"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+func (r *SubscriptionRepo) ApplyBatch(ctx context.Context, changes []usecase.BatchChange, atomic bool) ([]error, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.applyBatch(ctx, changes, atomic), nil
+}
+
+// applyBatch вызывается под r.mu.
+func (r *SubscriptionRepo) applyBatch(ctx context.Context, changes []usecase.BatchChange, atomic bool) []error {
+	// Для atomic состояние запоминается целиком и восстанавливается при первой ошибке.
+	subs, prices, auditLen := maps.Clone(r.subs), maps.Clone(r.prices), len(r.audit)
+	errs := make([]error, len(changes))
+	for i, ch := range changes {
+		switch ch.Kind {
+		case usecase.BatchCreate:
+			errs[i] = r.create(ctx, ch.After)
+		case usecase.BatchUpdate:
+			errs[i] = r.update(ctx, ch.After)
+		case usecase.BatchDelete:
+			if errs[i] = r.delete(ctx, ch.Before.ID, ch.Before.Version); errs[i] == nil {
+				cur := r.subs[ch.Before.ID]
+				deleted := clone(&cur)
+				changes[i].After = &deleted
+			}
+		default:
+			errs[i] = domain.Errorf(domain.ErrValidation, "unknown batch operation %q", ch.Kind)
+		}
+		if errs[i] != nil && atomic {
+			r.subs, r.prices, r.audit = subs, prices, r.audit[:auditLen]
+			return errs
+		}
+	}
+	return errs
+}
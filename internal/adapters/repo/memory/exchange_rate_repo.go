@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"context"
+	"math/big"
+	"sync"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+// ratePair — валютная пара в справочнике арендатора.
+type ratePair struct {
+	tenant   string
+	from, to domain.Currency
+}
+
+// ExchangeRateRepo хранит курсы в памяти и разрешает их так же, как postgres.ExchangeRateRepo.
+type ExchangeRateRepo struct {
+	mu    sync.RWMutex
+	rates map[ratePair]map[string]domain.ExchangeRate // ключ второго уровня — месяц MM-YYYY
+}
+
+func NewExchangeRateRepo() *ExchangeRateRepo {
+	return &ExchangeRateRepo{rates: make(map[ratePair]map[string]domain.ExchangeRate)}
+}
+
+func (r *ExchangeRateRepo) SaveRates(ctx context.Context, rates []domain.ExchangeRate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenant := usecase.TenantFrom(ctx)
+	for _, rate := range rates {
+		p := ratePair{tenant, rate.From, rate.To}
+		if r.rates[p] == nil {
+			r.rates[p] = make(map[string]domain.ExchangeRate)
+		}
+		rate.Rate = new(big.Rat).Set(rate.Rate)
+		r.rates[p][rate.Month.String()] = rate
+	}
+	return nil
+}
+
+func (r *ExchangeRateRepo) Rate(ctx context.Context, from, to domain.Currency, month domain.YearMonth) (*big.Rat, error) {
+	if from == to {
+		return big.NewRat(1, 1), nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tenant := usecase.TenantFrom(ctx)
+	direct, okDirect := latest(r.rates[ratePair{tenant, from, to}], month)
+	inverse, okInverse := latest(r.rates[ratePair{tenant, to, from}], month)
+	switch {
+	case okDirect && (!okInverse || !direct.Month.Time().Before(inverse.Month.Time())):
+		return new(big.Rat).Set(direct.Rate), nil
+	case okInverse:
+		return new(big.Rat).Inv(inverse.Rate), nil
+	}
+	return nil, usecase.ErrNoRate(from, to, month)
+}
+
+// latest ищет курс с наибольшим месяцем, не превышающим month.
+func latest(rates map[string]domain.ExchangeRate, month domain.YearMonth) (domain.ExchangeRate, bool) {
+	var best domain.ExchangeRate
+	found := false
+	for _, rate := range rates {
+		if rate.Month.BeforeOrEqual(month) && (!found || rate.Month.Time().After(best.Month.Time())) {
+			best, found = rate, true
+		}
+	}
+	return best, found
+}
@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+func (r *SubscriptionRepo) SavePriceChange(ctx context.Context, pc *domain.PriceChange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.changePrices(ctx, pc.SubscriptionID, func(changes []domain.PriceChange) ([]domain.PriceChange, error) {
+		for i := range changes {
+			if changes[i].EffectiveFrom.Equal(pc.EffectiveFrom) {
+				changes[i] = *pc
+				return changes, nil
+			}
+		}
+		changes = append(changes, *pc)
+		domain.SortPriceChanges(changes)
+		return changes, nil
+	})
+}
+
+func (r *SubscriptionRepo) ListPriceChanges(ctx context.Context, subscriptionID uuid.UUID) ([]domain.PriceChange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if _, ok := r.get(ctx, subscriptionID); !ok {
+		return nil, nil
+	}
+	return append([]domain.PriceChange(nil), r.prices[subscriptionID]...), nil
+}
+
+func (r *SubscriptionRepo) DeletePriceChange(ctx context.Context, subscriptionID uuid.UUID, effectiveFrom domain.YearMonth) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.changePrices(ctx, subscriptionID, func(changes []domain.PriceChange) ([]domain.PriceChange, error) {
+		for i := range changes {
+			if changes[i].EffectiveFrom.Equal(effectiveFrom) {
+				return append(changes[:i], changes[i+1:]...), nil
+			}
+		}
+		return nil, domain.Errorf(domain.ErrNotFound, "price change not found")
+	})
+}
+
+// changePrices применяет fn к копии графика цен неудалённой подписки и вместе с ним меняет версию
+// подписки и пишет запись в журнал; вызывается под r.mu.
+func (r *SubscriptionRepo) changePrices(ctx context.Context, id uuid.UUID, fn func([]domain.PriceChange) ([]domain.PriceChange, error)) error {
+	cur, ok := r.get(ctx, id)
+	if !ok || cur.Deleted() {
+		return errNotFound()
+	}
+	changes, err := fn(append([]domain.PriceChange(nil), r.prices[id]...))
+	if err != nil {
+		return err
+	}
+	before := clone(&cur)
+	before.PriceChanges = r.prices[id]
+	cur.UpdatedAt = time.Now().UTC()
+	cur.Version++
+	r.subs[id] = cur
+	r.prices[id] = changes
+	after := clone(&cur)
+	after.PriceChanges = changes
+	r.appendAudit(usecase.NewAuditRecord(ctx, domain.AuditPriceChange, &before, &after))
+	return nil
+}
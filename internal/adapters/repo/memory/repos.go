@@ -0,0 +1,17 @@
+package memory
+
+import "github.com/oziev02/subscriptions-service/internal/usecase"
+
+// NewRepos создаёт связанные хранилища в памяти: пользователи меняют подписки того же хранилища.
+func NewRepos() usecase.Repos {
+	subs := NewSubscriptionRepo()
+	return usecase.Repos{
+		Subscriptions: subs,
+		Services:      NewServiceRepo(),
+		Users:         NewUserRepo(subs),
+		Orgs:          NewOrgRepo(),
+		Teams:         NewTeamRepo(),
+		APIKeys:       NewAPIKeyRepo(),
+		Rates:         NewExchangeRateRepo(),
+	}
+}
@@ -0,0 +1,155 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+// ServiceRepo хранит каталоги сервисов в памяти и повторяет поведение postgres.ServiceRepo.
+// Запрет удаления используемого сервиса проверяет usecase.Service.DeleteService.
+type ServiceRepo struct {
+	mu       sync.RWMutex
+	services map[uuid.UUID]domain.Service
+	keys     map[serviceKey]uuid.UUID
+}
+
+// serviceKey — ключ поиска (domain.Service.Keys) в каталоге арендатора.
+type serviceKey struct {
+	tenant, key string
+}
+
+func NewServiceRepo() *ServiceRepo {
+	return &ServiceRepo{
+		services: make(map[uuid.UUID]domain.Service),
+		keys:     make(map[serviceKey]uuid.UUID),
+	}
+}
+
+func (r *ServiceRepo) Create(ctx context.Context, svc *domain.Service) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if svc.TenantID != usecase.TenantFrom(ctx) {
+		return errOtherTenant("service")
+	}
+	if _, ok := r.services[svc.ID]; ok {
+		return domain.Errorf(domain.ErrConflict, "service %s already exists", svc.ID)
+	}
+	if err := r.checkKeys(svc); err != nil {
+		return err
+	}
+	r.put(svc)
+	return nil
+}
+
+func (r *ServiceRepo) Get(ctx context.Context, id uuid.UUID) (*domain.Service, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	svc, ok := r.get(ctx, id)
+	if !ok {
+		return nil, errServiceNotFound()
+	}
+	return cloneService(&svc), nil
+}
+
+// get возвращает сервис из каталога арендатора вызова; вызывается под блокировкой.
+func (r *ServiceRepo) get(ctx context.Context, id uuid.UUID) (domain.Service, bool) {
+	svc, ok := r.services[id]
+	if !ok || svc.TenantID != usecase.TenantFrom(ctx) {
+		return domain.Service{}, false
+	}
+	return svc, true
+}
+
+func (r *ServiceRepo) FindByKey(ctx context.Context, key string) (*domain.Service, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	id, ok := r.keys[serviceKey{usecase.TenantFrom(ctx), key}]
+	if !ok {
+		return nil, errServiceNotFound()
+	}
+	svc := r.services[id]
+	return cloneService(&svc), nil
+}
+
+func (r *ServiceRepo) Update(ctx context.Context, svc *domain.Service) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cur, ok := r.get(ctx, svc.ID)
+	if !ok {
+		return errServiceNotFound()
+	}
+	svc.TenantID = cur.TenantID
+	if err := r.checkKeys(svc); err != nil {
+		return err
+	}
+	r.deleteKeys(&cur)
+	r.put(svc)
+	return nil
+}
+
+func (r *ServiceRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cur, ok := r.get(ctx, id)
+	if !ok {
+		return errServiceNotFound()
+	}
+	r.deleteKeys(&cur)
+	delete(r.services, id)
+	return nil
+}
+
+func (r *ServiceRepo) List(ctx context.Context) ([]*domain.Service, error) {
+	tenant := usecase.TenantFrom(ctx)
+	r.mu.RLock()
+	res := make([]*domain.Service, 0, len(r.services))
+	for _, svc := range r.services {
+		if svc.TenantID == tenant {
+			res = append(res, cloneService(&svc))
+		}
+	}
+	r.mu.RUnlock()
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res, nil
+}
+
+// checkKeys проверяет, что ключи svc не заняты другим сервисом его арендатора.
+func (r *ServiceRepo) checkKeys(svc *domain.Service) error {
+	for _, k := range svc.Keys() {
+		if id, ok := r.keys[serviceKey{svc.TenantID, k}]; ok && id != svc.ID {
+			return domain.Errorf(domain.ErrConflict, "name or alias %q is already used by another service", k)
+		}
+	}
+	return nil
+}
+
+func (r *ServiceRepo) put(svc *domain.Service) {
+	r.services[svc.ID] = *cloneService(svc)
+	for _, k := range svc.Keys() {
+		r.keys[serviceKey{svc.TenantID, k}] = svc.ID
+	}
+}
+
+func (r *ServiceRepo) deleteKeys(svc *domain.Service) {
+	for _, k := range svc.Keys() {
+		delete(r.keys, serviceKey{svc.TenantID, k})
+	}
+}
+
+func errServiceNotFound() error { return domain.Errorf(domain.ErrNotFound, "service not found") }
+
+func cloneService(svc *domain.Service) *domain.Service {
+	out := *svc
+	out.Aliases = append([]string(nil), svc.Aliases...)
+	if svc.DefaultPrice != nil {
+		p := *svc.DefaultPrice
+		out.DefaultPrice = &p
+	}
+	return &out
+}
@@ -0,0 +1,409 @@
+package memory
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+// SubscriptionRepo хранит подписки в памяти процесса и повторяет поведение postgres.SubscriptionRepo,
+// в том числе разделение по арендаторам.
+type SubscriptionRepo struct {
+	mu     sync.RWMutex
+	subs   map[uuid.UUID]domain.Subscription
+	prices map[uuid.UUID][]domain.PriceChange // по возрастанию EffectiveFrom
+	audit  []domain.AuditRecord               // только дописывается
+	// removedUsers — пользователи, удалённые через UserRepo; новые подписки им не создать.
+	removedUsers map[uuid.UUID]bool
+}
+
+func NewSubscriptionRepo() *SubscriptionRepo {
+	return &SubscriptionRepo{
+		subs:         make(map[uuid.UUID]domain.Subscription),
+		prices:       make(map[uuid.UUID][]domain.PriceChange),
+		removedUsers: make(map[uuid.UUID]bool),
+	}
+}
+
+func (r *SubscriptionRepo) Create(ctx context.Context, s *domain.Subscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.create(ctx, s)
+}
+
+func (r *SubscriptionRepo) create(ctx context.Context, s *domain.Subscription) error {
+	if s.TenantID != usecase.TenantFrom(ctx) {
+		return errOtherTenant("subscription")
+	}
+	if _, ok := r.subs[s.ID]; ok {
+		return domain.Errorf(domain.ErrConflict, "subscription %s already exists", s.ID)
+	}
+	if r.removedUsers[s.UserID] {
+		return errUserMissing(s.UserID)
+	}
+	s.Version = 1
+	r.subs[s.ID] = clone(s)
+	r.appendAudit(usecase.NewAuditRecord(ctx, domain.AuditCreate, nil, s))
+	return nil
+}
+
+func (r *SubscriptionRepo) Get(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.get(ctx, id)
+	if !ok {
+		return nil, errNotFound()
+	}
+	out := clone(&s)
+	return &out, nil
+}
+
+// get возвращает подписку арендатора вызова; вызывается под блокировкой.
+func (r *SubscriptionRepo) get(ctx context.Context, id uuid.UUID) (domain.Subscription, bool) {
+	s, ok := r.subs[id]
+	if !ok || s.TenantID != usecase.TenantFrom(ctx) {
+		return domain.Subscription{}, false
+	}
+	return s, true
+}
+
+func (r *SubscriptionRepo) Update(ctx context.Context, s *domain.Subscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.update(ctx, s)
+}
+
+func (r *SubscriptionRepo) update(ctx context.Context, s *domain.Subscription) error {
+	cur, ok := r.get(ctx, s.ID)
+	if !ok || cur.Deleted() {
+		return errNotFound()
+	}
+	if err := cur.CheckVersion(s.Version); err != nil {
+		return err
+	}
+	before := clone(&cur)
+	upd := clone(s)
+	cur.ServiceID = upd.ServiceID
+	cur.ServiceName = upd.ServiceName
+	cur.Price = upd.Price
+	cur.Currency = upd.Currency
+	cur.BillingPeriod = upd.BillingPeriod
+	cur.Start = upd.Start
+	cur.End = upd.End
+	cur.StartDate = upd.StartDate
+	cur.EndDate = upd.EndDate
+	cur.UpdatedAt = upd.UpdatedAt
+	cur.Version++
+	s.Version = cur.Version
+	r.subs[s.ID] = cur
+	r.appendAudit(usecase.NewAuditRecord(ctx, domain.AuditUpdate, &before, &cur))
+	return nil
+}
+
+func (r *SubscriptionRepo) Delete(ctx context.Context, id uuid.UUID, version int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.delete(ctx, id, version)
+}
+
+func (r *SubscriptionRepo) delete(ctx context.Context, id uuid.UUID, version int64) error {
+	cur, ok := r.get(ctx, id)
+	if !ok || cur.Deleted() {
+		return errNotFound()
+	}
+	if err := cur.CheckVersion(version); err != nil {
+		return err
+	}
+	deleted := clone(&cur)
+	now := time.Now().UTC()
+	deleted.DeletedAt = &now
+	deleted.Version++
+	r.subs[id] = deleted
+	r.appendAudit(usecase.NewAuditRecord(ctx, domain.AuditDelete, &cur, nil))
+	return nil
+}
+
+func (r *SubscriptionRepo) RenameService(ctx context.Context, serviceID uuid.UUID, name string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	now := time.Now().UTC()
+	tenant := usecase.TenantFrom(ctx)
+	for id, cur := range r.subs {
+		if cur.TenantID != tenant || cur.ServiceID != serviceID || cur.ServiceName == name {
+			continue
+		}
+		renamed := clone(&cur)
+		renamed.ServiceName = name
+		renamed.UpdatedAt = now
+		renamed.Version++
+		r.subs[id] = renamed
+		r.appendAudit(usecase.NewAuditRecord(ctx, domain.AuditUpdate, &cur, &renamed))
+		n++
+	}
+	return n, nil
+}
+
+func (r *SubscriptionRepo) Restore(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cur, ok := r.get(ctx, id)
+	if !ok {
+		return nil, errNotFound()
+	}
+	if !cur.Deleted() {
+		return nil, domain.Errorf(domain.ErrConflict, "subscription is not deleted")
+	}
+	restored := clone(&cur)
+	restored.DeletedAt = nil
+	restored.Version++
+	r.subs[id] = restored
+	r.appendAudit(usecase.NewAuditRecord(ctx, domain.AuditRestore, &cur, &restored))
+	out := clone(&restored)
+	return &out, nil
+}
+
+// Purge удаляет подписки вместе с изменениями цен; журнал аудита сохраняется.
+func (r *SubscriptionRepo) Purge(ctx context.Context, deletedBefore time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for id, s := range r.subs {
+		if s.Deleted() && s.DeletedAt.Before(deletedBefore) {
+			delete(r.subs, id)
+			delete(r.prices, id)
+			r.appendAudit(usecase.NewAuditRecord(ctx, domain.AuditPurge, &s, nil))
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *SubscriptionRepo) List(ctx context.Context, f usecase.ListFilter) ([]*domain.Subscription, error) {
+	matched := r.matching(ctx, f)
+	if c := f.Cursor; c != nil {
+		sort := f.Sort.OrDefault()
+		matched = slices.DeleteFunc(matched, func(s domain.Subscription) bool {
+			return !sortBefore(sort, c.Key, c.ID, usecase.SortKey(sort.Field, &s), s.ID)
+		})
+	}
+	limit := usecase.MaxListLimit
+	if f.Limit > 0 {
+		limit = f.Limit
+	}
+	offset := 0
+	if f.Offset > 0 {
+		offset = f.Offset
+	}
+	var res []*domain.Subscription
+	for i := offset; i < len(matched) && len(res) < limit; i++ {
+		res = append(res, &matched[i])
+	}
+	return res, nil
+}
+
+func (r *SubscriptionRepo) Count(ctx context.Context, f usecase.ListFilter) (int, error) {
+	return len(r.matching(ctx, f)), nil
+}
+
+func (r *SubscriptionRepo) Export(ctx context.Context, f usecase.ListFilter, fn func(*domain.Subscription) error) error {
+	matched := r.matching(ctx, f)
+	for i := range matched {
+		if err := fn(&matched[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matching возвращает копии подписок арендатора вызова, подходящих под фильтр, в порядке List.
+func (r *SubscriptionRepo) matching(ctx context.Context, f usecase.ListFilter) []domain.Subscription {
+	tenant := usecase.TenantFrom(ctx)
+	r.mu.RLock()
+	matched := make([]domain.Subscription, 0, len(r.subs))
+	for _, s := range r.subs {
+		if s.TenantID == tenant && matchList(&s, f) {
+			matched = append(matched, clone(&s))
+		}
+	}
+	r.mu.RUnlock()
+
+	sort := f.Sort.OrDefault()
+	slices.SortFunc(matched, func(a, b domain.Subscription) int {
+		if sortBefore(sort, usecase.SortKey(sort.Field, &a), a.ID, usecase.SortKey(sort.Field, &b), b.ID) {
+			return -1
+		}
+		return 1
+	})
+	return matched
+}
+
+// sortBefore сообщает, идёт ли подписка с ключом (k1, id1) раньше (k2, id2) в порядке sort;
+// сравнение повторяет сравнение строк (столбец, id) в Postgres.
+func sortBefore(sort usecase.ListSort, k1 any, id1 uuid.UUID, k2 any, id2 uuid.UUID) bool {
+	c := compareKeys(k1, k2)
+	if c == 0 {
+		c = bytes.Compare(id1[:], id2[:])
+	}
+	if sort.Desc {
+		return c > 0
+	}
+	return c < 0
+}
+
+func compareKeys(a, b any) int {
+	switch a := a.(type) {
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	case int:
+		return cmp.Compare(a, b.(int))
+	case string:
+		return strings.Compare(a, b.(string))
+	}
+	return 0
+}
+
+func matchList(s *domain.Subscription, f usecase.ListFilter) bool {
+	first := s.FirstDay().Time()
+	var end *time.Time // хранимое значение end_date, см. postgres.endValue
+	if s.End != nil {
+		t := s.End.FirstDay().Time()
+		if s.EndDate != nil {
+			t = s.EndDate.Time()
+		}
+		end = &t
+	}
+	switch {
+	case s.Deleted() && !f.IncludeDeleted,
+		len(f.UserIDs) > 0 && !slices.Contains(f.UserIDs, s.UserID),
+		!matchFilter(s, nil, f.ServiceName),
+		f.ServiceNameExact != nil && s.ServiceName != *f.ServiceNameExact,
+		f.ServiceID != nil && s.ServiceID != *f.ServiceID,
+		f.PriceMin != nil && s.Price < *f.PriceMin,
+		f.PriceMax != nil && s.Price > *f.PriceMax,
+		f.StartFrom != nil && first.Before(f.StartFrom.Time()),
+		f.StartTo != nil && first.After(f.StartTo.Time()),
+		f.EndFrom != nil && (end == nil || end.Before(f.EndFrom.Time())),
+		f.EndTo != nil && (end == nil || end.After(f.EndTo.Time())),
+		f.ActiveIn != nil && !s.ActiveIn(*f.ActiveIn),
+		f.OpenEnded && s.End != nil,
+		f.CreatedFrom != nil && s.CreatedAt.Before(*f.CreatedFrom),
+		f.CreatedTo != nil && !s.CreatedAt.Before(*f.CreatedTo):
+		return false
+	}
+	return true
+}
+
+func matchFilter(s *domain.Subscription, userID *uuid.UUID, serviceName *string) bool {
+	if userID != nil && s.UserID != *userID {
+		return false
+	}
+	if serviceName != nil && !ilike(s.ServiceName, "%"+*serviceName+"%") {
+		return false
+	}
+	return true
+}
+
+// releaseUser применяет к неудалённым подпискам пользователя изменения от release «всё или ничего»
+// и больше не даёт создавать ему подписки.
+func (r *SubscriptionRepo) releaseUser(ctx context.Context, userID uuid.UUID, release usecase.UserRelease) error {
+	tenant := usecase.TenantFrom(ctx)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var subs []*domain.Subscription
+	for _, s := range r.subs {
+		if s.TenantID == tenant && s.UserID == userID && !s.Deleted() {
+			c := clone(&s)
+			subs = append(subs, &c)
+		}
+	}
+	slices.SortFunc(subs, func(a, b *domain.Subscription) int { return bytes.Compare(a.ID[:], b.ID[:]) })
+	changes, err := release(subs)
+	if err != nil {
+		return err
+	}
+	for _, err := range r.applyBatch(ctx, changes, true) {
+		if err != nil {
+			return err
+		}
+	}
+	r.removedUsers[userID] = true
+	return nil
+}
+
+func errNotFound() error { return domain.Errorf(domain.ErrNotFound, "subscription not found") }
+
+func errOtherTenant(entity string) error {
+	return domain.Errorf(domain.ErrForbidden, "%s belongs to another tenant", entity)
+}
+
+func clone(s *domain.Subscription) domain.Subscription {
+	out := *s
+	if s.End != nil {
+		end := *s.End
+		out.End = &end
+	}
+	if s.StartDate != nil {
+		d := *s.StartDate
+		out.StartDate = &d
+	}
+	if s.EndDate != nil {
+		d := *s.EndDate
+		out.EndDate = &d
+	}
+	if s.DeletedAt != nil {
+		t := *s.DeletedAt
+		out.DeletedAt = &t
+	}
+	out.PriceChanges = append([]domain.PriceChange(nil), s.PriceChanges...)
+	return out
+}
+
+// ilike повторяет семантику оператора ILIKE: '%' — любая последовательность,
+// '_' — один символ, '\' экранирует следующий символ, регистр не учитывается.
+func ilike(s, pattern string) bool {
+	return likeMatch([]rune(strings.ToLower(s)), []rune(strings.ToLower(pattern)))
+}
+
+func likeMatch(s, p []rune) bool {
+	for len(p) > 0 {
+		switch p[0] {
+		case '%':
+			for len(p) > 0 && p[0] == '%' {
+				p = p[1:]
+			}
+			if len(p) == 0 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if likeMatch(s[i:], p) {
+					return true
+				}
+			}
+			return false
+		case '_':
+			if len(s) == 0 {
+				return false
+			}
+			s, p = s[1:], p[1:]
+		default:
+			if p[0] == '\\' && len(p) > 1 {
+				p = p[1:]
+			}
+			if len(s) == 0 || s[0] != p[0] {
+				return false
+			}
+			s, p = s[1:], p[1:]
+		}
+	}
+	return len(s) == 0
+}
@@ -0,0 +1,513 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+func newSub(name string, price int, user uuid.UUID, start string, end *string, created time.Time) *domain.Subscription {
+	s := &domain.Subscription{
+		ID:            uuid.New(),
+		TenantID:      domain.DefaultTenant,
+		ServiceName:   name,
+		Price:         price,
+		Currency:      domain.RUB,
+		BillingPeriod: domain.BillingMonthly,
+		UserID:        user,
+		Start:         domain.MustYearMonth(start),
+		CreatedAt:     created,
+		UpdatedAt:     created,
+	}
+	if end != nil {
+		e := domain.MustYearMonth(*end)
+		s.End = &e
+	}
+	return s
+}
+
+func strPtr(s string) *string { return &s }
+
+func TestILike(t *testing.T) {
+	cases := []struct {
+		s, p string
+		want bool
+	}{
+		{"Yandex Plus", "%plus%", true},
+		{"Yandex Plus", "%PLUS", true},
+		{"Yandex Plus", "yandex_plus", true},
+		{"Yandex Plus", "%music%", false},
+		{"100%", "100\\%", true},
+		{"100x", "100\\%", false},
+		{"Кинопоиск", "%ПОИСК%", true},
+	}
+	for _, c := range cases {
+		if got := ilike(c.s, c.p); got != c.want {
+			t.Errorf("ilike(%q, %q) = %v, want %v", c.s, c.p, got, c.want)
+		}
+	}
+}
+
+func TestListOrderAndPaging(t *testing.T) {
+	ctx := context.Background()
+	r := NewSubscriptionRepo()
+	user := uuid.New()
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		if err := r.Create(ctx, newSub("Netflix", 100, user, "01-2025", nil, base.Add(time.Duration(i)*time.Hour))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Create(ctx, newSub("Spotify", 100, uuid.New(), "01-2025", nil, base)); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := r.List(ctx, usecase.ListFilter{ServiceName: strPtr("flix"), Limit: 2, Offset: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 2 {
+		t.Fatalf("got %d items, want 2", len(res))
+	}
+	if want := base.Add(3 * time.Hour); !res[0].CreatedAt.Equal(want) {
+		t.Fatalf("first item created_at = %v, want %v", res[0].CreatedAt, want)
+	}
+	if !res[0].CreatedAt.After(res[1].CreatedAt) {
+		t.Fatal("items are not ordered by created_at desc")
+	}
+}
+
+func TestListFiltersAndSort(t *testing.T) {
+	ctx := context.Background()
+	r := NewSubscriptionRepo()
+	alice, bob, carol := uuid.New(), uuid.New(), uuid.New()
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	subs := map[string]*domain.Subscription{
+		"netflix":  newSub("Netflix", 500, alice, "01-2025", strPtr("03-2025"), base),
+		"netflix2": newSub("Netflix Premium", 900, bob, "02-2025", nil, base.Add(time.Hour)),
+		"spotify":  newSub("Spotify", 200, bob, "04-2025", strPtr("06-2025"), base.Add(2*time.Hour)),
+		"okko":     newSub("Okko", 300, carol, "03-2025", nil, base.Add(3*time.Hour)),
+	}
+	for _, s := range subs {
+		if err := r.Create(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	month := func(s string) *domain.YearMonth { ym := domain.MustYearMonth(s); return &ym }
+	day := func(s string) *domain.Date { d := domain.MustDate(s); return &d }
+	intPtr := func(n int) *int { return &n }
+	at := func(d time.Duration) *time.Time { t := base.Add(d); return &t }
+
+	cases := []struct {
+		name string
+		f    usecase.ListFilter
+		want []string
+	}{
+		{"default sort", usecase.ListFilter{}, []string{"okko", "spotify", "netflix2", "netflix"}},
+		{"users", usecase.ListFilter{UserIDs: []uuid.UUID{alice, carol}}, []string{"okko", "netflix"}},
+		{"exact name", usecase.ListFilter{ServiceNameExact: strPtr("Netflix")}, []string{"netflix"}},
+		{"price range", usecase.ListFilter{PriceMin: intPtr(300), PriceMax: intPtr(500)}, []string{"okko", "netflix"}},
+		{"start range", usecase.ListFilter{StartFrom: day("01-02-2025"), StartTo: day("31-03-2025")}, []string{"okko", "netflix2"}},
+		{"end range", usecase.ListFilter{EndFrom: day("01-03-2025"), EndTo: day("31-05-2025")}, []string{"netflix"}},
+		{"active in", usecase.ListFilter{ActiveIn: month("04-2025")}, []string{"okko", "spotify", "netflix2"}},
+		{"open ended", usecase.ListFilter{OpenEnded: true}, []string{"okko", "netflix2"}},
+		{"created range", usecase.ListFilter{CreatedFrom: at(time.Hour), CreatedTo: at(3 * time.Hour)}, []string{"spotify", "netflix2"}},
+		{"sort price", usecase.ListFilter{Sort: usecase.ListSort{Field: usecase.SortPrice}}, []string{"spotify", "okko", "netflix", "netflix2"}},
+		{"sort -start_date", usecase.ListFilter{Sort: usecase.ListSort{Field: usecase.SortStartDate, Desc: true}}, []string{"spotify", "okko", "netflix2", "netflix"}},
+		{"sort service_name", usecase.ListFilter{Sort: usecase.ListSort{Field: usecase.SortServiceName}}, []string{"netflix", "netflix2", "okko", "spotify"}},
+		{"cursor by price", usecase.ListFilter{
+			Sort:   usecase.ListSort{Field: usecase.SortPrice},
+			Cursor: &usecase.ListCursor{Sort: usecase.ListSort{Field: usecase.SortPrice}, Key: 300, ID: subs["okko"].ID},
+		}, []string{"netflix", "netflix2"}},
+	}
+	names := make(map[uuid.UUID]string)
+	for name, s := range subs {
+		names[s.ID] = name
+	}
+	for _, c := range cases {
+		res, err := r.List(ctx, c.f)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		var got []string
+		for _, s := range res {
+			got = append(got, names[s.ID])
+		}
+		if fmt.Sprint(got) != fmt.Sprint(c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+		if n, _ := r.Count(ctx, c.f); c.f.Cursor == nil && n != len(c.want) {
+			t.Errorf("%s: count = %d, want %d", c.name, n, len(c.want))
+		}
+	}
+}
+
+func TestSummary(t *testing.T) {
+	ctx := context.Background()
+	r := NewSubscriptionRepo()
+	user := uuid.New()
+	now := time.Now().UTC()
+	subs := []*domain.Subscription{
+		newSub("Yandex Plus", 400, user, "07-2025", nil, now),             // 07..12 -> 6 мес
+		newSub("Netflix", 1000, user, "01-2025", strPtr("08-2025"), now),  // 07..08 -> 2 мес
+		newSub("Spotify", 300, user, "01-2024", strPtr("06-2025"), now),   // не пересекается
+		newSub("Yandex Plus", 400, uuid.New(), "10-2025", nil, now),       // другой пользователь
+		newSub("Kinopoisk", 500, user, "12-2025", strPtr("12-2025"), now), // 12 -> 1 мес
+	}
+	for _, s := range subs {
+		if err := r.Create(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	from, to := domain.MustYearMonth("07-2025"), domain.MustYearMonth("12-2025")
+
+	total, err := r.Summary(ctx, usecase.SummaryFilter{From: from, To: to, UserID: &user})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(400*6 + 1000*2 + 500); total != want {
+		t.Fatalf("total = %d, want %d", total, want)
+	}
+
+	total, err = r.Summary(ctx, usecase.SummaryFilter{From: from, To: to, ServiceName: strPtr("yandex")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(400*6 + 400*3); total != want {
+		t.Fatalf("total = %d, want %d", total, want)
+	}
+}
+
+func TestMonthlySummary(t *testing.T) {
+	ctx := context.Background()
+	r := NewSubscriptionRepo()
+	user := uuid.New()
+	now := time.Now().UTC()
+	for _, s := range []*domain.Subscription{
+		newSub("Yandex Plus", 400, user, "08-2025", nil, now),
+		newSub("Netflix", 1000, user, "01-2025", strPtr("08-2025"), now),
+	} {
+		if err := r.Create(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f := usecase.SummaryFilter{From: domain.MustYearMonth("07-2025"), To: domain.MustYearMonth("10-2025"), UserID: &user}
+	res, err := r.MonthlySummary(ctx, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []usecase.MonthlyCost{
+		{Month: domain.MustYearMonth("07-2025"), Total: 1000, Subscriptions: 1},
+		{Month: domain.MustYearMonth("08-2025"), Total: 1400, Subscriptions: 2},
+		{Month: domain.MustYearMonth("09-2025"), Total: 400, Subscriptions: 1},
+		{Month: domain.MustYearMonth("10-2025"), Total: 400, Subscriptions: 1},
+	}
+	if len(res) != len(want) {
+		t.Fatalf("got %d months, want %d", len(res), len(want))
+	}
+	var sum int64
+	for i := range want {
+		if res[i] != want[i] {
+			t.Errorf("month %d: got %+v, want %+v", i, res[i], want[i])
+		}
+		sum += res[i].Total
+	}
+	total, err := r.Summary(ctx, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != sum {
+		t.Fatalf("summary total %d != sum of months %d", total, sum)
+	}
+}
+
+func TestGroupedSummary(t *testing.T) {
+	ctx := context.Background()
+	r := NewSubscriptionRepo()
+	alice, bob := uuid.New(), uuid.New()
+	now := time.Now().UTC()
+	for _, s := range []*domain.Subscription{
+		newSub("Netflix", 1000, alice, "01-2025", nil, now),
+		newSub("Netflix", 1000, bob, "07-2025", nil, now),
+		newSub("Spotify", 300, alice, "01-2025", nil, now),
+		newSub("Kinopoisk", 500, bob, "01-2024", strPtr("12-2024"), now), // вне периода
+	} {
+		if err := r.Create(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f := usecase.SummaryFilter{From: domain.MustYearMonth("01-2025"), To: domain.MustYearMonth("12-2025")}
+
+	res, err := r.GroupedSummary(ctx, f, usecase.GroupByServiceName, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []usecase.GroupCost{
+		{Key: "Netflix", Total: 1000*12 + 1000*6, Months: 18, Subscriptions: 2},
+		{Key: "Spotify", Total: 300 * 12, Months: 12, Subscriptions: 1},
+	}
+	if len(res) != len(want) {
+		t.Fatalf("got %+v, want %+v", res, want)
+	}
+	for i := range want {
+		if res[i] != want[i] {
+			t.Errorf("group %d: got %+v, want %+v", i, res[i], want[i])
+		}
+	}
+
+	res, err = r.GroupedSummary(ctx, f, usecase.GroupByUserID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 || res[0].Key != alice.String() || res[0].Total != 1000*12+300*12 {
+		t.Fatalf("top user: got %+v", res)
+	}
+}
+
+func TestSummaryBillingPeriods(t *testing.T) {
+	ctx := context.Background()
+	r := NewSubscriptionRepo()
+	user := uuid.New()
+	yearly := newSub("Yandex Plus", 1200, user, "03-2025", nil, time.Now().UTC())
+	yearly.BillingPeriod = domain.BillingYearly
+	quarterly := newSub("Netflix", 300, user, "01-2025", nil, time.Now().UTC())
+	quarterly.BillingPeriod = domain.BillingQuarterly
+	for _, s := range []*domain.Subscription{yearly, quarterly} {
+		if err := r.Create(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f := usecase.SummaryFilter{From: domain.MustYearMonth("01-2025"), To: domain.MustYearMonth("06-2025"), Mode: usecase.ModeBilled}
+
+	total, err := r.Summary(ctx, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(1200 + 300*2); total != want {
+		t.Fatalf("billed total = %d, want %d", total, want)
+	}
+	months, err := r.MonthlySummary(ctx, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if months[2].Total != 1200 || months[3].Total != 300 || months[1].Total != 0 || months[1].Subscriptions != 1 {
+		t.Fatalf("billed months = %+v", months)
+	}
+
+	f.Mode = usecase.ModeAmortized
+	if total, err = r.Summary(ctx, f); err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(100*4 + 100*6); total != want {
+		t.Fatalf("amortized total = %d, want %d", total, want)
+	}
+}
+
+func TestSoftDeleteRestorePurge(t *testing.T) {
+	ctx := context.Background()
+	r := NewSubscriptionRepo()
+	user := uuid.New()
+	now := time.Now().UTC()
+	kept := newSub("Netflix", 100, user, "01-2025", nil, now)
+	gone := newSub("Spotify", 200, user, "01-2025", nil, now)
+	for _, s := range []*domain.Subscription{kept, gone} {
+		if err := r.Create(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Delete(ctx, gone.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Delete(ctx, gone.ID, 0); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("second delete: %v, want not found", err)
+	}
+
+	f := usecase.SummaryFilter{From: domain.MustYearMonth("01-2025"), To: domain.MustYearMonth("01-2025")}
+	if total, _ := r.Summary(ctx, f); total != 100 {
+		t.Fatalf("summary without deleted = %d, want 100", total)
+	}
+	f.IncludeDeleted = true
+	if total, _ := r.Summary(ctx, f); total != 300 {
+		t.Fatalf("summary with deleted = %d, want 300", total)
+	}
+	if res, _ := r.List(ctx, usecase.ListFilter{}); len(res) != 1 {
+		t.Fatalf("list returned %d items, want 1", len(res))
+	}
+
+	if _, err := r.Restore(ctx, kept.ID); !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("restore of live subscription: %v, want conflict", err)
+	}
+	if _, err := r.Restore(ctx, gone.ID); err != nil {
+		t.Fatal(err)
+	}
+	if res, _ := r.List(ctx, usecase.ListFilter{}); len(res) != 2 {
+		t.Fatalf("list after restore returned %d items, want 2", len(res))
+	}
+
+	if err := r.Delete(ctx, gone.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := r.Purge(ctx, now.Add(-time.Hour)); n != 0 {
+		t.Fatalf("purged %d subscriptions within retention", n)
+	}
+	if n, _ := r.Purge(ctx, time.Now().Add(time.Second)); n != 1 {
+		t.Fatalf("purged %d subscriptions, want 1", n)
+	}
+	if _, err := r.Get(ctx, gone.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("get after purge: %v, want not found", err)
+	}
+	hist, _ := r.History(ctx, gone.ID)
+	var ops []domain.AuditOperation
+	for _, rec := range hist {
+		ops = append(ops, rec.Operation)
+	}
+	if want := []domain.AuditOperation{"create", "delete", "restore", "delete", "purge"}; fmt.Sprint(ops) != fmt.Sprint(want) {
+		t.Fatalf("history = %v, want %v", ops, want)
+	}
+}
+
+func TestPriceChangesAreAudited(t *testing.T) {
+	ctx := context.Background()
+	r := NewSubscriptionRepo()
+	s := newSub("Netflix", 100, uuid.New(), "01-2025", nil, time.Now().UTC())
+	if err := r.Create(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	from := domain.MustYearMonth("03-2025")
+	if err := r.SavePriceChange(ctx, &domain.PriceChange{SubscriptionID: s.ID, EffectiveFrom: from, Price: 150}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.DeletePriceChange(ctx, s.ID, from); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.DeletePriceChange(ctx, s.ID, from); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("delete of missing price change: %v, want not found", err)
+	}
+	if got, _ := r.Get(ctx, s.ID); got.Version != 3 {
+		t.Fatalf("version = %d, want 3", got.Version)
+	}
+	hist, _ := r.History(ctx, s.ID)
+	if len(hist) != 3 || hist[1].Operation != domain.AuditPriceChange || hist[2].Operation != domain.AuditPriceChange {
+		t.Fatalf("history = %+v", hist)
+	}
+	if !strings.Contains(string(hist[1].After), `"price_changes":[{"effective_from":"03-2025","price":150}]`) ||
+		strings.Contains(string(hist[1].Before), "price_changes") || strings.Contains(string(hist[2].After), "price_changes") {
+		t.Fatalf("snapshots do not show the price schedule: %s -> %s -> %s", hist[1].Before, hist[1].After, hist[2].After)
+	}
+
+	if err := r.Delete(ctx, s.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SavePriceChange(ctx, &domain.PriceChange{SubscriptionID: s.ID, EffectiveFrom: from, Price: 1}); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("price change of deleted subscription: %v, want not found", err)
+	}
+}
+
+func TestUserDeleteReleasesSubscriptionsAtomically(t *testing.T) {
+	ctx := context.Background()
+	subs := NewSubscriptionRepo()
+	users := NewUserRepo(subs)
+	u := &domain.User{ID: uuid.New(), TenantID: domain.DefaultTenant, Name: "Alice"}
+	if err := users.Create(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	a := newSub("Netflix", 100, u.ID, "01-2025", nil, time.Now().UTC())
+	b := newSub("Spotify", 200, u.ID, "01-2025", nil, time.Now().UTC())
+	for _, s := range []*domain.Subscription{a, b} {
+		if err := subs.Create(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Устаревшая версия второй подписки отменяет и удаление первой, и удаление пользователя.
+	stale := *b
+	stale.Version = 7
+	err := users.Delete(ctx, u.ID, func(got []*domain.Subscription) ([]usecase.BatchChange, error) {
+		if len(got) != 2 {
+			t.Fatalf("release got %d subscriptions, want 2", len(got))
+		}
+		return []usecase.BatchChange{{Kind: usecase.BatchDelete, Before: a}, {Kind: usecase.BatchDelete, Before: &stale}}, nil
+	})
+	if !errors.Is(err, domain.ErrPrecondition) {
+		t.Fatalf("delete with stale change: %v, want precondition", err)
+	}
+	if res, _ := subs.List(ctx, usecase.ListFilter{}); len(res) != 2 {
+		t.Fatalf("%d subscriptions left after failed delete, want 2", len(res))
+	}
+	if _, err := users.Get(ctx, u.ID); err != nil {
+		t.Fatalf("user after failed delete: %v", err)
+	}
+
+	err = users.Delete(ctx, u.ID, func(got []*domain.Subscription) ([]usecase.BatchChange, error) {
+		changes := make([]usecase.BatchChange, len(got))
+		for i, s := range got {
+			changes[i] = usecase.BatchChange{Kind: usecase.BatchDelete, Before: s}
+		}
+		return changes, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, _ := subs.List(ctx, usecase.ListFilter{}); len(res) != 0 {
+		t.Fatalf("%d subscriptions left after delete", len(res))
+	}
+	if err := subs.Create(ctx, newSub("Okko", 300, u.ID, "01-2025", nil, time.Now().UTC())); !errors.Is(err, domain.ErrValidation) {
+		t.Fatalf("create for deleted user: %v, want validation error", err)
+	}
+}
+
+func TestTenantScope(t *testing.T) {
+	acme, globex := usecase.WithTenant(context.Background(), "acme"), usecase.WithTenant(context.Background(), "globex")
+	r := NewSubscriptionRepo()
+	s := newSub("Netflix", 100, uuid.New(), "01-2025", nil, time.Now().UTC())
+	if err := r.Create(globex, s); !errors.Is(err, domain.ErrForbidden) {
+		t.Fatalf("create for another tenant: %v, want forbidden", err)
+	}
+	s.TenantID = "acme"
+	if err := r.Create(acme, s); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Get(globex, s.ID); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("get from another tenant: %v, want not found", err)
+	}
+	if err := r.Update(globex, s); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("update from another tenant: %v, want not found", err)
+	}
+	pc := &domain.PriceChange{SubscriptionID: s.ID, EffectiveFrom: domain.MustYearMonth("02-2025"), Price: 1}
+	if err := r.SavePriceChange(globex, pc); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("price change from another tenant: %v, want not found", err)
+	}
+	if res, _ := r.List(globex, usecase.ListFilter{}); len(res) != 0 {
+		t.Fatalf("another tenant lists %d subscriptions", len(res))
+	}
+	f := usecase.SummaryFilter{From: domain.MustYearMonth("01-2025"), To: domain.MustYearMonth("01-2025")}
+	if total, _ := r.Summary(globex, f); total != 0 {
+		t.Fatalf("another tenant summary = %d", total)
+	}
+	if hist, _ := r.History(globex, s.ID); len(hist) != 0 {
+		t.Fatalf("another tenant sees %d history records", len(hist))
+	}
+	if err := r.Delete(globex, s.ID, 0); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("delete from another tenant: %v, want not found", err)
+	}
+
+	// Окончательное удаление обслуживает всех арендаторов, а журнал остаётся у владельца.
+	if err := r.Delete(acme, s.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := r.Purge(globex, time.Now().Add(time.Second)); n != 1 {
+		t.Fatalf("purged %d subscriptions, want 1", n)
+	}
+	if hist, _ := r.History(acme, s.ID); len(hist) != 3 {
+		t.Fatalf("owner sees %d history records after purge, want 3", len(hist))
+	}
+}
@@ -0,0 +1,151 @@
+package memory
+
+import (
+	"context"
+	"math/big"
+	"slices"
+	"sort"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+// charge — стоимость подписки в одном месяце периода, аналог строки CTE charges в postgres.
+type charge struct {
+	sub    *domain.Subscription
+	month  domain.YearMonth
+	amount *big.Rat
+}
+
+// charges перебирает пары (подписка, месяц) периода, в которых активна подписка арендатора вызова.
+// Вызывается под блокировкой на чтение.
+func (r *SubscriptionRepo) charges(ctx context.Context, f usecase.SummaryFilter, fn func(c charge)) {
+	tenant := usecase.TenantFrom(ctx)
+	for id := range r.subs {
+		s := r.subs[id]
+		if s.TenantID != tenant || !matchSummary(&s, f) {
+			continue
+		}
+		s.PriceChanges = r.prices[id]
+		for m := f.From; m.BeforeOrEqual(f.To); m = m.AddMonths(1) {
+			if !s.ActiveIn(m) {
+				continue
+			}
+			var amount *big.Rat
+			if f.Mode == usecase.ModeAmortized {
+				amount = s.AmortizedIn(m)
+			} else {
+				amount = s.BilledIn(m)
+			}
+			fn(charge{sub: &s, month: m, amount: amount})
+		}
+	}
+}
+
+func (r *SubscriptionRepo) Summary(ctx context.Context, f usecase.SummaryFilter) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	total := new(big.Rat)
+	r.charges(ctx, f, func(c charge) { total.Add(total, c.amount) })
+	return domain.RoundRat(total), nil
+}
+
+func (r *SubscriptionRepo) SummaryCurrencies(ctx context.Context, f usecase.SummaryFilter) ([]domain.Currency, error) {
+	r.mu.RLock()
+	seen := make(map[domain.Currency]struct{})
+	r.charges(ctx, f, func(c charge) { seen[c.sub.Currency] = struct{}{} })
+	r.mu.RUnlock()
+	res := make([]domain.Currency, 0, len(seen))
+	for c := range seen {
+		res = append(res, c)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i] < res[j] })
+	return res, nil
+}
+
+func (r *SubscriptionRepo) MonthlySummary(ctx context.Context, f usecase.SummaryFilter) ([]usecase.MonthlyCost, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var res []usecase.MonthlyCost
+	var totals []*big.Rat
+	index := make(map[string]int)
+	for m := f.From; m.BeforeOrEqual(f.To); m = m.AddMonths(1) {
+		index[m.String()] = len(res)
+		res = append(res, usecase.MonthlyCost{Month: m})
+		totals = append(totals, new(big.Rat))
+	}
+	r.charges(ctx, f, func(c charge) {
+		i := index[c.month.String()]
+		totals[i].Add(totals[i], c.amount)
+		res[i].Subscriptions++
+	})
+	for i := range res {
+		res[i].Total = domain.RoundRat(totals[i])
+	}
+	return res, nil
+}
+
+func (r *SubscriptionRepo) GroupedSummary(ctx context.Context, f usecase.SummaryFilter, groupBy usecase.SummaryGroupBy, limit int) ([]usecase.GroupCost, error) {
+	var keyOf func(s *domain.Subscription) string
+	switch groupBy {
+	case usecase.GroupByServiceName:
+		keyOf = func(s *domain.Subscription) string { return s.ServiceName }
+	case usecase.GroupByUserID:
+		keyOf = func(s *domain.Subscription) string { return s.UserID.String() }
+	default:
+		return nil, domain.Errorf(domain.ErrValidation, "unsupported group_by %q", groupBy)
+	}
+
+	type group struct {
+		cost  usecase.GroupCost
+		total *big.Rat
+		subs  map[string]struct{}
+	}
+	r.mu.RLock()
+	groups := make(map[string]*group)
+	r.charges(ctx, f, func(c charge) {
+		key := keyOf(c.sub)
+		g, ok := groups[key]
+		if !ok {
+			g = &group{cost: usecase.GroupCost{Key: key}, total: new(big.Rat), subs: make(map[string]struct{})}
+			groups[key] = g
+		}
+		g.total.Add(g.total, c.amount)
+		g.cost.Months++
+		g.subs[c.sub.ID.String()] = struct{}{}
+	})
+	r.mu.RUnlock()
+
+	res := make([]usecase.GroupCost, 0, len(groups))
+	for _, g := range groups {
+		g.cost.Total = domain.RoundRat(g.total)
+		g.cost.Subscriptions = len(g.subs)
+		res = append(res, g.cost)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Total != res[j].Total {
+			return res[i].Total > res[j].Total
+		}
+		return res[i].Key < res[j].Key
+	})
+	if limit > 0 && len(res) > limit {
+		res = res[:limit]
+	}
+	return res, nil
+}
+
+func matchSummary(s *domain.Subscription, f usecase.SummaryFilter) bool {
+	if f.Currency != nil && s.Currency != *f.Currency {
+		return false
+	}
+	if f.ServiceID != nil && s.ServiceID != *f.ServiceID {
+		return false
+	}
+	if f.UserIDs != nil && !slices.Contains(f.UserIDs, s.UserID) {
+		return false
+	}
+	if s.Deleted() && !f.IncludeDeleted {
+		return false
+	}
+	return matchFilter(s, f.UserID, f.ServiceName)
+}
@@ -0,0 +1,189 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+// OrgRepo хранит организации в памяти и повторяет поведение postgres.OrgRepo, в том числе
+// разделение по арендаторам.
+type OrgRepo struct {
+	mu   sync.RWMutex
+	orgs map[uuid.UUID]domain.Organization
+}
+
+func NewOrgRepo() *OrgRepo {
+	return &OrgRepo{orgs: make(map[uuid.UUID]domain.Organization)}
+}
+
+func (r *OrgRepo) Create(ctx context.Context, o *domain.Organization) error {
+	if o.TenantID != usecase.TenantFrom(ctx) {
+		return errOtherTenant("organization")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.orgs[o.ID]; ok {
+		return domain.Errorf(domain.ErrConflict, "organization %s already exists", o.ID)
+	}
+	r.orgs[o.ID] = *o
+	return nil
+}
+
+func (r *OrgRepo) Get(ctx context.Context, id uuid.UUID) (*domain.Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	o, ok := r.orgs[id]
+	if !ok || o.TenantID != usecase.TenantFrom(ctx) {
+		return nil, errOrgNotFound()
+	}
+	return &o, nil
+}
+
+func (r *OrgRepo) Update(ctx context.Context, o *domain.Organization) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cur, ok := r.orgs[o.ID]
+	if !ok || cur.TenantID != usecase.TenantFrom(ctx) {
+		return errOrgNotFound()
+	}
+	o.TenantID = cur.TenantID
+	r.orgs[o.ID] = *o
+	return nil
+}
+
+func (r *OrgRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if o, ok := r.orgs[id]; !ok || o.TenantID != usecase.TenantFrom(ctx) {
+		return errOrgNotFound()
+	}
+	delete(r.orgs, id)
+	return nil
+}
+
+func (r *OrgRepo) List(ctx context.Context) ([]*domain.Organization, error) {
+	tenant := usecase.TenantFrom(ctx)
+	r.mu.RLock()
+	res := make([]*domain.Organization, 0, len(r.orgs))
+	for _, o := range r.orgs {
+		if o.TenantID == tenant {
+			res = append(res, &o)
+		}
+	}
+	r.mu.RUnlock()
+	slices.SortFunc(res, func(a, b *domain.Organization) int { return strings.Compare(a.Name, b.Name) })
+	return res, nil
+}
+
+// TeamRepo хранит команды в памяти и повторяет поведение postgres.TeamRepo.
+type TeamRepo struct {
+	mu    sync.RWMutex
+	teams map[uuid.UUID]domain.Team
+}
+
+func NewTeamRepo() *TeamRepo {
+	return &TeamRepo{teams: make(map[uuid.UUID]domain.Team)}
+}
+
+func (r *TeamRepo) Create(ctx context.Context, t *domain.Team) error {
+	if t.TenantID != usecase.TenantFrom(ctx) {
+		return errOtherTenant("team")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.teams[t.ID]; ok {
+		return domain.Errorf(domain.ErrConflict, "team %s already exists", t.ID)
+	}
+	if err := r.checkName(t); err != nil {
+		return err
+	}
+	r.teams[t.ID] = *t
+	return nil
+}
+
+func (r *TeamRepo) Get(ctx context.Context, id uuid.UUID) (*domain.Team, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.get(ctx, id)
+	if !ok {
+		return nil, errTeamNotFound()
+	}
+	return &t, nil
+}
+
+// get возвращает команду арендатора вызова; вызывается под блокировкой.
+func (r *TeamRepo) get(ctx context.Context, id uuid.UUID) (domain.Team, bool) {
+	t, ok := r.teams[id]
+	if !ok || t.TenantID != usecase.TenantFrom(ctx) {
+		return domain.Team{}, false
+	}
+	return t, true
+}
+
+func (r *TeamRepo) Update(ctx context.Context, t *domain.Team) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cur, ok := r.get(ctx, t.ID)
+	if !ok {
+		return errTeamNotFound()
+	}
+	t.TenantID = cur.TenantID
+	if err := r.checkName(t); err != nil {
+		return err
+	}
+	var teams []*domain.Team
+	for _, other := range r.teams {
+		if other.OrgID == t.OrgID {
+			teams = append(teams, &other)
+		}
+	}
+	if err := t.CheckParent(teams); err != nil {
+		return err
+	}
+	r.teams[t.ID] = *t
+	return nil
+}
+
+func (r *TeamRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.get(ctx, id); !ok {
+		return errTeamNotFound()
+	}
+	delete(r.teams, id)
+	return nil
+}
+
+func (r *TeamRepo) List(ctx context.Context, orgID uuid.UUID) ([]*domain.Team, error) {
+	tenant := usecase.TenantFrom(ctx)
+	r.mu.RLock()
+	var res []*domain.Team
+	for _, t := range r.teams {
+		if t.TenantID == tenant && t.OrgID == orgID {
+			res = append(res, &t)
+		}
+	}
+	r.mu.RUnlock()
+	slices.SortFunc(res, func(a, b *domain.Team) int { return strings.Compare(a.Name, b.Name) })
+	return res, nil
+}
+
+// checkName проверяет, что в организации нет другой команды с таким же названием.
+func (r *TeamRepo) checkName(t *domain.Team) error {
+	for id, other := range r.teams {
+		if id != t.ID && other.OrgID == t.OrgID && strings.EqualFold(other.Name, t.Name) {
+			return domain.Errorf(domain.ErrConflict, "team %q already exists in the organization", t.Name)
+		}
+	}
+	return nil
+}
+
+func errOrgNotFound() error  { return domain.Errorf(domain.ErrNotFound, "organization not found") }
+func errTeamNotFound() error { return domain.Errorf(domain.ErrNotFound, "team not found") }
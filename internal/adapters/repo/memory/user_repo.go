@@ -0,0 +1,138 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+// UserRepo хранит пользователей в памяти и повторяет поведение postgres.UserRepo; подписки
+// удаляемых пользователей он меняет в subs.
+type UserRepo struct {
+	mu    sync.RWMutex
+	users map[uuid.UUID]domain.User
+	subs  *SubscriptionRepo
+}
+
+func NewUserRepo(subs *SubscriptionRepo) *UserRepo {
+	return &UserRepo{users: make(map[uuid.UUID]domain.User), subs: subs}
+}
+
+func (r *UserRepo) Create(ctx context.Context, u *domain.User) error {
+	if u.TenantID != usecase.TenantFrom(ctx) {
+		return errOtherTenant("user")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[u.ID]; ok {
+		return domain.Errorf(domain.ErrConflict, "user %s already exists", u.ID)
+	}
+	if err := r.checkEmail(u); err != nil {
+		return err
+	}
+	r.users[u.ID] = *u
+	return nil
+}
+
+func (r *UserRepo) Get(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	u, ok := r.get(ctx, id)
+	if !ok {
+		return nil, errUserNotFound()
+	}
+	return &u, nil
+}
+
+// get возвращает неудалённого пользователя арендатора вызова; вызывается под блокировкой.
+func (r *UserRepo) get(ctx context.Context, id uuid.UUID) (domain.User, bool) {
+	u, ok := r.users[id]
+	if !ok || u.Deleted() || u.TenantID != usecase.TenantFrom(ctx) {
+		return domain.User{}, false
+	}
+	return u, true
+}
+
+func (r *UserRepo) Update(ctx context.Context, u *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cur, ok := r.get(ctx, u.ID)
+	if !ok {
+		return errUserNotFound()
+	}
+	u.TenantID = cur.TenantID
+	if err := r.checkEmail(u); err != nil {
+		return err
+	}
+	r.users[u.ID] = *u
+	return nil
+}
+
+func (r *UserRepo) Delete(ctx context.Context, id uuid.UUID, release usecase.UserRelease) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.get(ctx, id)
+	if !ok {
+		return errUserNotFound()
+	}
+	if err := r.subs.releaseUser(ctx, id, release); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	u.DeletedAt = &now
+	r.users[id] = u
+	return nil
+}
+
+func (r *UserRepo) List(ctx context.Context, f usecase.UserFilter) ([]*domain.User, error) {
+	tenant := usecase.TenantFrom(ctx)
+	r.mu.RLock()
+	var res []*domain.User
+	for _, u := range r.users {
+		if u.TenantID != tenant || u.Deleted() || (f.TeamIDs != nil && (u.TeamID == nil || !slices.Contains(f.TeamIDs, *u.TeamID))) {
+			continue
+		}
+		res = append(res, &u)
+	}
+	r.mu.RUnlock()
+	slices.SortFunc(res, func(a, b *domain.User) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID.String(), b.ID.String())
+	})
+	if f.Offset >= len(res) {
+		return nil, nil
+	}
+	res = res[max(f.Offset, 0):]
+	if f.Limit > 0 && f.Limit < len(res) {
+		res = res[:f.Limit]
+	}
+	return res, nil
+}
+
+// checkEmail проверяет, что email u не занят другим неудалённым пользователем его арендатора.
+func (r *UserRepo) checkEmail(u *domain.User) error {
+	if u.Email == "" {
+		return nil
+	}
+	for id, other := range r.users {
+		if id != u.ID && other.TenantID == u.TenantID && !other.Deleted() && strings.EqualFold(other.Email, u.Email) {
+			return domain.Errorf(domain.ErrConflict, "email %s is already used", u.Email)
+		}
+	}
+	return nil
+}
+
+func errUserNotFound() error { return domain.Errorf(domain.ErrNotFound, "user not found") }
+
+func errUserMissing(id uuid.UUID) error {
+	return domain.Errorf(domain.ErrValidation, "user %s does not exist", id)
+}
@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+// APIKeyRepo хранит API-ключи; ключ ищется по уникальному индексу на hash. Поиск по hash нужен
+// до того, как арендатор вызова известен, поэтому только он снимает ограничение политики RLS
+// (allTenants); остальные запросы ограничены арендатором вызова.
+type APIKeyRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewAPIKeyRepo(pool *pgxpool.Pool) *APIKeyRepo {
+	return &APIKeyRepo{pool: pool}
+}
+
+const apiKeyColumns = `id, name, prefix, hash, user_id, roles, created_at, revoked_at, tenant_id`
+
+func (r *APIKeyRepo) Create(ctx context.Context, k *domain.APIKey) error {
+	if k.TenantID != usecase.TenantFrom(ctx) {
+		return errOtherTenant("API key")
+	}
+	_, err := r.pool.Exec(ctx, `INSERT INTO api_keys (`+apiKeyColumns+`) VALUES ($1,$2,$3,$4,$5,$6,$7,NULL,$8)`,
+		k.ID, k.Name, k.Prefix, k.Hash, k.UserID, roles(k.Roles), k.CreatedAt, k.TenantID)
+	return mapErr(err)
+}
+
+func (r *APIKeyRepo) GetByHash(ctx context.Context, hash []byte) (*domain.APIKey, error) {
+	var k *domain.APIKey
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		if err := allTenants(ctx, tx); err != nil {
+			return err
+		}
+		var err error
+		k, err = scanAPIKey(tx.QueryRow(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE hash=$1`, hash))
+		return err
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errAPIKeyNotFound()
+	}
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return k, nil
+}
+
+func (r *APIKeyRepo) List(ctx context.Context) ([]*domain.APIKey, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE tenant_id=$1 ORDER BY created_at DESC, id`,
+		usecase.TenantFrom(ctx))
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	res, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.APIKey, error) { return scanAPIKey(row) })
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return res, nil
+}
+
+func (r *APIKeyRepo) Revoke(ctx context.Context, id uuid.UUID, at time.Time) error {
+	tag, err := r.pool.Exec(ctx, `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, $2) WHERE id=$1 AND tenant_id=$3`,
+		id, at, usecase.TenantFrom(ctx))
+	if err != nil {
+		return mapErr(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errAPIKeyNotFound()
+	}
+	return nil
+}
+
+func scanAPIKey(row pgx.Row) (*domain.APIKey, error) {
+	var k domain.APIKey
+	if err := row.Scan(&k.ID, &k.Name, &k.Prefix, &k.Hash, &k.UserID, &k.Roles, &k.CreatedAt, &k.RevokedAt, &k.TenantID); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// roles хранит отсутствие ролей пустым массивом, а не NULL.
+func roles(r []string) []string {
+	if r == nil {
+		return []string{}
+	}
+	return r
+}
+
+func errAPIKeyNotFound() error { return domain.Errorf(domain.ErrNotFound, "API key not found") }
@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+func insertAudit(ctx context.Context, tx pgx.Tx, rec domain.AuditRecord) error {
+	const q = `INSERT INTO subscription_audit (subscription_id, operation, actor, request_id, before, after, created_at, tenant_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	_, err := tx.Exec(ctx, q, rec.SubscriptionID, string(rec.Operation), rec.Actor, rec.RequestID,
+		jsonValue(rec.Before), jsonValue(rec.After), rec.CreatedAt, rec.TenantID)
+	return err
+}
+
+// jsonValue передаёт пустой снимок как NULL, а не как пустую строку.
+func jsonValue(b []byte) any {
+	if len(b) == 0 {
+		return nil
+	}
+	return string(b)
+}
+
+func (r *SubscriptionRepo) History(ctx context.Context, subscriptionID uuid.UUID) ([]domain.AuditRecord, error) {
+	const q = `SELECT id, operation, actor, request_id, before, after, created_at
+		FROM subscription_audit WHERE subscription_id = $1 AND tenant_id = $2 ORDER BY id`
+	tenant := usecase.TenantFrom(ctx)
+	rows, err := r.pool.Query(ctx, q, subscriptionID, tenant)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	defer rows.Close()
+	var res []domain.AuditRecord
+	for rows.Next() {
+		rec := domain.AuditRecord{SubscriptionID: subscriptionID, TenantID: tenant}
+		var op string
+		var before, after []byte
+		if err := rows.Scan(&rec.ID, &op, &rec.Actor, &rec.RequestID, &before, &after, &rec.CreatedAt); err != nil {
+			return nil, mapErr(err)
+		}
+		rec.Operation = domain.AuditOperation(op)
+		rec.Before, rec.After = before, after
+		res = append(res, rec)
+	}
+	return res, mapErr(rows.Err())
+}
@@ -0,0 +1,271 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+// Каждое изменение пакета — один оператор: CTE changed меняет подписку, а INSERT по её результату
+// пишет запись аудита. Условия на версию вместо блокировок дают 0 строк, а не ошибку, поэтому
+// несовпадение версии не прерывает транзакцию и операторы можно отправить одним pgx.Batch.
+
+// Создание берёт разделяемую блокировку пользователя (см. UserRepo.Delete) и не создаёт подписку
+// удалённому пользователю; почему подписка не создана, уточняет explainCreate.
+const batchCreateSQL = `WITH changed AS (
+		INSERT INTO subscriptions (` + subColumns + `)
+		SELECT $1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,NULL,1,$12,$13
+		WHERE EXISTS (SELECT 1 FROM users WHERE id=$6 AND tenant_id=$13 AND deleted_at IS NULL FOR SHARE)
+		ON CONFLICT (id) DO NOTHING
+		RETURNING id, tenant_id
+	)`
+
+const batchUpdateSQL = `WITH changed AS (
+		UPDATE subscriptions
+		SET service_name=$2, price=$3, currency=$4, billing_period=$5, start_date=$6, end_date=$7, day_precision=$8, updated_at=$9,
+			service_id=$11, version=version+1
+		WHERE id=$1 AND tenant_id=$12 AND deleted_at IS NULL AND version=$10
+		RETURNING id, tenant_id
+	)`
+
+const batchDeleteSQL = `WITH changed AS (
+		UPDATE subscriptions SET deleted_at=NOW(), version=version+1
+		WHERE id=$1 AND tenant_id=$3 AND deleted_at IS NULL AND version=$2
+		RETURNING id, tenant_id, version, deleted_at
+	)`
+
+// batchDeleteReturning завершает оператор удаления: он возвращает новую версию и время удаления.
+const batchDeleteReturning = `
+	RETURNING (SELECT version FROM changed), (SELECT deleted_at FROM changed)`
+
+// batchAuditSQL дописывает к CTE changed вставку записи аудита; её параметры нумеруются с n.
+func batchAuditSQL(n int) string {
+	p := func(k int) string { return "$" + itoa(n+k) }
+	return `
+	INSERT INTO subscription_audit (subscription_id, tenant_id, operation, actor, request_id, before, after, created_at)
+	SELECT id, tenant_id, ` + p(0) + `::text, ` + p(1) + `::text, ` + p(2) + `::text, ` + p(3) + `::jsonb, ` + p(4) + `::jsonb, ` + p(5) + `::timestamptz
+	FROM changed`
+}
+
+type batchStmt struct {
+	sql    string
+	args   []any
+	noRows error // ошибка, если оператор не изменил ни одной строки
+	// explain, если задан, уточняет noRows запросом в той же транзакции.
+	explain func(ctx context.Context, tx pgx.Tx) error
+}
+
+func (r *SubscriptionRepo) ApplyBatch(ctx context.Context, changes []usecase.BatchChange, atomic bool) ([]error, error) {
+	stmts := make([]batchStmt, len(changes))
+	for i, ch := range changes {
+		st, err := batchStatement(ctx, ch)
+		if err != nil {
+			return nil, err
+		}
+		stmts[i] = st
+	}
+	errs := make([]error, len(changes))
+	aborted, err := r.sendBatch(ctx, changes, stmts, errs, atomic)
+	if err != nil || !aborted || atomic {
+		return errs, err
+	}
+	// Оператор с ошибкой прервал транзакцию best-effort пакета: применяем изменения по одному.
+	for i := range changes {
+		one := make([]error, 1)
+		if _, err := r.sendBatch(ctx, changes[i:i+1], stmts[i:i+1], one, false); err != nil {
+			return nil, err
+		}
+		errs[i] = one[0]
+	}
+	return errs, nil
+}
+
+// sendBatch выполняет операторы одним pgx.Batch в транзакции и раскладывает ошибки по errs.
+// aborted сообщает, что оператор завершился ошибкой и транзакция откачена.
+func (r *SubscriptionRepo) sendBatch(ctx context.Context, changes []usecase.BatchChange, stmts []batchStmt, errs []error, atomic bool) (aborted bool, err error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return false, mapErr(err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }() // после Commit откат ничего не делает
+
+	b := &pgx.Batch{}
+	for _, st := range stmts {
+		b.Queue(st.sql, st.args...)
+	}
+	br := tx.SendBatch(ctx, b)
+	failed := false
+	deleted := make([]*domain.Subscription, len(stmts))
+	for i, st := range stmts {
+		var changed bool
+		var err error
+		if changes[i].Kind == usecase.BatchDelete {
+			deleted[i], err = scanDeleted(br.QueryRow(), changes[i].Before)
+			changed = deleted[i] != nil
+		} else {
+			var cmd pgconn.CommandTag
+			cmd, err = br.Exec()
+			changed = cmd.RowsAffected() > 0
+		}
+		switch {
+		case aborted:
+			// Операторы после ошибки в прерванной транзакции не выполнялись.
+		case err != nil:
+			errs[i], aborted = mapErr(err), true
+		case !changed:
+			errs[i], failed = st.noRows, true
+		}
+	}
+	if err := br.Close(); err != nil && !aborted {
+		return false, mapErr(err)
+	}
+	if aborted {
+		return true, nil
+	}
+	for i, st := range stmts {
+		if errs[i] != nil && st.explain != nil {
+			errs[i] = mapErr(st.explain(ctx, tx))
+		}
+	}
+	if failed && atomic {
+		return false, nil
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return false, mapErr(err)
+	}
+	for i, ch := range changes {
+		switch {
+		case errs[i] != nil:
+		case ch.After != nil:
+			ch.After.Version = nextVersion(ch)
+		default:
+			changes[i].After = deleted[i]
+		}
+	}
+	return false, nil
+}
+
+// scanDeleted читает результат оператора удаления в копию before; nil — подписка не удалена.
+func scanDeleted(row pgx.Row, before *domain.Subscription) (*domain.Subscription, error) {
+	d := *before
+	var at time.Time
+	err := row.Scan(&d.Version, &at)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	at = at.UTC()
+	d.DeletedAt = &at
+	return &d, nil
+}
+
+// applyChanges применяет изменения по одному в уже открытой транзакции; первая ошибка прерывает её.
+func applyChanges(ctx context.Context, tx pgx.Tx, changes []usecase.BatchChange) error {
+	for _, ch := range changes {
+		st, err := batchStatement(ctx, ch)
+		if err != nil {
+			return err
+		}
+		cmd, err := tx.Exec(ctx, st.sql, st.args...)
+		if err != nil {
+			return err
+		}
+		if cmd.RowsAffected() == 0 {
+			if st.explain != nil {
+				return st.explain(ctx, tx)
+			}
+			return st.noRows
+		}
+	}
+	for _, ch := range changes {
+		if ch.After != nil {
+			ch.After.Version = nextVersion(ch)
+		}
+	}
+	return nil
+}
+
+// batchStatement строит оператор изменения. Изменения ограничены арендатором вызова: подписка
+// другого арендатора не находится, и оператор не меняет ни одной строки.
+func batchStatement(ctx context.Context, ch usecase.BatchChange) (batchStmt, error) {
+	var st batchStmt
+	var args []any
+	tenant := usecase.TenantFrom(ctx)
+	switch ch.Kind {
+	case usecase.BatchCreate:
+		s := ch.After
+		if s.TenantID != tenant {
+			return st, errOtherTenant("subscription")
+		}
+		st.sql, st.noRows, st.explain = batchCreateSQL, errUserMissing(s.UserID), explainCreate(s.ID, s.UserID)
+		args = []any{s.ID, s.ServiceName, s.Price, s.Currency, s.BillingPeriod, s.UserID,
+			s.FirstDay().Time(), endValue(s), s.DayPrecision(), s.CreatedAt, s.UpdatedAt, s.ServiceID, s.TenantID}
+	case usecase.BatchUpdate:
+		s := ch.After
+		st.sql, st.noRows = batchUpdateSQL, domain.ErrVersionMismatch
+		args = []any{s.ID, s.ServiceName, s.Price, s.Currency, s.BillingPeriod,
+			s.FirstDay().Time(), endValue(s), s.DayPrecision(), s.UpdatedAt, ch.Before.Version, s.ServiceID, tenant}
+	case usecase.BatchDelete:
+		st.sql, st.noRows = batchDeleteSQL, domain.ErrVersionMismatch
+		args = []any{ch.Before.ID, ch.Before.Version, tenant}
+	default:
+		return st, errors.New("unknown batch operation " + string(ch.Kind))
+	}
+	st.sql += batchAuditSQL(len(args) + 1)
+	if ch.Kind == usecase.BatchDelete {
+		st.sql += batchDeleteReturning
+	}
+	st.args = append(args, auditArgs(ctx, ch)...)
+	return st, nil
+}
+
+// explainCreate различает причины, по которым batchCreateSQL не создал подписку: если пользователь
+// существует и не удалён, подписка с таким ID уже есть.
+func explainCreate(id, userID uuid.UUID) func(ctx context.Context, tx pgx.Tx) error {
+	return func(ctx context.Context, tx pgx.Tx) error {
+		var live bool
+		err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id=$1 AND tenant_id=$2 AND deleted_at IS NULL)`,
+			userID, usecase.TenantFrom(ctx)).Scan(&live)
+		switch {
+		case err != nil:
+			return err
+		case !live:
+			return errUserMissing(userID)
+		}
+		return domain.Errorf(domain.ErrConflict, "subscription %s already exists", id)
+	}
+}
+
+// auditArgs — параметры записи аудита для batchAuditSQL. Снимок «после» строится с версией,
+// которую подписка получит при успехе.
+func auditArgs(ctx context.Context, ch usecase.BatchChange) []any {
+	var after *domain.Subscription
+	if ch.After != nil {
+		next := *ch.After
+		next.Version = nextVersion(ch)
+		after = &next
+	}
+	op := map[usecase.BatchOpKind]domain.AuditOperation{
+		usecase.BatchCreate: domain.AuditCreate,
+		usecase.BatchUpdate: domain.AuditUpdate,
+		usecase.BatchDelete: domain.AuditDelete,
+	}[ch.Kind]
+	rec := usecase.NewAuditRecord(ctx, op, ch.Before, after)
+	return []any{string(rec.Operation), rec.Actor, rec.RequestID, jsonValue(rec.Before), jsonValue(rec.After), rec.CreatedAt}
+}
+
+func nextVersion(ch usecase.BatchChange) int64 {
+	if ch.Before == nil {
+		return 1
+	}
+	return ch.Before.Version + 1
+}
@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+// Результаты пакета на настоящей базе: удаление возвращает новую версию, а несозданная подписка —
+// причину, по которой её не создать.
+func TestApplyBatchResults(t *testing.T) {
+	pool := testPool(t)
+	repo := NewSubscriptionRepo(pool, zap.NewNop())
+	svc := usecase.NewService(usecase.Repos{
+		Subscriptions: repo,
+		Services:      NewServiceRepo(pool),
+		Users:         NewUserRepo(pool),
+		Orgs:          NewOrgRepo(pool),
+		Teams:         NewTeamRepo(pool),
+		APIKeys:       NewAPIKeyRepo(pool),
+	})
+	ctx := context.Background()
+	user, err := svc.CreateUser(ctx, usecase.UserInput{Name: "Alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, err := svc.Create(ctx, usecase.CreateInput{ServiceName: "Netflix", Price: 500, UserID: user.ID, StartDate: "01-2025"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dup, orphan := *sub, *sub
+	orphan.ID, orphan.UserID = uuid.New(), uuid.New()
+	changes := []usecase.BatchChange{
+		{Kind: usecase.BatchCreate, After: &dup},
+		{Kind: usecase.BatchCreate, After: &orphan},
+		{Kind: usecase.BatchDelete, Before: sub},
+	}
+	errs, err := repo.ApplyBatch(ctx, changes, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(errs[0], domain.ErrConflict) {
+		t.Fatalf("duplicate id: err = %v, want conflict", errs[0])
+	}
+	if !errors.Is(errs[1], domain.ErrValidation) {
+		t.Fatalf("missing user: err = %v, want validation error", errs[1])
+	}
+	if errs[2] != nil {
+		t.Fatal(errs[2])
+	}
+	deleted := changes[2].After
+	if deleted == nil || deleted.Version != 2 || deleted.DeletedAt == nil {
+		t.Fatalf("deleted = %+v, want version 2 with deleted_at", deleted)
+	}
+	got, err := repo.Get(ctx, sub.ID)
+	if err != nil || got.Version != deleted.Version {
+		t.Fatalf("stored version %+v, err %v; batch reported %d", got, err, deleted.Version)
+	}
+}
@@ -0,0 +1,53 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+)
+
+// mapErr переводит ошибки pgx/Postgres в доменные категории. Какую запись не нашёл pgx.ErrNoRows,
+// знает только вызывающий, поэтому там, где её можно не найти, нужен mapNotFound.
+func mapErr(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.Errorf(domain.ErrNotFound, "record not found")
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == "23505", pgErr.Code == "40001", pgErr.Code == "40P01":
+			return &domain.Error{Kind: domain.ErrConflict, Err: err}
+		case strings.HasPrefix(pgErr.Code, "23"), strings.HasPrefix(pgErr.Code, "22"):
+			return &domain.Error{Kind: domain.ErrValidation, Err: err}
+		case pgErr.Code == "42501": // в том числе нарушение политики RLS
+			return &domain.Error{Kind: domain.ErrForbidden, Err: err}
+		case strings.HasPrefix(pgErr.Code, "08"), strings.HasPrefix(pgErr.Code, "53"), strings.HasPrefix(pgErr.Code, "57P"):
+			return &domain.Error{Kind: domain.ErrUnavailable, Err: err}
+		}
+		return err
+	}
+	var connErr *pgconn.ConnectError
+	var netErr net.Error
+	if errors.As(err, &connErr) || errors.As(err, &netErr) || pgconn.Timeout(err) || errors.Is(err, context.DeadlineExceeded) {
+		return &domain.Error{Kind: domain.ErrUnavailable, Err: err}
+	}
+	return err
+}
+
+// mapNotFound — mapErr, который называет ненайденную запись: pgx.ErrNoRows становится
+// ErrNotFound «<entity> not found».
+func mapNotFound(err error, entity string) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.Errorf(domain.ErrNotFound, "%s not found", entity)
+	}
+	return mapErr(err)
+}
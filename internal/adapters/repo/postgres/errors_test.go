@@ -0,0 +1,26 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+)
+
+func TestMapNotFoundNamesEntity(t *testing.T) {
+	for _, entity := range []string{"subscription", "user", "team", "service"} {
+		err := mapNotFound(fmt.Errorf("lock: %w", pgx.ErrNoRows), entity)
+		if !errors.Is(err, domain.ErrNotFound) || err.Error() != entity+" not found" {
+			t.Fatalf("%s: err = %v, want %q", entity, err, entity+" not found")
+		}
+	}
+	if err := mapErr(pgx.ErrNoRows); !errors.Is(err, domain.ErrNotFound) || err.Error() == "subscription not found" {
+		t.Fatalf("mapErr names an entity it does not know: %v", err)
+	}
+	if err := mapNotFound(nil, "user"); err != nil {
+		t.Fatalf("nil error mapped to %v", err)
+	}
+}
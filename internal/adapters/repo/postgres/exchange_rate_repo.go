@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+type ExchangeRateRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewExchangeRateRepo(pool *pgxpool.Pool) *ExchangeRateRepo {
+	return &ExchangeRateRepo{pool: pool}
+}
+
+// SaveRates сохраняет курсы арендатора вызова (usecase.TenantFrom), не затрагивая курсы других арендаторов.
+func (r *ExchangeRateRepo) SaveRates(ctx context.Context, rates []domain.ExchangeRate) error {
+	const q = `INSERT INTO exchange_rates (tenant_id, from_currency, to_currency, month, rate)
+		VALUES ($1, $2, $3, $4, $5::numeric)
+		ON CONFLICT (tenant_id, from_currency, to_currency, month) DO UPDATE
+		SET rate = EXCLUDED.rate, updated_at = NOW()`
+	tenant := usecase.TenantFrom(ctx)
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		b := &pgx.Batch{}
+		for _, rate := range rates {
+			b.Queue(q, tenant, rate.From, rate.To, rate.Month.Time(), rate.Rate.FloatString(10))
+		}
+		return tx.SendBatch(ctx, b).Close()
+	})
+	return mapErr(err)
+}
+
+// Rate берёт последний курс арендатора вызова не позже month; если есть только обратная пара, курс инвертируется.
+func (r *ExchangeRateRepo) Rate(ctx context.Context, from, to domain.Currency, month domain.YearMonth) (*big.Rat, error) {
+	if from == to {
+		return big.NewRat(1, 1), nil
+	}
+	const q = `
+	SELECT rate::text, inverse FROM (
+		SELECT rate, month, false AS inverse FROM exchange_rates
+		WHERE tenant_id = $4 AND from_currency = $1 AND to_currency = $2 AND month <= $3
+		UNION ALL
+		SELECT rate, month, true AS inverse FROM exchange_rates
+		WHERE tenant_id = $4 AND from_currency = $2 AND to_currency = $1 AND month <= $3
+	) r
+	ORDER BY month DESC, inverse
+	LIMIT 1`
+	var text string
+	var inverse bool
+	err := r.pool.QueryRow(ctx, q, from, to, month.Time(), usecase.TenantFrom(ctx)).Scan(&text, &inverse)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, usecase.ErrNoRate(from, to, month)
+	}
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	rate, ok := new(big.Rat).SetString(text)
+	if !ok || rate.Sign() <= 0 {
+		return nil, domain.Errorf(domain.ErrValidation, "bad exchange rate %q for %s/%s", text, from, to)
+	}
+	if inverse {
+		rate.Inv(rate)
+	}
+	return rate, nil
+}
@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+// exportFetch — сколько строк за раз читается из курсора выгрузки.
+const exportFetch = 1000
+
+// Export читает подписки через серверный курсор порциями по exportFetch, поэтому память не растёт
+// с размером выгрузки. Курсор живёт в read-only транзакции, которая видит один снимок данных.
+func (r *SubscriptionRepo) Export(ctx context.Context, f usecase.ListFilter, fn func(*domain.Subscription) error) error {
+	conds, args := listConds(usecase.TenantFrom(ctx), f)
+	var fnErr error
+	err := pgx.BeginTxFunc(ctx, r.pool, pgx.TxOptions{AccessMode: pgx.ReadOnly, IsoLevel: pgx.RepeatableRead}, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `DECLARE export_cur NO SCROLL CURSOR FOR
+			SELECT `+subColumns+` FROM subscriptions `+whereClause(conds)+` ORDER BY `+orderBy(f.Sort.OrDefault()), args...)
+		if err != nil {
+			return err
+		}
+		for {
+			rows, err := tx.Query(ctx, `FETCH FORWARD `+itoa(exportFetch)+` FROM export_cur`)
+			if err != nil {
+				return err
+			}
+			subs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.Subscription, error) {
+				return scanSub(row)
+			})
+			if err != nil {
+				return err
+			}
+			for _, s := range subs {
+				if fnErr = fn(s); fnErr != nil {
+					return fnErr
+				}
+			}
+			if len(subs) < exportFetch {
+				return nil
+			}
+		}
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	return mapErr(err)
+}
@@ -0,0 +1,10 @@
+-- Роль, которой подключается сервис. Политики RLS (миграция 0015) не действуют на суперпользователей
+-- и роли с BYPASSRLS, поэтому сервис работает отдельной ролью без этих прав, а postgres только
+-- применяет миграции. Таблицы, которые миграции создадут от имени postgres, сразу доступны роли.
+CREATE ROLE subscriptions_app LOGIN PASSWORD 'subscriptions_app' NOSUPERUSER NOBYPASSRLS NOCREATEDB NOCREATEROLE;
+GRANT CONNECT ON DATABASE subscriptions TO subscriptions_app;
+GRANT USAGE ON SCHEMA public TO subscriptions_app;
+ALTER DEFAULT PRIVILEGES FOR ROLE postgres IN SCHEMA public
+    GRANT SELECT, INSERT, UPDATE, DELETE ON TABLES TO subscriptions_app;
+ALTER DEFAULT PRIVILEGES FOR ROLE postgres IN SCHEMA public
+    GRANT USAGE, SELECT ON SEQUENCES TO subscriptions_app;
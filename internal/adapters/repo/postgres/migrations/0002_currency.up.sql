@@ -0,0 +1,5 @@
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'RUB'
+        CHECK (currency IN ('RUB', 'USD', 'EUR', 'GBP', 'CNY', 'KZT'));
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_currency ON subscriptions (currency);
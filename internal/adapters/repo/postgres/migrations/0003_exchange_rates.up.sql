@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    from_currency CHAR(3) NOT NULL,
+    to_currency CHAR(3) NOT NULL,
+    month DATE NOT NULL,
+    rate NUMERIC(24, 10) NOT NULL CHECK (rate > 0),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (from_currency, to_currency, month),
+    CHECK (from_currency <> to_currency)
+);
@@ -0,0 +1,4 @@
+-- Для подписок с точностью до месяца start_date/end_date хранят первое число месяца,
+-- для day_precision = true — фактические даты начала и окончания.
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS day_precision BOOLEAN NOT NULL DEFAULT false;
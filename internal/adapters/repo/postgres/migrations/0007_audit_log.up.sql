@@ -0,0 +1,24 @@
+-- Журнал аудита не ссылается на subscriptions: записи должны пережить удаление подписки.
+CREATE TABLE IF NOT EXISTS subscription_audit (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id UUID NOT NULL,
+    operation TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    request_id TEXT NOT NULL DEFAULT '',
+    before JSONB NULL,
+    after JSONB NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_audit_subscription ON subscription_audit (subscription_id, id);
+
+-- Журнал только дописывается.
+CREATE OR REPLACE FUNCTION subscription_audit_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'subscription_audit is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER subscription_audit_append_only
+    BEFORE UPDATE OR DELETE ON subscription_audit
+    FOR EACH ROW EXECUTE FUNCTION subscription_audit_append_only();
@@ -0,0 +1,3 @@
+DELETE FROM subscriptions WHERE deleted_at IS NOT NULL;
+DROP INDEX IF EXISTS idx_subscriptions_deleted;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,4 @@
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ NULL;
+
+-- Удалённых подписок немного; индекс нужен задаче окончательного удаления.
+CREATE INDEX IF NOT EXISTS idx_subscriptions_deleted ON subscriptions (deleted_at) WHERE deleted_at IS NOT NULL;
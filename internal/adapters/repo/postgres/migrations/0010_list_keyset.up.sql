@@ -0,0 +1,2 @@
+-- Страницы списка читаются по курсору (created_at, id) в обратном порядке.
+CREATE INDEX IF NOT EXISTS idx_subscriptions_created ON subscriptions (created_at, id);
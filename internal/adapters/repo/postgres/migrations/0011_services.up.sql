@@ -0,0 +1,56 @@
+CREATE TABLE IF NOT EXISTS services (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL CHECK (char_length(name) > 0),
+    aliases TEXT[] NOT NULL DEFAULT '{}',
+    category TEXT NOT NULL DEFAULT '',
+    default_price INTEGER NULL CHECK (default_price >= 0),
+    currency CHAR(3) NOT NULL DEFAULT 'RUB' CHECK (currency IN ('RUB', 'USD', 'EUR', 'GBP', 'CNY', 'KZT')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Ключи поиска сервиса: нормализованные название и псевдонимы (domain.NormalizeServiceName).
+-- Первичный ключ не даёт двум сервисам иметь одинаковое написание.
+CREATE TABLE IF NOT EXISTS service_keys (
+    key TEXT PRIMARY KEY,
+    service_id UUID NOT NULL REFERENCES services (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_service_keys_service ON service_keys (service_id);
+
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS service_id UUID NULL REFERENCES services (id);
+
+-- collapse_spaces повторяет strings.Fields из domain.NormalizeServiceName: пробельные символы
+-- Unicode (unicode.IsSpace, в том числе неразрывный пробел) схлопываются в один пробел и
+-- отбрасываются по краям. Функция временная и пропадает вместе с сеансом миграции.
+CREATE FUNCTION pg_temp.collapse_spaces(s TEXT) RETURNS TEXT LANGUAGE sql IMMUTABLE AS $$
+    SELECT regexp_replace(
+        regexp_replace(s, '[\u0009-\u000D\u0020\u0085\u00A0\u1680\u2000-\u200A\u2028\u2029\u202F\u205F\u3000]+', ' ', 'g'),
+        '^ | $', '', 'g')
+$$;
+
+-- Заполнение каталога: по сервису на каждое различное (без учёта регистра и пробелов) название;
+-- каноническим становится самое раннее написание.
+WITH names AS (
+    SELECT DISTINCT ON (lower(name)) name
+    FROM (
+        SELECT pg_temp.collapse_spaces(service_name) AS name, created_at
+        FROM subscriptions
+    ) t
+    ORDER BY lower(name), created_at
+), inserted AS (
+    INSERT INTO services (id, name)
+    SELECT gen_random_uuid(), name FROM names
+    RETURNING id, name
+)
+INSERT INTO service_keys (key, service_id)
+SELECT lower(name), id FROM inserted;
+
+-- Подписки только привязываются к сервису: service_name не меняется, поэтому версии подписок
+-- (ETag у клиентов) и журнал аудита остаются верными.
+UPDATE subscriptions s
+SET service_id = k.service_id
+FROM service_keys k
+WHERE k.key = lower(pg_temp.collapse_spaces(s.service_name));
+
+ALTER TABLE subscriptions ALTER COLUMN service_id SET NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_subscriptions_service_id ON subscriptions (service_id);
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS users (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL CHECK (char_length(name) > 0),
+    email TEXT NULL,
+    team TEXT NOT NULL DEFAULT '',
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ NULL
+);
+
+-- email уникален без учёта регистра среди неудалённых пользователей.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (lower(email)) WHERE deleted_at IS NULL AND email IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_users_name ON users (name, id) WHERE deleted_at IS NULL;
+
+-- Владельцы уже сохранённых подписок становятся пользователями; имя — их ID, его можно поменять позже.
+INSERT INTO users (id, name)
+SELECT DISTINCT user_id, user_id::text FROM subscriptions
+ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE subscriptions ADD CONSTRAINT subscriptions_user_id_fkey FOREIGN KEY (user_id) REFERENCES users (id);
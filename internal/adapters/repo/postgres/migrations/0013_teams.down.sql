@@ -0,0 +1,5 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS team TEXT NOT NULL DEFAULT '';
+UPDATE users u SET team = t.name FROM teams t WHERE t.id = u.team_id;
+ALTER TABLE users DROP COLUMN IF EXISTS team_id;
+DROP TABLE IF EXISTS teams;
+DROP TABLE IF EXISTS organizations;
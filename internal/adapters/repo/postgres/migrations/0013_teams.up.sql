@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS organizations (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL CHECK (char_length(name) > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS teams (
+    id UUID PRIMARY KEY,
+    org_id UUID NOT NULL REFERENCES organizations (id),
+    parent_id UUID NULL REFERENCES teams (id),
+    name TEXT NOT NULL CHECK (char_length(name) > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (parent_id IS NULL OR parent_id <> id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_teams_org_name ON teams (org_id, lower(name));
+CREATE INDEX IF NOT EXISTS idx_teams_parent ON teams (parent_id);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS team_id UUID NULL REFERENCES teams (id);
+CREATE INDEX IF NOT EXISTS idx_users_team ON users (team_id) WHERE deleted_at IS NULL;
+
+-- Текстовые команды пользователей становятся командами верхнего уровня организации «Default».
+WITH org AS (
+    INSERT INTO organizations (id, name)
+    SELECT gen_random_uuid(), 'Default'
+    WHERE EXISTS (SELECT 1 FROM users WHERE team <> '')
+    RETURNING id
+)
+INSERT INTO teams (id, org_id, name)
+SELECT gen_random_uuid(), org.id, t.name
+FROM org, (SELECT DISTINCT ON (lower(team)) team AS name FROM users WHERE team <> '' ORDER BY lower(team), team) t;
+
+UPDATE users u SET team_id = t.id
+FROM teams t
+WHERE u.team <> '' AND lower(t.name) = lower(u.team);
+
+ALTER TABLE users DROP COLUMN IF EXISTS team;
@@ -0,0 +1,72 @@
+DROP POLICY IF EXISTS exchange_rates_tenant ON exchange_rates;
+ALTER TABLE exchange_rates NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE exchange_rates DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS api_keys_tenant ON api_keys;
+ALTER TABLE api_keys NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE api_keys DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS teams_tenant ON teams;
+ALTER TABLE teams NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE teams DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS organizations_tenant ON organizations;
+ALTER TABLE organizations NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE organizations DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS users_tenant ON users;
+ALTER TABLE users NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE users DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS service_keys_tenant ON service_keys;
+ALTER TABLE service_keys NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE service_keys DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS services_tenant ON services;
+ALTER TABLE services NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE services DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS subscription_audit_tenant ON subscription_audit;
+ALTER TABLE subscription_audit NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE subscription_audit DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS subscription_prices_tenant ON subscription_prices;
+ALTER TABLE subscription_prices NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE subscription_prices DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS subscriptions_tenant ON subscriptions;
+ALTER TABLE subscriptions NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE subscriptions DISABLE ROW LEVEL SECURITY;
+
+ALTER TABLE teams DROP CONSTRAINT IF EXISTS teams_parent_tenant_fkey;
+ALTER TABLE teams DROP CONSTRAINT IF EXISTS teams_org_tenant_fkey;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_team_tenant_fkey;
+ALTER TABLE api_keys DROP CONSTRAINT IF EXISTS api_keys_user_tenant_fkey;
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_user_tenant_fkey;
+DROP INDEX IF EXISTS idx_teams_id_tenant;
+DROP INDEX IF EXISTS idx_organizations_id_tenant;
+DROP INDEX IF EXISTS idx_users_id_tenant;
+DROP INDEX IF EXISTS idx_users_tenant_name;
+CREATE INDEX IF NOT EXISTS idx_users_name ON users (name, id) WHERE deleted_at IS NULL;
+DROP INDEX IF EXISTS idx_users_tenant_email;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (lower(email)) WHERE deleted_at IS NULL AND email IS NOT NULL;
+
+ALTER TABLE subscriptions DROP CONSTRAINT IF EXISTS subscriptions_service_tenant_fkey;
+DROP INDEX IF EXISTS idx_services_id_tenant;
+ALTER TABLE service_keys DROP CONSTRAINT IF EXISTS service_keys_pkey;
+ALTER TABLE service_keys ADD PRIMARY KEY (key);
+
+-- Курсы арендаторов сливаются в общий справочник; при совпадении пары и месяца остаётся
+-- курс арендатора по умолчанию.
+DELETE FROM exchange_rates WHERE tenant_id <> 'default'
+    AND (from_currency, to_currency, month) IN (SELECT from_currency, to_currency, month FROM exchange_rates WHERE tenant_id = 'default');
+DELETE FROM exchange_rates a USING exchange_rates b
+    WHERE a.tenant_id <> 'default' AND b.tenant_id <> 'default' AND a.tenant_id > b.tenant_id
+    AND a.from_currency = b.from_currency AND a.to_currency = b.to_currency AND a.month = b.month;
+ALTER TABLE exchange_rates DROP CONSTRAINT IF EXISTS exchange_rates_pkey;
+ALTER TABLE exchange_rates ADD PRIMARY KEY (from_currency, to_currency, month);
+
+DROP INDEX IF EXISTS idx_api_keys_tenant;
+DROP INDEX IF EXISTS idx_subscriptions_tenant_user;
+DROP INDEX IF EXISTS idx_subscriptions_tenant_created;
+
+ALTER TABLE exchange_rates DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE teams DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE organizations DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE users DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE service_keys DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE services DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE subscription_audit DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS tenant_id;
@@ -0,0 +1,125 @@
+-- Все данные, созданные до разделения по арендаторам, принадлежат арендатору по умолчанию.
+-- Значение по умолчанию заполняет столбцы без UPDATE (журнал аудита только дописывается)
+-- и сразу снимается: новые строки получают арендатора явно.
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE subscriptions ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE subscription_audit ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE subscription_audit ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE api_keys ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE users ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE organizations ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE organizations ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE teams ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE services ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE services ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE service_keys ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE service_keys ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE exchange_rates ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE exchange_rates ALTER COLUMN tenant_id DROP DEFAULT;
+
+-- Каталог сервисов у каждого арендатора свой: написание занято только внутри арендатора,
+-- а подписка ссылается лишь на сервис своего арендатора.
+ALTER TABLE service_keys DROP CONSTRAINT IF EXISTS service_keys_pkey;
+ALTER TABLE service_keys ADD PRIMARY KEY (tenant_id, key);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_services_id_tenant ON services (id, tenant_id);
+ALTER TABLE subscriptions ADD CONSTRAINT subscriptions_service_tenant_fkey
+    FOREIGN KEY (service_id, tenant_id) REFERENCES services (id, tenant_id);
+
+-- Пользователи, организации и команды тоже принадлежат арендатору, и ссылки между ними,
+-- как и ссылки подписок и API-ключей на пользователей, не выходят за его пределы.
+-- email уникален среди неудалённых пользователей арендатора.
+DROP INDEX IF EXISTS idx_users_email;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_tenant_email ON users (tenant_id, lower(email)) WHERE deleted_at IS NULL AND email IS NOT NULL;
+DROP INDEX IF EXISTS idx_users_name;
+CREATE INDEX IF NOT EXISTS idx_users_tenant_name ON users (tenant_id, name, id) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_id_tenant ON users (id, tenant_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_organizations_id_tenant ON organizations (id, tenant_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_teams_id_tenant ON teams (id, tenant_id);
+ALTER TABLE subscriptions ADD CONSTRAINT subscriptions_user_tenant_fkey
+    FOREIGN KEY (user_id, tenant_id) REFERENCES users (id, tenant_id);
+ALTER TABLE api_keys ADD CONSTRAINT api_keys_user_tenant_fkey
+    FOREIGN KEY (user_id, tenant_id) REFERENCES users (id, tenant_id);
+ALTER TABLE users ADD CONSTRAINT users_team_tenant_fkey
+    FOREIGN KEY (team_id, tenant_id) REFERENCES teams (id, tenant_id);
+ALTER TABLE teams ADD CONSTRAINT teams_org_tenant_fkey
+    FOREIGN KEY (org_id, tenant_id) REFERENCES organizations (id, tenant_id);
+ALTER TABLE teams ADD CONSTRAINT teams_parent_tenant_fkey
+    FOREIGN KEY (parent_id, tenant_id) REFERENCES teams (id, tenant_id);
+
+-- Курсы валют арендатор загружает для себя: администратор одного арендатора не меняет
+-- пересчёт сводок другого.
+ALTER TABLE exchange_rates DROP CONSTRAINT IF EXISTS exchange_rates_pkey;
+ALTER TABLE exchange_rates ADD PRIMARY KEY (tenant_id, from_currency, to_currency, month);
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_tenant_created ON subscriptions (tenant_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_tenant_user ON subscriptions (tenant_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_api_keys_tenant ON api_keys (tenant_id, created_at);
+
+-- Строки видны, только если их арендатор совпадает с настройкой сеанса app.tenant_id, которую
+-- сервис задаёт при каждом запросе. app.all_tenants включают до конца транзакции обслуживающие
+-- операции над всеми арендаторами (окончательное удаление подписок) и поиск API-ключа по секрету,
+-- когда арендатор вызова ещё не известен.
+-- FORCE распространяет политики и на владельца таблиц; суперпользователи и роли с BYPASSRLS
+-- их по-прежнему обходят.
+ALTER TABLE subscriptions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscriptions FORCE ROW LEVEL SECURITY;
+CREATE POLICY subscriptions_tenant ON subscriptions
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+-- Изменения цен видны вместе со своей подпиской.
+ALTER TABLE subscription_prices ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscription_prices FORCE ROW LEVEL SECURITY;
+CREATE POLICY subscription_prices_tenant ON subscription_prices
+    USING (EXISTS (SELECT 1 FROM subscriptions s WHERE s.id = subscription_id));
+
+ALTER TABLE subscription_audit ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscription_audit FORCE ROW LEVEL SECURITY;
+CREATE POLICY subscription_audit_tenant ON subscription_audit
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE services ENABLE ROW LEVEL SECURITY;
+ALTER TABLE services FORCE ROW LEVEL SECURITY;
+CREATE POLICY services_tenant ON services
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE service_keys ENABLE ROW LEVEL SECURITY;
+ALTER TABLE service_keys FORCE ROW LEVEL SECURITY;
+CREATE POLICY service_keys_tenant ON service_keys
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE users ENABLE ROW LEVEL SECURITY;
+ALTER TABLE users FORCE ROW LEVEL SECURITY;
+CREATE POLICY users_tenant ON users
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE organizations ENABLE ROW LEVEL SECURITY;
+ALTER TABLE organizations FORCE ROW LEVEL SECURITY;
+CREATE POLICY organizations_tenant ON organizations
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE teams ENABLE ROW LEVEL SECURITY;
+ALTER TABLE teams FORCE ROW LEVEL SECURITY;
+CREATE POLICY teams_tenant ON teams
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE api_keys ENABLE ROW LEVEL SECURITY;
+ALTER TABLE api_keys FORCE ROW LEVEL SECURITY;
+CREATE POLICY api_keys_tenant ON api_keys
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+
+ALTER TABLE exchange_rates ENABLE ROW LEVEL SECURITY;
+ALTER TABLE exchange_rates FORCE ROW LEVEL SECURITY;
+CREATE POLICY exchange_rates_tenant ON exchange_rates
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on')
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
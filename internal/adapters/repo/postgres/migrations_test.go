@@ -0,0 +1,106 @@
+package postgres
+
+import (
+	"context"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+)
+
+// Заполнение каталога в миграции 0011 нормализует названия так же, как domain.NormalizeServiceName:
+// подписка, созданная до миграции, попадает в тот же сервис, что и созданная после.
+func TestServiceBackfillMatchesDomain(t *testing.T) {
+	owner, _, _ := testSchema(t)
+	ctx := context.Background()
+	files := migrations(t)
+	split := slices.IndexFunc(files, func(f string) bool { return strings.Contains(f, "0011_services") })
+	applyMigrations(t, owner, files[:split])
+
+	names := []string{
+		"Yandex Plus",
+		"  yandex\tPLUS ",
+		"Yandex\u00a0Plus",        // неразрывный пробел
+		"yandex \u3000plus\u2009", // идеографический и тонкий пробелы
+		"\u0085YANDEX plus",
+		"Netflix",
+		"net flix",
+	}
+	for i, name := range names {
+		_, err := owner.Exec(ctx, `INSERT INTO subscriptions (id, service_name, price, user_id, start_date, created_at)
+			VALUES ($1, $2, 100, $3, '2025-01-01', NOW() + make_interval(secs => $4))`, uuid.New(), name, uuid.New(), i)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	applyMigrations(t, owner, files[split:])
+	if _, err := owner.Exec(ctx, "SET app.all_tenants = 'on'"); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := owner.Query(ctx, `SELECT s.service_name, v.name, k.key
+		FROM subscriptions s JOIN services v ON v.id = s.service_id JOIN service_keys k ON k.service_id = v.id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	services := make(map[string]string) // ключ domain.NormalizeServiceName → каноническое название
+	n := 0
+	for rows.Next() {
+		var name, canonical, key string
+		if err := rows.Scan(&name, &canonical, &key); err != nil {
+			t.Fatal(err)
+		}
+		n++
+		want := domain.NormalizeServiceName(name)
+		if key != want || domain.NormalizeServiceName(canonical) != want {
+			t.Errorf("%q: service %q with key %q, want key %q", name, canonical, key, want)
+		}
+		if prev, ok := services[want]; ok && prev != canonical {
+			t.Errorf("%q split from service %q into %q", name, prev, canonical)
+		}
+		services[want] = canonical
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if n != len(names) || services["yandex plus"] != "Yandex Plus" || services["net flix"] != "net flix" {
+		t.Fatalf("%d subscriptions linked, services %q", n, services)
+	}
+}
+
+// Класс пробельных символов collapse_spaces из миграции 0011 совпадает с unicode.IsSpace,
+// на котором построен strings.Fields.
+func TestCollapseSpacesClassMatchesUnicode(t *testing.T) {
+	sql, err := os.ReadFile("migrations/0011_services.up.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	class := regexp.MustCompile(`\[((?:\\u[0-9A-F]{4}(?:-\\u[0-9A-F]{4})?)+)\]`).FindSubmatch(sql)
+	if class == nil {
+		t.Fatal("collapse_spaces class not found")
+	}
+	in := make(map[rune]bool)
+	for _, m := range regexp.MustCompile(`\\u([0-9A-F]{4})(?:-\\u([0-9A-F]{4}))?`).FindAllSubmatch(class[1], -1) {
+		lo, _ := strconv.ParseUint(string(m[1]), 16, 32)
+		hi := lo
+		if len(m[2]) > 0 {
+			hi, _ = strconv.ParseUint(string(m[2]), 16, 32)
+		}
+		for r := lo; r <= hi; r++ {
+			in[rune(r)] = true
+		}
+	}
+	for r := rune(0); r <= unicode.MaxRune; r++ {
+		if in[r] != unicode.IsSpace(r) {
+			t.Errorf("U+%04X: in class %v, unicode.IsSpace %v", r, in[r], unicode.IsSpace(r))
+		}
+	}
+}
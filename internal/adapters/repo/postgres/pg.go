@@ -3,13 +3,38 @@ package postgres
 import (
 	"context"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/oziev02/subscriptions-service/internal/usecase"
 )
 
 func NewPool(ctx context.Context, dsn string) (*pgxpool.Pool, error) {
-	cfg, err := pgxpool.ParseConfig(dsn)
+	cfg, err := poolConfig(dsn)
 	if err != nil {
 		return nil, err
 	}
 	return pgxpool.NewWithConfig(ctx, cfg)
 }
+
+func poolConfig(dsn string) (*pgxpool.Config, error) {
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	// Политики RLS (миграция 0015) сравнивают арендатора строки с настройкой сеанса app.tenant_id,
+	// поэтому соединение получает арендатора вызова каждый раз, когда берётся из пула.
+	cfg.BeforeAcquire = func(ctx context.Context, conn *pgx.Conn) bool {
+		_, err := conn.Exec(ctx, "SELECT set_config('app.tenant_id', $1, false)", usecase.TenantFrom(ctx))
+		return err == nil
+	}
+	return cfg, nil
+}
+
+// BypassesRLS сообщает, обходит ли роль соединения политики RLS: суперпользователь или роль
+// с BYPASSRLS видит данные всех арендаторов, и разделение держится только на условиях в запросах.
+func BypassesRLS(ctx context.Context, pool *pgxpool.Pool) (bool, error) {
+	var bypass bool
+	err := pool.QueryRow(ctx, `SELECT rolsuper OR rolbypassrls FROM pg_roles WHERE rolname = current_user`).Scan(&bypass)
+	return bypass, err
+}
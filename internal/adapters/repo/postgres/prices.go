@@ -0,0 +1,109 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+// Изменения цен принадлежат арендатору подписки, поэтому каждый запрос проверяет её арендатора.
+
+const priceColumns = `effective_from, price, created_at`
+
+func (r *SubscriptionRepo) SavePriceChange(ctx context.Context, pc *domain.PriceChange) error {
+	const q = `INSERT INTO subscription_prices (subscription_id, ` + priceColumns + `)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (subscription_id, effective_from) DO UPDATE
+		SET price = EXCLUDED.price, created_at = EXCLUDED.created_at`
+	return r.changePrices(ctx, pc.SubscriptionID, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, q, pc.SubscriptionID, pc.EffectiveFrom.Time(), pc.Price, pc.CreatedAt)
+		return err
+	})
+}
+
+func (r *SubscriptionRepo) ListPriceChanges(ctx context.Context, subscriptionID uuid.UUID) ([]domain.PriceChange, error) {
+	const q = `SELECT p.effective_from, p.price, p.created_at
+		FROM subscription_prices p
+		JOIN subscriptions s ON s.id = p.subscription_id
+		WHERE p.subscription_id = $1 AND s.tenant_id = $2
+		ORDER BY p.effective_from`
+	rows, err := r.pool.Query(ctx, q, subscriptionID, usecase.TenantFrom(ctx))
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	res, err := scanPrices(rows, subscriptionID)
+	return res, mapErr(err)
+}
+
+func (r *SubscriptionRepo) DeletePriceChange(ctx context.Context, subscriptionID uuid.UUID, effectiveFrom domain.YearMonth) error {
+	return r.changePrices(ctx, subscriptionID, func(tx pgx.Tx) error {
+		cmd, err := tx.Exec(ctx, `DELETE FROM subscription_prices WHERE subscription_id=$1 AND effective_from=$2`,
+			subscriptionID, effectiveFrom.Time())
+		if err != nil {
+			return err
+		}
+		if cmd.RowsAffected() == 0 {
+			return domain.Errorf(domain.ErrNotFound, "price change not found")
+		}
+		return nil
+	})
+}
+
+// changePrices меняет график цен неудалённой подписки арендатора вызова в одной транзакции
+// с новой версией подписки и записью в журнале, где снимки содержат график до и после.
+func (r *SubscriptionRepo) changePrices(ctx context.Context, id uuid.UUID, fn func(tx pgx.Tx) error) error {
+	return r.inTx(ctx, func(tx pgx.Tx) error {
+		before, err := lockSub(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if before.Deleted() {
+			return pgx.ErrNoRows
+		}
+		if before.PriceChanges, err = txPrices(ctx, tx, id); err != nil {
+			return err
+		}
+		if err := fn(tx); err != nil {
+			return err
+		}
+		after, err := scanSub(tx.QueryRow(ctx, `UPDATE subscriptions SET updated_at=NOW(), version=version+1
+			WHERE id=$1 AND tenant_id=$2 RETURNING `+subColumns, id, before.TenantID))
+		if err != nil {
+			return err
+		}
+		if after.PriceChanges, err = txPrices(ctx, tx, id); err != nil {
+			return err
+		}
+		return insertAudit(ctx, tx, usecase.NewAuditRecord(ctx, domain.AuditPriceChange, before, after))
+	})
+}
+
+// txPrices читает график цен подписки, уже заблокированной в транзакции.
+func txPrices(ctx context.Context, tx pgx.Tx, id uuid.UUID) ([]domain.PriceChange, error) {
+	rows, err := tx.Query(ctx, `SELECT `+priceColumns+` FROM subscription_prices
+		WHERE subscription_id = $1 ORDER BY effective_from`, id)
+	if err != nil {
+		return nil, err
+	}
+	return scanPrices(rows, id)
+}
+
+func scanPrices(rows pgx.Rows, subscriptionID uuid.UUID) ([]domain.PriceChange, error) {
+	defer rows.Close()
+	var res []domain.PriceChange
+	for rows.Next() {
+		pc := domain.PriceChange{SubscriptionID: subscriptionID}
+		var from time.Time
+		if err := rows.Scan(&from, &pc.Price, &pc.CreatedAt); err != nil {
+			return nil, err
+		}
+		pc.EffectiveFrom = domain.YearMonthFromTime(from)
+		res = append(res, pc)
+	}
+	return res, rows.Err()
+}
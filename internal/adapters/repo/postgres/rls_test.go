@@ -0,0 +1,249 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+// testSchema создаёт отдельную схему в базе TEST_DATABASE_DSN и возвращает соединение её владельца
+// (search_path указывает на схему), DSN и имя схемы. Без TEST_DATABASE_DSN тест пропускается.
+func testSchema(t *testing.T) (*pgx.Conn, string, string) {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	ctx := context.Background()
+	owner, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = owner.Close(ctx) })
+
+	schema := "rls_test_" + strings.ReplaceAll(uuid.NewString(), "-", "")[:12]
+	t.Cleanup(func() {
+		if _, err := owner.Exec(ctx, "DROP SCHEMA IF EXISTS "+schema+" CASCADE"); err != nil {
+			t.Logf("cleanup: drop schema %s: %v", schema, err)
+		}
+	})
+	if _, err := owner.Exec(ctx, "CREATE SCHEMA "+schema+"; SET search_path TO "+schema+", public"); err != nil {
+		t.Fatal(err)
+	}
+	return owner, dsn, schema
+}
+
+// migrations возвращает файлы миграций вверх по порядку.
+func migrations(t *testing.T) []string {
+	t.Helper()
+	files, err := filepath.Glob("migrations/*.up.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(files)
+	return files
+}
+
+func applyMigrations(t *testing.T, conn *pgx.Conn, files []string) {
+	t.Helper()
+	for _, f := range files {
+		sql, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := conn.Exec(context.Background(), string(sql)); err != nil {
+			t.Fatalf("%s: %v", f, err)
+		}
+	}
+}
+
+// testPool применяет миграции в отдельной схеме базы TEST_DATABASE_DSN и возвращает пул, соединения
+// которого работают ролью без прав владельца таблиц с теми же атрибутами, что и роль сервиса
+// из initdb/01_app_role.sql. Пользователь из TEST_DATABASE_DSN должен уметь создавать схемы и роли
+// (например, postgres из docker-compose).
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	owner, dsn, schema := testSchema(t)
+	ctx := context.Background()
+	applyMigrations(t, owner, migrations(t))
+
+	role := "rls_test_app_" + strings.TrimPrefix(schema, "rls_test_")
+	t.Cleanup(func() {
+		for _, q := range []string{"DROP OWNED BY " + role, "DROP ROLE IF EXISTS " + role} {
+			if _, err := owner.Exec(ctx, q); err != nil {
+				t.Logf("cleanup: %s: %v", q, err)
+			}
+		}
+	})
+	if _, err := owner.Exec(ctx, `CREATE ROLE `+role+` NOLOGIN NOSUPERUSER NOBYPASSRLS;
+		GRANT `+role+` TO CURRENT_USER;
+		GRANT USAGE ON SCHEMA `+schema+` TO `+role+`;
+		GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA `+schema+` TO `+role+`;
+		GRANT USAGE, SELECT ON ALL SEQUENCES IN SCHEMA `+schema+` TO `+role); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := poolConfig(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+		_, err := conn.Exec(ctx, "SET ROLE "+role+"; SET search_path TO "+schema+", public")
+		return err
+	}
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+	if bypass, err := BypassesRLS(ctx, pool); err != nil || bypass {
+		t.Fatalf("test role bypasses RLS: %v, err %v", bypass, err)
+	}
+	return pool
+}
+
+func tenantAdmin(tenant string) context.Context {
+	return usecase.WithPrincipal(context.Background(), domain.Principal{Subject: tenant, TenantID: tenant, Roles: []string{domain.RoleAdmin}})
+}
+
+// Разделение арендаторов на настоящей базе: данные арендатора acme не видны и не меняются
+// из globex ни через сервис, ни запросами без условия по арендатору, которые ограничивает RLS.
+func TestTenantIsolation(t *testing.T) {
+	pool := testPool(t)
+	svc := usecase.NewService(usecase.Repos{
+		Subscriptions: NewSubscriptionRepo(pool, zap.NewNop()),
+		Services:      NewServiceRepo(pool),
+		Users:         NewUserRepo(pool),
+		Orgs:          NewOrgRepo(pool),
+		Teams:         NewTeamRepo(pool),
+		APIKeys:       NewAPIKeyRepo(pool),
+		Rates:         NewExchangeRateRepo(pool),
+	})
+	acme, globex := tenantAdmin("acme"), tenantAdmin("globex")
+
+	org, err := svc.CreateOrg(acme, usecase.OrgInput{Name: "Acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	team, err := svc.CreateTeam(acme, usecase.TeamInput{OrgID: org.ID, Name: "Platform"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := svc.CreateUser(acme, usecase.UserInput{Name: "Alice", TeamID: &team.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, err := svc.Create(acme, usecase.CreateInput{ServiceName: "Netflix", Price: 500, UserID: user.ID, StartDate: "01-2025"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, secret, err := svc.CreateAPIKey(acme, usecase.APIKeyInput{Name: "ops", UserID: &user.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rate := []usecase.RateInput{{From: "USD", To: "RUB", Month: "01-2025", Rate: "90"}}
+	if _, err := svc.UploadRates(acme, rate); err != nil {
+		t.Fatal(err)
+	}
+
+	price := 1
+	period := usecase.SummaryInput{From: "01-2025", To: "12-2025"}
+	tests := []struct {
+		name string
+		op   func(ctx context.Context) error
+	}{
+		{"get", func(ctx context.Context) error { _, err := svc.Get(ctx, sub.ID, true); return err }},
+		{"update", func(ctx context.Context) error {
+			_, err := svc.Update(ctx, sub.ID, usecase.UpdateInput{Price: &price})
+			return err
+		}},
+		{"delete", func(ctx context.Context) error { return svc.Delete(ctx, sub.ID, 0) }},
+		{"get service", func(ctx context.Context) error { _, err := svc.GetService(ctx, sub.ServiceID); return err }},
+		{"get user", func(ctx context.Context) error { _, err := svc.GetUser(ctx, user.ID); return err }},
+		{"update user", func(ctx context.Context) error {
+			_, err := svc.UpdateUser(ctx, user.ID, usecase.UserInput{Name: "Mallory"})
+			return err
+		}},
+		{"delete user", func(ctx context.Context) error { return svc.DeleteUser(ctx, user.ID, usecase.DeleteCascade) }},
+		{"get org", func(ctx context.Context) error { _, err := svc.GetOrg(ctx, org.ID); return err }},
+		{"update team", func(ctx context.Context) error {
+			_, err := svc.UpdateTeam(ctx, team.ID, usecase.TeamInput{Name: "Hijacked"})
+			return err
+		}},
+		{"org cost tree", func(ctx context.Context) error { _, err := svc.OrgCostTree(ctx, org.ID, period); return err }},
+		{"team summary", func(ctx context.Context) error {
+			in := period
+			in.TeamID = &team.ID
+			_, err := svc.Summary(ctx, in)
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.op(globex); !errors.Is(err, domain.ErrNotFound) && !errors.Is(err, domain.ErrForbidden) {
+				t.Fatalf("err = %v, want not found or forbidden", err)
+			}
+		})
+	}
+
+	if page, err := svc.List(globex, usecase.ListFilter{IncludeDeleted: true}); err != nil || len(page.Items) != 0 {
+		t.Fatalf("list from another tenant: %d items, err %v", len(page.Items), err)
+	}
+	if users, err := svc.ListUsers(globex, usecase.UserFilter{}); err != nil || len(users) != 0 {
+		t.Fatalf("users from another tenant: %d, err %v", len(users), err)
+	}
+	if res, err := svc.Summary(globex, period); err != nil || res.Total != 0 {
+		t.Fatalf("summary from another tenant: %+v, err %v", res, err)
+	}
+
+	// Запросы без условия по арендатору ограничивают политики RLS.
+	for _, table := range []string{"subscriptions", "subscription_audit", "services", "service_keys", "users", "organizations", "teams", "api_keys", "exchange_rates"} {
+		var n int
+		if err := pool.QueryRow(globex, "SELECT count(*) FROM "+table).Scan(&n); err != nil || n != 0 {
+			t.Fatalf("%s visible to another tenant: %d rows, err %v", table, n, err)
+		}
+		if err := pool.QueryRow(acme, "SELECT count(*) FROM "+table).Scan(&n); err != nil || n == 0 {
+			t.Fatalf("%s not visible to its tenant: %d rows, err %v", table, n, err)
+		}
+	}
+	tag, err := pool.Exec(globex, "UPDATE subscriptions SET price = 1")
+	if err != nil || tag.RowsAffected() != 0 {
+		t.Fatalf("update from another tenant: %d rows, err %v", tag.RowsAffected(), err)
+	}
+	_, err = pool.Exec(globex, "INSERT INTO organizations (id, name, tenant_id) VALUES ($1, 'Evil', 'acme')", uuid.New())
+	if err := mapErr(err); !errors.Is(err, domain.ErrForbidden) {
+		t.Fatalf("insert for another tenant: err = %v, want forbidden", err)
+	}
+
+	rate[0].Rate = "1"
+	if _, err := svc.UploadRates(globex, rate); err != nil {
+		t.Fatal(err)
+	}
+	if r, err := NewExchangeRateRepo(pool).Rate(acme, domain.USD, domain.RUB, domain.MustYearMonth("01-2025")); err != nil || r.RatString() != "90" {
+		t.Fatalf("rate of acme after upload by another tenant: %v, err %v", r, err)
+	}
+
+	got, err := svc.Get(acme, sub.ID, false)
+	if err != nil || got.Price != 500 || got.Version != 1 {
+		t.Fatalf("subscription changed by another tenant: %+v, err %v", got, err)
+	}
+	if u, err := svc.GetUser(acme, user.ID); err != nil || u.Name != "Alice" {
+		t.Fatalf("user changed by another tenant: %+v, err %v", u, err)
+	}
+	p, err := svc.AuthenticateAPIKey(context.Background(), secret)
+	if err != nil || p.Tenant() != "acme" {
+		t.Fatalf("API key of acme: %+v, err %v", p, err)
+	}
+}
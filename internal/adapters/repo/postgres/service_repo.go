@@ -0,0 +1,142 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+// ServiceRepo хранит каталоги сервисов арендаторов; запросы ограничены арендатором вызова, как у
+// SubscriptionRepo. Ключи поиска лежат в service_keys, и их уникальность в каталоге арендатора
+// обеспечивает первичный ключ таблицы (tenant_id, key).
+type ServiceRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewServiceRepo(pool *pgxpool.Pool) *ServiceRepo {
+	return &ServiceRepo{pool: pool}
+}
+
+const serviceColumns = `id, name, aliases, category, default_price, currency, created_at, updated_at, tenant_id`
+
+func (r *ServiceRepo) Create(ctx context.Context, svc *domain.Service) error {
+	if svc.TenantID != usecase.TenantFrom(ctx) {
+		return errOtherTenant("service")
+	}
+	return r.inTx(ctx, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `INSERT INTO services (`+serviceColumns+`) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)`,
+			svc.ID, svc.Name, aliases(svc), svc.Category, svc.DefaultPrice, svc.Currency, svc.CreatedAt, svc.UpdatedAt, svc.TenantID)
+		if err != nil {
+			return err
+		}
+		return insertKeys(ctx, tx, svc)
+	})
+}
+
+func (r *ServiceRepo) Get(ctx context.Context, id uuid.UUID) (*domain.Service, error) {
+	return scanServiceRow(r.pool.QueryRow(ctx, `SELECT `+serviceColumns+` FROM services WHERE id=$1 AND tenant_id=$2`,
+		id, usecase.TenantFrom(ctx)))
+}
+
+func (r *ServiceRepo) FindByKey(ctx context.Context, key string) (*domain.Service, error) {
+	return scanServiceRow(r.pool.QueryRow(ctx, `SELECT v.id, v.name, v.aliases, v.category, v.default_price, v.currency, v.created_at, v.updated_at, v.tenant_id
+		FROM service_keys k JOIN services v ON v.id = k.service_id
+		WHERE k.tenant_id=$1 AND k.key=$2`, usecase.TenantFrom(ctx), key))
+}
+
+func (r *ServiceRepo) Update(ctx context.Context, svc *domain.Service) error {
+	return r.inTx(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `UPDATE services
+			SET name=$2, aliases=$3, category=$4, default_price=$5, currency=$6, updated_at=$7
+			WHERE id=$1 AND tenant_id=$8`,
+			svc.ID, svc.Name, aliases(svc), svc.Category, svc.DefaultPrice, svc.Currency, svc.UpdatedAt, usecase.TenantFrom(ctx))
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return errServiceNotFound()
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM service_keys WHERE service_id=$1`, svc.ID); err != nil {
+			return err
+		}
+		return insertKeys(ctx, tx, svc)
+	})
+}
+
+func (r *ServiceRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM services WHERE id=$1 AND tenant_id=$2`, id, usecase.TenantFrom(ctx))
+	if err != nil {
+		return mapDeleteErr(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errServiceNotFound()
+	}
+	return nil
+}
+
+func (r *ServiceRepo) List(ctx context.Context) ([]*domain.Service, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+serviceColumns+` FROM services WHERE tenant_id=$1 ORDER BY name, id`,
+		usecase.TenantFrom(ctx))
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	res, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.Service, error) { return scanService(row) })
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return res, nil
+}
+
+func (r *ServiceRepo) inTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	return mapNotFound(pgx.BeginFunc(ctx, r.pool, fn), "service")
+}
+
+func insertKeys(ctx context.Context, tx pgx.Tx, svc *domain.Service) error {
+	b := &pgx.Batch{}
+	for _, k := range svc.Keys() {
+		b.Queue(`INSERT INTO service_keys (tenant_id, key, service_id) VALUES ($1, $2, $3)`, usecase.TenantFrom(ctx), k, svc.ID)
+	}
+	err := tx.SendBatch(ctx, b).Close()
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return domain.Errorf(domain.ErrConflict, "name or alias is already used by another service")
+	}
+	return err
+}
+
+// aliases не даёт записать NULL в NOT NULL столбец для сервиса без псевдонимов.
+func aliases(svc *domain.Service) []string {
+	if svc.Aliases == nil {
+		return []string{}
+	}
+	return svc.Aliases
+}
+
+func scanServiceRow(row pgx.Row) (*domain.Service, error) {
+	svc, err := scanService(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errServiceNotFound()
+	}
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return svc, nil
+}
+
+func scanService(row pgx.Row) (*domain.Service, error) {
+	var svc domain.Service
+	if err := row.Scan(&svc.ID, &svc.Name, &svc.Aliases, &svc.Category, &svc.DefaultPrice, &svc.Currency,
+		&svc.CreatedAt, &svc.UpdatedAt, &svc.TenantID); err != nil {
+		return nil, err
+	}
+	return &svc, nil
+}
+
+func errServiceNotFound() error { return domain.Errorf(domain.ErrNotFound, "service not found") }
@@ -16,6 +16,10 @@ import (
 	"github.com/oziev02/subscriptions-service/internal/usecase"
 )
 
+const subColumns = `id, service_name, price, currency, billing_period, user_id, start_date, end_date, day_precision, created_at, updated_at, deleted_at, version, service_id, tenant_id`
+
+// SubscriptionRepo хранит подписки. Каждый запрос явно ограничен арендатором вызова
+// (usecase.TenantFrom); политики RLS из миграции 0015 повторяют это ограничение в самой базе.
 type SubscriptionRepo struct {
 	pool *pgxpool.Pool
 	log  *zap.Logger
@@ -26,157 +30,358 @@ func NewSubscriptionRepo(pool *pgxpool.Pool, log *zap.Logger) *SubscriptionRepo
 }
 
 func (r *SubscriptionRepo) Create(ctx context.Context, s *domain.Subscription) error {
-	const q = `INSERT INTO subscriptions
-		(id, service_name, price, user_id, start_date, end_date, created_at, updated_at)
-		VALUES ($1,$2,$3,$4,$5,$6,$7,$8)`
-	_, err := r.pool.Exec(ctx, q, s.ID, s.ServiceName, s.Price, s.UserID, s.Start.Time(), nullableYM(s.End), s.CreatedAt, s.UpdatedAt)
-	return err
+	const q = `INSERT INTO subscriptions (` + subColumns + `)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,1,$13,$14)`
+	if s.TenantID != usecase.TenantFrom(ctx) {
+		return errOtherTenant("subscription")
+	}
+	return r.inTx(ctx, func(tx pgx.Tx) error {
+		if err := lockUser(ctx, tx, s.UserID); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, q, s.ID, s.ServiceName, s.Price, s.Currency, s.BillingPeriod, s.UserID,
+			s.FirstDay().Time(), endValue(s), s.DayPrecision(), s.CreatedAt, s.UpdatedAt, s.DeletedAt, s.ServiceID, s.TenantID)
+		if err != nil {
+			return err
+		}
+		s.Version = 1
+		return insertAudit(ctx, tx, usecase.NewAuditRecord(ctx, domain.AuditCreate, nil, s))
+	})
 }
 
 func (r *SubscriptionRepo) Get(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
-	const q = `SELECT id, service_name, price, user_id, start_date, end_date, created_at, updated_at
-		FROM subscriptions WHERE id=$1`
-	row := r.pool.QueryRow(ctx, q, id)
-	return scanSub(row)
+	const q = `SELECT ` + subColumns + ` FROM subscriptions WHERE id=$1 AND tenant_id=$2`
+	row := r.pool.QueryRow(ctx, q, id, usecase.TenantFrom(ctx))
+	s, err := scanSub(row)
+	if err != nil {
+		return nil, mapNotFound(err, "subscription")
+	}
+	return s, nil
 }
 
 func (r *SubscriptionRepo) Update(ctx context.Context, s *domain.Subscription) error {
 	const q = `UPDATE subscriptions
-		SET service_name=$2, price=$3, start_date=$4, end_date=$5, updated_at=$6
-		WHERE id=$1`
-	_, err := r.pool.Exec(ctx, q, s.ID, s.ServiceName, s.Price, s.Start.Time(), nullableYM(s.End), s.UpdatedAt)
-	return err
+		SET service_name=$2, price=$3, currency=$4, billing_period=$5, start_date=$6, end_date=$7, day_precision=$8, updated_at=$9,
+			service_id=$11, version=version+1
+		WHERE id=$1 AND tenant_id=$12 AND deleted_at IS NULL AND version=$10
+		RETURNING ` + subColumns
+	return r.inTx(ctx, func(tx pgx.Tx) error {
+		before, err := lockSub(ctx, tx, s.ID)
+		if err != nil {
+			return err
+		}
+		if before.Deleted() {
+			return pgx.ErrNoRows
+		}
+		if err := before.CheckVersion(s.Version); err != nil {
+			return err
+		}
+		after, err := scanSub(tx.QueryRow(ctx, q, s.ID, s.ServiceName, s.Price, s.Currency, s.BillingPeriod,
+			s.FirstDay().Time(), endValue(s), s.DayPrecision(), s.UpdatedAt, s.Version, s.ServiceID, before.TenantID))
+		if err != nil {
+			return err
+		}
+		s.Version = after.Version
+		return insertAudit(ctx, tx, usecase.NewAuditRecord(ctx, domain.AuditUpdate, before, after))
+	})
+}
+
+func (r *SubscriptionRepo) Delete(ctx context.Context, id uuid.UUID, version int64) error {
+	return r.inTx(ctx, func(tx pgx.Tx) error {
+		before, err := lockSub(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if before.Deleted() {
+			return pgx.ErrNoRows
+		}
+		if err := before.CheckVersion(version); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, "UPDATE subscriptions SET deleted_at=NOW(), version=version+1 WHERE id=$1 AND tenant_id=$2",
+			id, before.TenantID); err != nil {
+			return err
+		}
+		return insertAudit(ctx, tx, usecase.NewAuditRecord(ctx, domain.AuditDelete, before, nil))
+	})
+}
+
+// RenameService меняет название по одной подписке, чтобы у каждой была своя запись в журнале.
+func (r *SubscriptionRepo) RenameService(ctx context.Context, serviceID uuid.UUID, name string) (int, error) {
+	n := 0
+	err := r.inTx(ctx, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, `SELECT `+subColumns+` FROM subscriptions
+			WHERE service_id=$1 AND service_name<>$2 AND tenant_id=$3 ORDER BY id FOR UPDATE`,
+			serviceID, name, usecase.TenantFrom(ctx))
+		if err != nil {
+			return err
+		}
+		subs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.Subscription, error) { return scanSub(row) })
+		if err != nil {
+			return err
+		}
+		for _, before := range subs {
+			after, err := scanSub(tx.QueryRow(ctx, `UPDATE subscriptions SET service_name=$2, updated_at=NOW(), version=version+1
+				WHERE id=$1 AND tenant_id=$3 RETURNING `+subColumns, before.ID, name, before.TenantID))
+			if err != nil {
+				return err
+			}
+			if err := insertAudit(ctx, tx, usecase.NewAuditRecord(ctx, domain.AuditUpdate, before, after)); err != nil {
+				return err
+			}
+		}
+		n = len(subs)
+		return nil
+	})
+	return n, err
 }
 
-func (r *SubscriptionRepo) Delete(ctx context.Context, id uuid.UUID) error {
-	cmd, err := r.pool.Exec(ctx, "DELETE FROM subscriptions WHERE id=$1", id)
+func (r *SubscriptionRepo) Restore(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
+	var after *domain.Subscription
+	err := r.inTx(ctx, func(tx pgx.Tx) error {
+		before, err := lockSub(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if !before.Deleted() {
+			return domain.Errorf(domain.ErrConflict, "subscription is not deleted")
+		}
+		after, err = scanSub(tx.QueryRow(ctx, `UPDATE subscriptions SET deleted_at=NULL, version=version+1
+			WHERE id=$1 AND tenant_id=$2 RETURNING `+subColumns, id, before.TenantID))
+		if err != nil {
+			return err
+		}
+		return insertAudit(ctx, tx, usecase.NewAuditRecord(ctx, domain.AuditRestore, before, after))
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if cmd.RowsAffected() == 0 {
-		return errors.New("not found")
+	return after, nil
+}
+
+// Purge удаляет подписки всех арендаторов (изменения цен удаляются каскадно); журнал аудита сохраняется.
+func (r *SubscriptionRepo) Purge(ctx context.Context, deletedBefore time.Time) (int, error) {
+	n := 0
+	err := r.inTx(ctx, func(tx pgx.Tx) error {
+		if err := allTenants(ctx, tx); err != nil {
+			return err
+		}
+		rows, err := tx.Query(ctx, `DELETE FROM subscriptions WHERE deleted_at < $1 RETURNING `+subColumns, deletedBefore)
+		if err != nil {
+			return err
+		}
+		purged, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.Subscription, error) { return scanSub(row) })
+		if err != nil {
+			return err
+		}
+		for _, s := range purged {
+			if err := insertAudit(ctx, tx, usecase.NewAuditRecord(ctx, domain.AuditPurge, s, nil)); err != nil {
+				return err
+			}
+		}
+		n = len(purged)
+		return nil
+	})
+	return n, err
+}
+
+// lockSub читает подписку арендатора вызова под блокировкой строки, чтобы снимок «до» в журнале
+// совпадал с реальной последовательностью изменений.
+func lockSub(ctx context.Context, tx pgx.Tx, id uuid.UUID) (*domain.Subscription, error) {
+	return scanSub(tx.QueryRow(ctx, `SELECT `+subColumns+` FROM subscriptions WHERE id=$1 AND tenant_id=$2 FOR UPDATE`,
+		id, usecase.TenantFrom(ctx)))
+}
+
+// lockUser берёт разделяемую блокировку неудалённого пользователя арендатора вызова, чтобы он
+// не был удалён (UserRepo.Delete) до фиксации новой подписки.
+func lockUser(ctx context.Context, tx pgx.Tx, id uuid.UUID) error {
+	var found bool
+	err := tx.QueryRow(ctx, `SELECT true FROM users WHERE id=$1 AND tenant_id=$2 AND deleted_at IS NULL FOR SHARE`,
+		id, usecase.TenantFrom(ctx)).Scan(&found)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errUserMissing(id)
 	}
-	return nil
+	return err
+}
+
+// allTenants снимает до конца транзакции ограничение политик RLS по арендатору для обслуживающих
+// операций над всеми арендаторами: Purge и поиска API-ключа по секрету.
+func allTenants(ctx context.Context, tx pgx.Tx) error {
+	_, err := tx.Exec(ctx, "SET LOCAL app.all_tenants = 'on'")
+	return err
+}
+
+// inTx выполняет fn в транзакции; ошибки fn и фиксации проходят через mapNotFound, и pgx.ErrNoRows
+// из fn означает, что не нашлась подписка.
+func (r *SubscriptionRepo) inTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	return mapNotFound(pgx.BeginFunc(ctx, r.pool, fn), "subscription")
 }
 
 func (r *SubscriptionRepo) List(ctx context.Context, f usecase.ListFilter) ([]*domain.Subscription, error) {
-	var filters []string
-	var args []any
-	idx := 1
-	if f.UserID != nil {
-		filters = append(filters, "user_id = $"+itoa(idx))
-		args = append(args, *f.UserID)
-		idx++
-	}
-	if f.ServiceName != nil {
-		filters = append(filters, "service_name ILIKE $"+itoa(idx))
-		args = append(args, "%"+*f.ServiceName+"%")
-		idx++
-	}
-	where := ""
-	if len(filters) > 0 {
-		where = "WHERE " + strings.Join(filters, " AND ")
+	conds, args := listConds(usecase.TenantFrom(ctx), f)
+	sort := f.Sort.OrDefault()
+	if f.Cursor != nil {
+		op := ">"
+		if sort.Desc {
+			op = "<"
+		}
+		args = append(args, f.Cursor.Key, f.Cursor.ID)
+		conds = append(conds, "("+sortColumns[sort.Field]+", id) "+op+" ($"+itoa(len(args)-1)+", $"+itoa(len(args))+")")
 	}
-	limit := 100
-	if f.Limit > 0 && f.Limit <= 100 {
+	limit := usecase.MaxListLimit
+	if f.Limit > 0 {
 		limit = f.Limit
 	}
 	offset := 0
 	if f.Offset > 0 {
 		offset = f.Offset
 	}
-	q := `SELECT id, service_name, price, user_id, start_date, end_date, created_at, updated_at
-		FROM subscriptions ` + where + ` ORDER BY created_at DESC LIMIT ` + itoa(limit) + ` OFFSET ` + itoa(offset)
+	q := `SELECT ` + subColumns + `
+		FROM subscriptions ` + whereClause(conds) + ` ORDER BY ` + orderBy(sort) + ` LIMIT ` + itoa(limit) + ` OFFSET ` + itoa(offset)
 	rows, err := r.pool.Query(ctx, q, args...)
 	if err != nil {
-		return nil, err
+		return nil, mapErr(err)
 	}
 	defer rows.Close()
 	var res []*domain.Subscription
 	for rows.Next() {
 		s, err := scanSub(rows)
 		if err != nil {
-			return nil, err
+			return nil, mapErr(err)
 		}
 		res = append(res, s)
 	}
-	return res, rows.Err()
+	return res, mapErr(rows.Err())
 }
 
-func (r *SubscriptionRepo) Summary(ctx context.Context, from, to domain.YearMonth, userID *uuid.UUID, serviceName *string) (int64, error) {
-	// Суммируем price * количество месяцев пересечения в границах from..to (включительно).
-	var filters []string
+func (r *SubscriptionRepo) Count(ctx context.Context, f usecase.ListFilter) (int, error) {
+	conds, args := listConds(usecase.TenantFrom(ctx), f)
+	var n int
+	err := r.pool.QueryRow(ctx, `SELECT count(*) FROM subscriptions `+whereClause(conds), args...).Scan(&n)
+	return n, mapErr(err)
+}
+
+// listConds строит условия по арендатору и фильтру списка; страница (Limit, Offset, Cursor)
+// не учитывается. Значения из фильтра передаются только параметрами запроса.
+func listConds(tenant string, f usecase.ListFilter) ([]string, []any) {
+	var conds []string
 	var args []any
-	idx := 1
-	if userID != nil {
-		filters = append(filters, "user_id = $"+itoa(idx))
-		args = append(args, *userID)
-		idx++
-	}
-	if serviceName != nil {
-		filters = append(filters, "service_name ILIKE $"+itoa(idx))
-		args = append(args, "%"+*serviceName+"%")
-		idx++
-	}
-	where := ""
-	if len(filters) > 0 {
-		where = " AND " + strings.Join(filters, " AND ")
-	}
-
-	q := `
-	WITH bounds AS (
-		SELECT $` + itoa(idx) + `::date AS from_date, $` + itoa(idx+1) + `::date AS to_date
-	),
-	active AS (
-		SELECT s.*, 
-			GREATEST(date_trunc('month', s.start_date), date_trunc('month', (SELECT from_date FROM bounds))) AS eff_start,
-			LEAST(
-				date_trunc('month', COALESCE(s.end_date, (SELECT to_date FROM bounds))),
-				date_trunc('month', (SELECT to_date FROM bounds))
-			) AS eff_end
-		FROM subscriptions s, bounds
-		WHERE (s.end_date IS NULL OR s.end_date >= (SELECT from_date FROM bounds))
-		  AND s.start_date <= (SELECT to_date FROM bounds)
-		` + where + `
-	),
-	months AS (
-		SELECT price, 1 + (date_part('year', eff_end) - date_part('year', eff_start)) * 12
-			 + (date_part('month', eff_end) - date_part('month', eff_start)) AS months_overlap
-		FROM active
-		WHERE eff_end >= eff_start
-	)
-	SELECT COALESCE(SUM(price * months_overlap), 0) AS total FROM months;
-	`
-	args = append(args, from.Time(), to.Time())
-	var total int64
-	if err := r.pool.QueryRow(ctx, q, args...).Scan(&total); err != nil {
-		return 0, err
-	}
-	return total, nil
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, strings.ReplaceAll(cond, "?", "$"+itoa(len(args))))
+	}
+	add("tenant_id = ?", tenant)
+	if !f.IncludeDeleted {
+		conds = append(conds, "deleted_at IS NULL")
+	}
+	if len(f.UserIDs) > 0 {
+		add("user_id = ANY(?)", f.UserIDs)
+	}
+	if f.ServiceName != nil {
+		add("service_name ILIKE ?", "%"+*f.ServiceName+"%")
+	}
+	if f.ServiceNameExact != nil {
+		add("service_name = ?", *f.ServiceNameExact)
+	}
+	if f.ServiceID != nil {
+		add("service_id = ?", *f.ServiceID)
+	}
+	if f.PriceMin != nil {
+		add("price >= ?", *f.PriceMin)
+	}
+	if f.PriceMax != nil {
+		add("price <= ?", *f.PriceMax)
+	}
+	if f.StartFrom != nil {
+		add("start_date >= ?", f.StartFrom.Time())
+	}
+	if f.StartTo != nil {
+		add("start_date <= ?", f.StartTo.Time())
+	}
+	if f.EndFrom != nil {
+		add("end_date >= ?", f.EndFrom.Time())
+	}
+	if f.EndTo != nil {
+		add("end_date <= ?", f.EndTo.Time())
+	}
+	if f.ActiveIn != nil {
+		add(activeInMonth("", "?::date"), f.ActiveIn.FirstDay().Time())
+	}
+	if f.OpenEnded {
+		conds = append(conds, "end_date IS NULL")
+	}
+	if f.CreatedFrom != nil {
+		add("created_at >= ?", *f.CreatedFrom)
+	}
+	if f.CreatedTo != nil {
+		add("created_at < ?", *f.CreatedTo)
+	}
+	return conds, args
+}
+
+// sortColumns — столбцы для полей сортировки; в запрос попадают только они.
+var sortColumns = map[usecase.SortField]string{
+	usecase.SortCreatedAt:   "created_at",
+	usecase.SortPrice:       "price",
+	usecase.SortStartDate:   "start_date",
+	usecase.SortServiceName: "service_name",
+}
+
+func orderBy(sort usecase.ListSort) string {
+	dir := " ASC"
+	if sort.Desc {
+		dir = " DESC"
+	}
+	return sortColumns[sort.Field] + dir + ", id" + dir
+}
+
+func whereClause(conds []string) string {
+	if len(conds) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(conds, " AND ")
 }
 
 func scanSub(row pgx.Row) (*domain.Subscription, error) {
 	var s domain.Subscription
 	var start, end *time.Time
-	err := row.Scan(&s.ID, &s.ServiceName, &s.Price, &s.UserID, &start, &end, &s.CreatedAt, &s.UpdatedAt)
+	var dayPrecision bool
+	err := row.Scan(&s.ID, &s.ServiceName, &s.Price, &s.Currency, &s.BillingPeriod, &s.UserID, &start, &end, &dayPrecision, &s.CreatedAt, &s.UpdatedAt, &s.DeletedAt, &s.Version, &s.ServiceID, &s.TenantID)
 	if err != nil {
 		return nil, err
 	}
 	if start != nil {
 		s.Start = domain.YearMonthFromTime(*start)
+		if dayPrecision {
+			d := domain.DateFromTime(*start)
+			s.StartDate = &d
+		}
 	}
 	if end != nil {
 		ym := domain.YearMonthFromTime(*end)
 		s.End = &ym
+		if dayPrecision {
+			d := domain.DateFromTime(*end)
+			s.EndDate = &d
+		}
 	}
 	return &s, nil
 }
 
-func nullableYM(ym *domain.YearMonth) any {
-	if ym == nil {
+// endValue — значение end_date: для подписок с точностью до месяца хранится первое число месяца окончания.
+func endValue(s *domain.Subscription) any {
+	switch {
+	case s.End == nil:
 		return nil
+	case s.EndDate != nil:
+		return s.EndDate.Time()
+	default:
+		return s.End.Time()
 	}
-	return ym.Time()
 }
 
 func itoa(i int) string { return strconv.Itoa(i) }
+
+func errOtherTenant(entity string) error {
+	return domain.Errorf(domain.ErrForbidden, "%s belongs to another tenant", entity)
+}
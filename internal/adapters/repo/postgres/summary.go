@@ -0,0 +1,227 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+// Все расчёты стоимости строятся на CTE charges: по строке на каждую пару (подписка, месяц периода),
+// в которой подписка активна. units — сумма за месяц, умноженная на делитель режима (см. modeUnits).
+// CTE spans считает для пары цену, действующую в месяце (по subscription_prices), и активные
+// дни месяца [lo, hi] — для неполных месяцев подписок с точностью до дня цена уменьшается пропорционально.
+
+// billedUnits — сумма фактических списаний в месяце (domain.Subscription.BilledIn); квартальный и
+// годовой период списывается, только если начался не позже последнего активного дня.
+const billedUnits = `CASE billing_period
+			WHEN 'weekly' THEN price::numeric * ((hi - start_date) / 7 - (lo - start_date + 6) / 7 + 1)
+			WHEN 'quarterly' THEN CASE WHEN ` + monthsSinceStart + ` % 3 = 0 AND ` + periodStarted + ` THEN price ELSE 0 END
+			WHEN 'yearly' THEN CASE WHEN ` + monthsSinceStart + ` % 12 = 0 AND ` + periodStarted + ` THEN price ELSE 0 END
+			ELSE price::numeric * (hi - lo + 1) / month_days
+		END`
+
+const monthsSinceStart = `((date_part('year', month) - date_part('year', start_date)) * 12
+				+ date_part('month', month) - date_part('month', start_date))::int`
+
+const periodStarted = `(start_date + make_interval(months => ` + monthsSinceStart + `))::date <= hi`
+
+// amortizedUnits — месячный эквивалент цены в двенадцатых долях (domain.BillingPeriod.MonthlyTwelfths).
+const amortizedUnits = `price::numeric * CASE billing_period
+			WHEN 'weekly' THEN 52
+			WHEN 'quarterly' THEN 4
+			WHEN 'yearly' THEN 1
+			ELSE 12
+		END * (hi - lo + 1) / month_days`
+
+func modeUnits(m usecase.SummaryMode) (units string, divisor string) {
+	if m == usecase.ModeAmortized {
+		return amortizedUnits, "12"
+	}
+	return billedUnits, "1"
+}
+
+// chargesCTE возвращает CTE months (месяцы from..to) и charges по подпискам арендатора tenant, а также
+// выражение итоговой суммы по units. Промежуточное округление до 6 знаков убирает погрешность деления
+// numeric перед итоговым ROUND.
+func chargesCTE(tenant string, f usecase.SummaryFilter) (cte string, sumExpr func(col string) string, args []any) {
+	filters, args, idx := summaryConds(tenant, f, 1)
+	where := ""
+	if len(filters) > 0 {
+		where = "WHERE " + strings.Join(filters, " AND ")
+	}
+	units, divisor := modeUnits(f.Mode)
+	cte = `
+	WITH months AS (
+		SELECT generate_series($` + itoa(idx) + `::date, $` + itoa(idx+1) + `::date, interval '1 month')::date AS month
+	),
+	spans AS (
+		SELECT s.id, s.service_name, s.user_id, s.currency, s.billing_period, s.start_date, m.month,
+			COALESCE((
+				SELECT p.price FROM subscription_prices p
+				WHERE p.subscription_id = s.id AND p.effective_from <= m.month
+				ORDER BY p.effective_from DESC
+				LIMIT 1
+			), s.price) AS price,
+			GREATEST(m.month, s.start_date) AS lo,
+			LEAST(
+				(m.month + interval '1 month')::date - 1,
+				CASE
+					WHEN s.end_date IS NULL THEN (m.month + interval '1 month')::date - 1
+					WHEN s.day_precision THEN s.end_date
+					ELSE (date_trunc('month', s.end_date) + interval '1 month')::date - 1
+				END
+			) AS hi,
+			(m.month + interval '1 month')::date - m.month AS month_days
+		FROM subscriptions s
+		JOIN months m ON ` + activeInMonth("s.", "m.month") + `
+		` + where + `
+	),
+	charges AS (
+		SELECT id, service_name, user_id, currency, month, ` + units + ` AS units
+		FROM spans
+	)`
+	sumExpr = func(col string) string {
+		return `COALESCE(ROUND(ROUND(SUM(` + col + `), 6) / ` + divisor + `), 0)::bigint`
+	}
+	args = append(args, f.From.Time(), f.To.Time())
+	return cte, sumExpr, args
+}
+
+// activeInMonth — условие «подписка действует в месяце month» (первое число месяца) для таблицы
+// subscriptions с префиксом столбцов prefix. Им же отбираются подписки списка по ListFilter.ActiveIn.
+func activeInMonth(prefix, month string) string {
+	return `date_trunc('month', ` + prefix + `start_date) <= ` + month + `
+			AND (` + prefix + `end_date IS NULL OR date_trunc('month', ` + prefix + `end_date) >= ` + month + `)`
+}
+
+func (r *SubscriptionRepo) Summary(ctx context.Context, f usecase.SummaryFilter) (int64, error) {
+	cte, sum, args := chargesCTE(usecase.TenantFrom(ctx), f)
+	q := cte + `
+	SELECT ` + sum("units") + ` AS total FROM charges`
+	var total int64
+	if err := r.pool.QueryRow(ctx, q, args...).Scan(&total); err != nil {
+		return 0, mapErr(err)
+	}
+	return total, nil
+}
+
+func (r *SubscriptionRepo) SummaryCurrencies(ctx context.Context, f usecase.SummaryFilter) ([]domain.Currency, error) {
+	cte, _, args := chargesCTE(usecase.TenantFrom(ctx), f)
+	q := cte + `
+	SELECT DISTINCT currency FROM charges ORDER BY currency`
+	rows, err := r.pool.Query(ctx, q, args...)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	defer rows.Close()
+	var res []domain.Currency
+	for rows.Next() {
+		var c domain.Currency
+		if err := rows.Scan(&c); err != nil {
+			return nil, mapErr(err)
+		}
+		res = append(res, c)
+	}
+	return res, mapErr(rows.Err())
+}
+
+func (r *SubscriptionRepo) MonthlySummary(ctx context.Context, f usecase.SummaryFilter) ([]usecase.MonthlyCost, error) {
+	cte, sum, args := chargesCTE(usecase.TenantFrom(ctx), f)
+	q := cte + `
+	SELECT m.month, ` + sum("c.units") + ` AS total, COUNT(c.id) AS cnt
+	FROM months m
+	LEFT JOIN charges c ON c.month = m.month
+	GROUP BY m.month
+	ORDER BY m.month`
+	rows, err := r.pool.Query(ctx, q, args...)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	defer rows.Close()
+	var res []usecase.MonthlyCost
+	for rows.Next() {
+		var month time.Time
+		var mc usecase.MonthlyCost
+		if err := rows.Scan(&month, &mc.Total, &mc.Subscriptions); err != nil {
+			return nil, mapErr(err)
+		}
+		mc.Month = domain.YearMonthFromTime(month)
+		res = append(res, mc)
+	}
+	return res, mapErr(rows.Err())
+}
+
+// groupKeys — допустимые измерения группировки и соответствующие выражения SQL.
+var groupKeys = map[usecase.SummaryGroupBy]string{
+	usecase.GroupByServiceName: "service_name",
+	usecase.GroupByUserID:      "user_id::text",
+}
+
+func (r *SubscriptionRepo) GroupedSummary(ctx context.Context, f usecase.SummaryFilter, groupBy usecase.SummaryGroupBy, limit int) ([]usecase.GroupCost, error) {
+	key, ok := groupKeys[groupBy]
+	if !ok {
+		return nil, domain.Errorf(domain.ErrValidation, "unsupported group_by %q", groupBy)
+	}
+	cte, sum, args := chargesCTE(usecase.TenantFrom(ctx), f)
+	q := cte + `
+	SELECT ` + key + ` AS key, ` + sum("units") + ` AS total, COUNT(*) AS months, COUNT(DISTINCT id) AS cnt
+	FROM charges
+	GROUP BY 1
+	ORDER BY total DESC, key`
+	if limit > 0 {
+		q += ` LIMIT ` + itoa(limit)
+	}
+	rows, err := r.pool.Query(ctx, q, args...)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	defer rows.Close()
+	var res []usecase.GroupCost
+	for rows.Next() {
+		var g usecase.GroupCost
+		if err := rows.Scan(&g.Key, &g.Total, &g.Months, &g.Subscriptions); err != nil {
+			return nil, mapErr(err)
+		}
+		res = append(res, g)
+	}
+	return res, mapErr(rows.Err())
+}
+
+// summaryConds строит условия по арендатору и фильтрам для таблицы с алиасом s, нумеруя параметры с idx.
+func summaryConds(tenant string, f usecase.SummaryFilter, idx int) ([]string, []any, int) {
+	filters := []string{"s.tenant_id = $" + itoa(idx)}
+	args := []any{tenant}
+	idx++
+	if !f.IncludeDeleted {
+		filters = append(filters, "s.deleted_at IS NULL")
+	}
+	if f.UserID != nil {
+		filters = append(filters, "s.user_id = $"+itoa(idx))
+		args = append(args, *f.UserID)
+		idx++
+	}
+	if f.UserIDs != nil {
+		filters = append(filters, "s.user_id = ANY($"+itoa(idx)+")")
+		args = append(args, f.UserIDs)
+		idx++
+	}
+	if f.ServiceName != nil {
+		filters = append(filters, "s.service_name ILIKE $"+itoa(idx))
+		args = append(args, "%"+*f.ServiceName+"%")
+		idx++
+	}
+	if f.ServiceID != nil {
+		filters = append(filters, "s.service_id = $"+itoa(idx))
+		args = append(args, *f.ServiceID)
+		idx++
+	}
+	if f.Currency != nil {
+		filters = append(filters, "s.currency = $"+itoa(idx))
+		args = append(args, *f.Currency)
+		idx++
+	}
+	return filters, args, idx
+}
@@ -0,0 +1,198 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+// OrgRepo хранит организации; запросы ограничены арендатором вызова, как у SubscriptionRepo.
+type OrgRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewOrgRepo(pool *pgxpool.Pool) *OrgRepo {
+	return &OrgRepo{pool: pool}
+}
+
+const orgColumns = `id, name, created_at, updated_at, tenant_id`
+
+func (r *OrgRepo) Create(ctx context.Context, o *domain.Organization) error {
+	if o.TenantID != usecase.TenantFrom(ctx) {
+		return errOtherTenant("organization")
+	}
+	_, err := r.pool.Exec(ctx, `INSERT INTO organizations (`+orgColumns+`) VALUES ($1,$2,$3,$4,$5)`,
+		o.ID, o.Name, o.CreatedAt, o.UpdatedAt, o.TenantID)
+	return mapErr(err)
+}
+
+func (r *OrgRepo) Get(ctx context.Context, id uuid.UUID) (*domain.Organization, error) {
+	o, err := scanOrg(r.pool.QueryRow(ctx, `SELECT `+orgColumns+` FROM organizations WHERE id=$1 AND tenant_id=$2`,
+		id, usecase.TenantFrom(ctx)))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errOrgNotFound()
+	}
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return o, nil
+}
+
+func (r *OrgRepo) Update(ctx context.Context, o *domain.Organization) error {
+	tag, err := r.pool.Exec(ctx, `UPDATE organizations SET name=$2, updated_at=$3 WHERE id=$1 AND tenant_id=$4`,
+		o.ID, o.Name, o.UpdatedAt, usecase.TenantFrom(ctx))
+	if err != nil {
+		return mapErr(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errOrgNotFound()
+	}
+	return nil
+}
+
+func (r *OrgRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM organizations WHERE id=$1 AND tenant_id=$2`, id, usecase.TenantFrom(ctx))
+	if err != nil {
+		return mapDeleteErr(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errOrgNotFound()
+	}
+	return nil
+}
+
+func (r *OrgRepo) List(ctx context.Context) ([]*domain.Organization, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+orgColumns+` FROM organizations WHERE tenant_id=$1 ORDER BY name, id`,
+		usecase.TenantFrom(ctx))
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	res, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.Organization, error) { return scanOrg(row) })
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return res, nil
+}
+
+func scanOrg(row pgx.Row) (*domain.Organization, error) {
+	var o domain.Organization
+	if err := row.Scan(&o.ID, &o.Name, &o.CreatedAt, &o.UpdatedAt, &o.TenantID); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// TeamRepo хранит команды арендатора вызова; название уникально в организации по индексу
+// (org_id, lower(name)).
+type TeamRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewTeamRepo(pool *pgxpool.Pool) *TeamRepo {
+	return &TeamRepo{pool: pool}
+}
+
+const teamColumns = `id, org_id, parent_id, name, created_at, updated_at, tenant_id`
+
+func (r *TeamRepo) Create(ctx context.Context, t *domain.Team) error {
+	if t.TenantID != usecase.TenantFrom(ctx) {
+		return errOtherTenant("team")
+	}
+	_, err := r.pool.Exec(ctx, `INSERT INTO teams (`+teamColumns+`) VALUES ($1,$2,$3,$4,$5,$6,$7)`,
+		t.ID, t.OrgID, t.ParentID, t.Name, t.CreatedAt, t.UpdatedAt, t.TenantID)
+	return mapErr(err)
+}
+
+func (r *TeamRepo) Get(ctx context.Context, id uuid.UUID) (*domain.Team, error) {
+	t, err := scanTeam(r.pool.QueryRow(ctx, `SELECT `+teamColumns+` FROM teams WHERE id=$1 AND tenant_id=$2`,
+		id, usecase.TenantFrom(ctx)))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errTeamNotFound()
+	}
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return t, nil
+}
+
+// Update блокирует организацию, поэтому переносы команд одной организации выполняются по очереди
+// и проверка родителя видит то дерево, в которое попадёт изменение.
+func (r *TeamRepo) Update(ctx context.Context, t *domain.Team) error {
+	tenant := usecase.TenantFrom(ctx)
+	return mapNotFound(pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `SELECT 1 FROM organizations WHERE id=$1 AND tenant_id=$2 FOR UPDATE`, t.OrgID, tenant); err != nil {
+			return err
+		}
+		rows, err := tx.Query(ctx, `SELECT `+teamColumns+` FROM teams WHERE org_id=$1 AND tenant_id=$2`, t.OrgID, tenant)
+		if err != nil {
+			return err
+		}
+		teams, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.Team, error) { return scanTeam(row) })
+		if err != nil {
+			return err
+		}
+		if err := t.CheckParent(teams); err != nil {
+			return err
+		}
+		tag, err := tx.Exec(ctx, `UPDATE teams SET parent_id=$2, name=$3, updated_at=$4 WHERE id=$1 AND org_id=$5 AND tenant_id=$6`,
+			t.ID, t.ParentID, t.Name, t.UpdatedAt, t.OrgID, tenant)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return errTeamNotFound()
+		}
+		return nil
+	}), "team")
+}
+
+func (r *TeamRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM teams WHERE id=$1 AND tenant_id=$2`, id, usecase.TenantFrom(ctx))
+	if err != nil {
+		return mapDeleteErr(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errTeamNotFound()
+	}
+	return nil
+}
+
+func (r *TeamRepo) List(ctx context.Context, orgID uuid.UUID) ([]*domain.Team, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+teamColumns+` FROM teams WHERE org_id=$1 AND tenant_id=$2 ORDER BY name, id`,
+		orgID, usecase.TenantFrom(ctx))
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	res, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.Team, error) { return scanTeam(row) })
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return res, nil
+}
+
+func scanTeam(row pgx.Row) (*domain.Team, error) {
+	var t domain.Team
+	if err := row.Scan(&t.ID, &t.OrgID, &t.ParentID, &t.Name, &t.CreatedAt, &t.UpdatedAt, &t.TenantID); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// mapDeleteErr — mapErr для удаления: на запись успели сослаться после проверки в usecase.
+func mapDeleteErr(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return &domain.Error{Kind: domain.ErrConflict, Err: err}
+	}
+	return mapErr(err)
+}
+
+func errOrgNotFound() error  { return domain.Errorf(domain.ErrNotFound, "organization not found") }
+func errTeamNotFound() error { return domain.Errorf(domain.ErrNotFound, "team not found") }
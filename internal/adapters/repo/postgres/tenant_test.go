@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+// Запросы без базы не выполнить, поэтому проверяется, что каждый построенный запрос
+// ограничен арендатором вызова.
+func TestQueriesAreScopedByTenant(t *testing.T) {
+	user := uuid.New()
+	list := usecase.ListFilter{UserIDs: []uuid.UUID{user}, IncludeDeleted: true}
+	conds, args := listConds("acme", list)
+	if conds[0] != "tenant_id = $1" || args[0] != "acme" {
+		t.Fatalf("list conditions = %v, args %v", conds, args)
+	}
+
+	summary := usecase.SummaryFilter{From: domain.MustYearMonth("01-2025"), To: domain.MustYearMonth("02-2025"), UserID: &user, IncludeDeleted: true}
+	cte, _, args := chargesCTE("acme", summary)
+	if !strings.Contains(cte, "WHERE s.tenant_id = $1") || args[0] != "acme" {
+		t.Fatalf("charges CTE is not scoped by tenant: %s %v", cte, args)
+	}
+
+	ctx := usecase.WithTenant(context.Background(), "acme")
+	sub := &domain.Subscription{ID: uuid.New(), TenantID: "acme", Start: domain.MustYearMonth("01-2025"), Version: 1}
+	for _, ch := range []usecase.BatchChange{
+		{Kind: usecase.BatchUpdate, Before: sub, After: sub},
+		{Kind: usecase.BatchDelete, Before: sub},
+	} {
+		st, err := batchStatement(ctx, ch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(st.sql, "tenant_id=$") {
+			t.Fatalf("%s statement is not scoped by tenant: %s", ch.Kind, st.sql)
+		}
+	}
+	other := *sub
+	other.TenantID = "globex"
+	if _, err := batchStatement(ctx, usecase.BatchChange{Kind: usecase.BatchCreate, After: &other}); !errors.Is(err, domain.ErrForbidden) {
+		t.Fatalf("create for another tenant: %v, want forbidden", err)
+	}
+}
@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+// UserRepo хранит пользователей; запросы ограничены арендатором вызова, как у SubscriptionRepo.
+// Удаление мягкое: на пользователя остаются ссылки из подписок (subscriptions.user_id), в том числе
+// из их истории.
+type UserRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewUserRepo(pool *pgxpool.Pool) *UserRepo {
+	return &UserRepo{pool: pool}
+}
+
+const userColumns = `id, name, email, team_id, timezone, created_at, updated_at, deleted_at, tenant_id`
+
+func (r *UserRepo) Create(ctx context.Context, u *domain.User) error {
+	if u.TenantID != usecase.TenantFrom(ctx) {
+		return errOtherTenant("user")
+	}
+	_, err := r.pool.Exec(ctx, `INSERT INTO users (`+userColumns+`) VALUES ($1,$2,$3,$4,$5,$6,$7,NULL,$8)`,
+		u.ID, u.Name, nullString(u.Email), u.TeamID, u.Timezone, u.CreatedAt, u.UpdatedAt, u.TenantID)
+	return mapErr(err)
+}
+
+func (r *UserRepo) Get(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	u, err := scanUser(r.pool.QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE id=$1 AND tenant_id=$2 AND deleted_at IS NULL`,
+		id, usecase.TenantFrom(ctx)))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, errUserNotFound()
+	}
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return u, nil
+}
+
+func (r *UserRepo) Update(ctx context.Context, u *domain.User) error {
+	tag, err := r.pool.Exec(ctx, `UPDATE users SET name=$2, email=$3, team_id=$4, timezone=$5, updated_at=$6
+		WHERE id=$1 AND tenant_id=$7 AND deleted_at IS NULL`,
+		u.ID, u.Name, nullString(u.Email), u.TeamID, u.Timezone, u.UpdatedAt, usecase.TenantFrom(ctx))
+	if err != nil {
+		return mapErr(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return errUserNotFound()
+	}
+	return nil
+}
+
+// Delete блокирует строку пользователя до конца транзакции: Create и пакетное создание подписок
+// берут на неё разделяемую блокировку и после удаления пользователя не находят.
+func (r *UserRepo) Delete(ctx context.Context, id uuid.UUID, release usecase.UserRelease) error {
+	tenant := usecase.TenantFrom(ctx)
+	return mapNotFound(pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		var found bool
+		err := tx.QueryRow(ctx, `SELECT true FROM users WHERE id=$1 AND tenant_id=$2 AND deleted_at IS NULL FOR UPDATE`,
+			id, tenant).Scan(&found)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errUserNotFound()
+		}
+		if err != nil {
+			return err
+		}
+		rows, err := tx.Query(ctx, `SELECT `+subColumns+` FROM subscriptions
+			WHERE user_id=$1 AND tenant_id=$2 AND deleted_at IS NULL ORDER BY id FOR UPDATE`, id, tenant)
+		if err != nil {
+			return err
+		}
+		subs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.Subscription, error) { return scanSub(row) })
+		if err != nil {
+			return err
+		}
+		changes, err := release(subs)
+		if err != nil {
+			return err
+		}
+		if err := applyChanges(ctx, tx, changes); err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `UPDATE users SET deleted_at=NOW() WHERE id=$1 AND tenant_id=$2`, id, tenant)
+		return err
+	}), "user")
+}
+
+func (r *UserRepo) List(ctx context.Context, f usecase.UserFilter) ([]*domain.User, error) {
+	conds := []string{"tenant_id = $1", "deleted_at IS NULL"}
+	args := []any{usecase.TenantFrom(ctx)}
+	if f.TeamIDs != nil {
+		args = append(args, f.TeamIDs)
+		conds = append(conds, "team_id = ANY($"+itoa(len(args))+")")
+	}
+	q := `SELECT ` + userColumns + ` FROM users ` + whereClause(conds) + ` ORDER BY name, id`
+	if f.Limit > 0 {
+		q += ` LIMIT ` + itoa(f.Limit)
+	}
+	if f.Offset > 0 {
+		q += ` OFFSET ` + itoa(f.Offset)
+	}
+	rows, err := r.pool.Query(ctx, q, args...)
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	res, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*domain.User, error) { return scanUser(row) })
+	if err != nil {
+		return nil, mapErr(err)
+	}
+	return res, nil
+}
+
+func scanUser(row pgx.Row) (*domain.User, error) {
+	var u domain.User
+	var email *string
+	if err := row.Scan(&u.ID, &u.Name, &email, &u.TeamID, &u.Timezone, &u.CreatedAt, &u.UpdatedAt, &u.DeletedAt, &u.TenantID); err != nil {
+		return nil, err
+	}
+	if email != nil {
+		u.Email = *email
+	}
+	return &u, nil
+}
+
+// nullString хранит пустую строку как NULL, чтобы она не участвовала в уникальном индексе.
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func errUserNotFound() error { return domain.Errorf(domain.ErrNotFound, "user not found") }
+
+func errUserMissing(id uuid.UUID) error {
+	return domain.Errorf(domain.ErrValidation, "user %s does not exist", id)
+}
@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditOperation — вид изменения подписки в журнале аудита.
+type AuditOperation string
+
+const (
+	AuditCreate AuditOperation = "create"
+	AuditUpdate AuditOperation = "update"
+	AuditDelete AuditOperation = "delete"
+	// AuditRestore — отмена удаления.
+	AuditRestore AuditOperation = "restore"
+	// AuditPurge — окончательное удаление после срока хранения.
+	AuditPurge AuditOperation = "purge"
+	// AuditPriceChange — изменение графика цен: добавление, замена или удаление изменения цены.
+	AuditPriceChange AuditOperation = "price_change"
+)
+
+// AuditRecord — неизменяемая запись журнала: кто, в рамках какого запроса и как изменил подписку.
+// Before пуст для create, After — для delete и purge.
+type AuditRecord struct {
+	ID             int64
+	SubscriptionID uuid.UUID
+	TenantID       string // арендатор подписки; журнал виден только ему
+	Operation      AuditOperation
+	Actor          string
+	RequestID      string
+	Before         json.RawMessage
+	After          json.RawMessage
+	CreatedAt      time.Time
+}
+
+// subscriptionSnapshot — состояние подписки в журнале; даты в том же формате, что и в API.
+// График цен попадает в снимок, только если он загружен в подписку (в записях price_change).
+type subscriptionSnapshot struct {
+	ID            uuid.UUID  `json:"id"`
+	ServiceID     uuid.UUID  `json:"service_id"`
+	ServiceName   string     `json:"service_name"`
+	Price         int        `json:"price"`
+	Currency      string     `json:"currency"`
+	BillingPeriod string     `json:"billing_period"`
+	UserID        uuid.UUID  `json:"user_id"`
+	StartDate     string     `json:"start_date"`
+	EndDate       *string    `json:"end_date,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"`
+	Version       int64      `json:"version"`
+
+	PriceChanges []priceChangeSnapshot `json:"price_changes,omitempty"`
+}
+
+type priceChangeSnapshot struct {
+	EffectiveFrom string `json:"effective_from"`
+	Price         int    `json:"price"`
+}
+
+// Snapshot сериализует подписку для журнала аудита; для nil возвращает nil.
+func Snapshot(s *Subscription) json.RawMessage {
+	if s == nil {
+		return nil
+	}
+	snap := subscriptionSnapshot{
+		ID:            s.ID,
+		ServiceID:     s.ServiceID,
+		ServiceName:   s.ServiceName,
+		Price:         s.Price,
+		Currency:      s.Currency.String(),
+		BillingPeriod: s.BillingPeriod.String(),
+		UserID:        s.UserID,
+		StartDate:     s.Start.String(),
+		CreatedAt:     s.CreatedAt.UTC(),
+		UpdatedAt:     s.UpdatedAt.UTC(),
+		DeletedAt:     s.DeletedAt,
+		Version:       s.Version,
+	}
+	if s.StartDate != nil {
+		snap.StartDate = s.StartDate.String()
+	}
+	for _, pc := range s.PriceChanges {
+		snap.PriceChanges = append(snap.PriceChanges, priceChangeSnapshot{EffectiveFrom: pc.EffectiveFrom.String(), Price: pc.Price})
+	}
+	if s.EndDate != nil {
+		v := s.EndDate.String()
+		snap.EndDate = &v
+	} else if s.End != nil {
+		v := s.End.String()
+		snap.EndDate = &v
+	}
+	b, _ := json.Marshal(snap) // структура из простых полей, ошибки маршалинга быть не может
+	return b
+}
+
+// NewAuditRecord собирает запись об изменении подписки before -> after.
+func NewAuditRecord(op AuditOperation, actor, requestID string, before, after *Subscription) AuditRecord {
+	rec := AuditRecord{
+		Operation: op,
+		Actor:     actor,
+		RequestID: requestID,
+		Before:    Snapshot(before),
+		After:     Snapshot(after),
+		CreatedAt: time.Now().UTC(),
+	}
+	if after != nil {
+		rec.SubscriptionID, rec.TenantID = after.ID, after.TenantID
+	} else if before != nil {
+		rec.SubscriptionID, rec.TenantID = before.ID, before.TenantID
+	}
+	return rec
+}
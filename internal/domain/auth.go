@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Роли вызывающего.
+const (
+	RoleAdmin   = "admin"
+	RoleFinance = "finance"
+)
+
+// DefaultTenant — арендатор вызывающих, у которых он не задан, и подписок, созданных до разделения
+// данных по арендаторам.
+const DefaultTenant = "default"
+
+var tenantIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// ValidateTenantID проверяет идентификатор арендатора: 1–64 латинские буквы, цифры, '.', '_' или '-'.
+func ValidateTenantID(id string) error {
+	if !tenantIDPattern.MatchString(id) {
+		return Errorf(ErrValidation, "invalid tenant_id %q", id)
+	}
+	return nil
+}
+
+// Principal — аутентифицированный вызывающий: владелец API-ключа или субъект JWT.
+type Principal struct {
+	Subject  string     // попадает в журнал аудита как исполнитель
+	UserID   *uuid.UUID // пользователь, от имени которого действует вызывающий
+	TenantID string     // арендатор, данные которого видит вызывающий; пустой — DefaultTenant
+	Roles    []string
+}
+
+func (p Principal) HasRole(role string) bool { return slices.Contains(p.Roles, role) }
+
+// Tenant возвращает арендатора вызывающего.
+func (p Principal) Tenant() string {
+	if p.TenantID == "" {
+		return DefaultTenant
+	}
+	return p.TenantID
+}
+
+// APIKey — статический ключ доступа. Сам ключ не хранится: по нему ищется SHA-256 хеш (Hash),
+// а Prefix — его начало, чтобы ключ можно было узнать в списке.
+type APIKey struct {
+	ID        uuid.UUID
+	Name      string
+	Prefix    string
+	Hash      []byte
+	UserID    *uuid.UUID
+	TenantID  string
+	Roles     []string
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+func (k *APIKey) Revoked() bool { return k.RevokedAt != nil }
+
+// Principal — вызывающий, который предъявил этот ключ.
+func (k *APIKey) Principal() Principal {
+	return Principal{Subject: "api-key:" + k.ID.String(), UserID: k.UserID, TenantID: k.TenantID, Roles: k.Roles}
+}
+
+// Normalize убирает лишние пробелы в названии и повторы ролей; ключ без арендатора получает DefaultTenant.
+func (k *APIKey) Normalize() {
+	k.Name = strings.Join(strings.Fields(k.Name), " ")
+	if k.TenantID == "" {
+		k.TenantID = DefaultTenant
+	}
+	slices.Sort(k.Roles)
+	k.Roles = slices.Compact(k.Roles)
+}
+
+func (k *APIKey) Validate() error {
+	if k.Name == "" {
+		return Errorf(ErrValidation, "name is required")
+	}
+	if err := ValidateTenantID(k.TenantID); err != nil {
+		return err
+	}
+	for _, r := range k.Roles {
+		if r != RoleAdmin && r != RoleFinance {
+			return Errorf(ErrValidation, "unknown role %q", r)
+		}
+	}
+	return nil
+}
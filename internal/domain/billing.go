@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"math/big"
+	"strings"
+)
+
+// BillingPeriod — период, за который списывается Price.
+type BillingPeriod string
+
+const (
+	BillingWeekly    BillingPeriod = "weekly"
+	BillingMonthly   BillingPeriod = "monthly"
+	BillingQuarterly BillingPeriod = "quarterly"
+	BillingYearly    BillingPeriod = "yearly"
+
+	DefaultBillingPeriod = BillingMonthly
+)
+
+var ErrUnknownBillingPeriod = Errorf(ErrValidation, "billing_period must be one of: weekly, monthly, quarterly, yearly")
+
+func ParseBillingPeriod(s string) (BillingPeriod, error) {
+	p := BillingPeriod(strings.ToLower(strings.TrimSpace(s)))
+	if !p.Valid() {
+		return "", ErrUnknownBillingPeriod
+	}
+	return p, nil
+}
+
+func (p BillingPeriod) Valid() bool {
+	switch p {
+	case BillingWeekly, BillingMonthly, BillingQuarterly, BillingYearly:
+		return true
+	}
+	return false
+}
+
+func (p BillingPeriod) String() string { return string(p) }
+
+// MonthlyTwelfths — месячный эквивалент цены в двенадцатых долях: Price*MonthlyTwelfths()/12.
+// Неделя считается как 52/12 месяца.
+func (p BillingPeriod) MonthlyTwelfths() int64 {
+	switch p {
+	case BillingWeekly:
+		return 52
+	case BillingQuarterly:
+		return 4
+	case BillingYearly:
+		return 1
+	default:
+		return 12
+	}
+}
+
+// ChargesIn возвращает число списаний в месяце m. Списания идут от начала подписки с шагом
+// BillingPeriod и прекращаются после её окончания: период, начавшийся позже последнего
+// дня подписки, не списывается.
+func (s *Subscription) ChargesIn(m YearMonth) int {
+	lo, hi, ok := s.activeSpan(m)
+	if !ok {
+		return 0
+	}
+	months := s.Start.MonthsUntil(m) - 1
+	switch s.BillingPeriod {
+	case BillingWeekly:
+		first := s.FirstDay()
+		a := first.DaysUntil(lo)
+		b := first.DaysUntil(hi)
+		return b/7 - (a+6)/7 + 1
+	case BillingQuarterly:
+		if months%3 == 0 && !hi.Before(s.periodStartIn(m)) {
+			return 1
+		}
+		return 0
+	case BillingYearly:
+		if months%12 == 0 && !hi.Before(s.periodStartIn(m)) {
+			return 1
+		}
+		return 0
+	default:
+		return 1
+	}
+}
+
+// periodStartIn — день месяца m, соответствующий первому дню подписки; для коротких месяцев
+// сдвигается на последний день, как date + interval в Postgres.
+func (s *Subscription) periodStartIn(m YearMonth) Date {
+	day, last := s.FirstDay().Time().Day(), m.LastDay()
+	if day > last.Time().Day() {
+		return last
+	}
+	return m.FirstDay().AddDays(day - 1)
+}
+
+// BilledIn — сумма списаний в месяце m по цене, действующей в этом месяце. Месячная цена
+// пропорционально уменьшается для неполных месяцев подписок с точностью до дня.
+func (s *Subscription) BilledIn(m YearMonth) *big.Rat {
+	price := int64(s.PriceAt(m))
+	if s.BillingPeriod == BillingMonthly || s.BillingPeriod == "" {
+		return s.prorate(m, price, 1)
+	}
+	return new(big.Rat).SetInt64(price * int64(s.ChargesIn(m)))
+}
+
+// AmortizedIn — месячный эквивалент цены для месяца m с учётом неполного месяца.
+func (s *Subscription) AmortizedIn(m YearMonth) *big.Rat {
+	return s.prorate(m, int64(s.PriceAt(m))*s.BillingPeriod.MonthlyTwelfths(), 12)
+}
+
+// prorate возвращает amount/div, умноженное на долю активных дней месяца m.
+func (s *Subscription) prorate(m YearMonth, amount, div int64) *big.Rat {
+	days := int64(m.FirstDay().DaysUntil(m.LastDay()) + 1)
+	return big.NewRat(amount*int64(s.ActiveDaysIn(m)), div*days)
+}
+
+// RoundRat округляет до целого, половину — от нуля (как ROUND для numeric в Postgres).
+func RoundRat(r *big.Rat) int64 {
+	num := new(big.Int).Abs(r.Num())
+	q, m := new(big.Int).QuoRem(num, r.Denom(), new(big.Int))
+	if m.Lsh(m, 1).Cmp(r.Denom()) >= 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	if r.Sign() < 0 {
+		q.Neg(q)
+	}
+	return q.Int64()
+}
@@ -0,0 +1,120 @@
+package domain
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestChargesIn(t *testing.T) {
+	end := MustYearMonth("06-2026")
+	cases := []struct {
+		period BillingPeriod
+		month  string
+		want   int
+	}{
+		{BillingMonthly, "03-2025", 1},
+		{BillingMonthly, "12-2024", 0},
+		{BillingQuarterly, "01-2025", 1},
+		{BillingQuarterly, "02-2025", 0},
+		{BillingQuarterly, "04-2025", 1},
+		{BillingYearly, "01-2025", 1},
+		{BillingYearly, "12-2025", 0},
+		{BillingYearly, "01-2026", 1},
+		{BillingYearly, "07-2026", 0}, // после окончания
+		{BillingWeekly, "01-2025", 5}, // 1, 8, 15, 22, 29
+		{BillingWeekly, "02-2025", 4}, // 5, 12, 19, 26
+		{BillingWeekly, "03-2025", 4}, // 5, 12, 19, 26
+	}
+	for _, c := range cases {
+		s := Subscription{Price: 100, BillingPeriod: c.period, Start: MustYearMonth("01-2025"), End: &end}
+		if got := s.ChargesIn(MustYearMonth(c.month)); got != c.want {
+			t.Errorf("%s in %s: got %d, want %d", c.period, c.month, got, c.want)
+		}
+	}
+}
+
+func TestChargesInDayPrecisionEnd(t *testing.T) {
+	start := MustDate("15-01-2024")
+	cases := []struct {
+		period BillingPeriod
+		end    string
+		month  string
+		want   int
+	}{
+		{BillingYearly, "17-01-2025", "01-2025", 1}, // два дня нового периода
+		{BillingYearly, "15-01-2025", "01-2025", 1},
+		{BillingYearly, "14-01-2025", "01-2025", 0}, // период не начался
+		{BillingQuarterly, "02-04-2024", "04-2024", 0},
+		{BillingQuarterly, "16-04-2024", "04-2024", 1},
+	}
+	for _, c := range cases {
+		end := MustDate(c.end)
+		endYM := end.YearMonth()
+		s := Subscription{
+			Price: 100, BillingPeriod: c.period,
+			Start: start.YearMonth(), StartDate: &start,
+			End: &endYM, EndDate: &end,
+		}
+		if got := s.ChargesIn(MustYearMonth(c.month)); got != c.want {
+			t.Errorf("%s ending %s in %s: got %d, want %d", c.period, c.end, c.month, got, c.want)
+		}
+		if got := s.BilledIn(MustYearMonth(c.month)); got.Cmp(big.NewRat(int64(100*c.want), 1)) != 0 {
+			t.Errorf("%s ending %s billed in %s: got %s", c.period, c.end, c.month, got)
+		}
+	}
+
+	// 31-е число в коротком месяце сдвигается на его последний день
+	start = MustDate("31-01-2024")
+	end := MustDate("28-02-2025")
+	endYM := end.YearMonth()
+	s := Subscription{
+		Price: 100, BillingPeriod: BillingYearly,
+		Start: start.YearMonth(), StartDate: &start, End: &endYM, EndDate: &end,
+	}
+	if got := s.ChargesIn(MustYearMonth("01-2025")); got != 1 {
+		t.Fatalf("yearly from 31-01 in 01-2025: got %d, want 1", got)
+	}
+}
+
+func TestAmortizedIn(t *testing.T) {
+	s := Subscription{Price: 1200, BillingPeriod: BillingYearly, Start: MustYearMonth("01-2025")}
+	if got := s.AmortizedIn(MustYearMonth("05-2025")); got.Cmp(big.NewRat(100, 1)) != 0 {
+		t.Fatalf("yearly amortized = %s, want 100", got)
+	}
+	s.BillingPeriod = BillingWeekly
+	if got := s.AmortizedIn(MustYearMonth("05-2025")); got.Cmp(big.NewRat(1200*52, 12)) != 0 {
+		t.Fatalf("weekly amortized = %s", got)
+	}
+}
+
+func TestBilledInProrated(t *testing.T) {
+	start, end := MustDate("15-01-2025"), MustDate("03-03-2025")
+	s := Subscription{
+		Price:         310,
+		BillingPeriod: BillingMonthly,
+		Start:         start.YearMonth(),
+		End:           func() *YearMonth { ym := end.YearMonth(); return &ym }(),
+		StartDate:     &start,
+		EndDate:       &end,
+	}
+	cases := []struct {
+		month string
+		want  *big.Rat
+	}{
+		{"01-2025", big.NewRat(310*17, 31)},
+		{"02-2025", big.NewRat(310, 1)},
+		{"03-2025", big.NewRat(30, 1)},
+		{"04-2025", big.NewRat(0, 1)},
+	}
+	for _, c := range cases {
+		if got := s.BilledIn(MustYearMonth(c.month)); got.Cmp(c.want) != 0 {
+			t.Errorf("%s: got %s, want %s", c.month, got, c.want)
+		}
+	}
+
+	// месячная точность — полные месяцы без пропорции
+	s.StartDate, s.EndDate = nil, nil
+	if got := s.BilledIn(MustYearMonth("03-2025")); got.Cmp(big.NewRat(310, 1)) != 0 {
+		t.Fatalf("month precision: got %s, want 310", got)
+	}
+}
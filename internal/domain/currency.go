@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"math/big"
+	"strings"
+)
+
+// Currency — код валюты ISO 4217.
+type Currency string
+
+const (
+	RUB Currency = "RUB"
+	USD Currency = "USD"
+	EUR Currency = "EUR"
+	GBP Currency = "GBP"
+	CNY Currency = "CNY"
+	KZT Currency = "KZT"
+
+	DefaultCurrency = RUB
+)
+
+var knownCurrencies = map[Currency]struct{}{
+	RUB: {}, USD: {}, EUR: {}, GBP: {}, CNY: {}, KZT: {},
+}
+
+var ErrUnknownCurrency = Errorf(ErrValidation, "unknown currency; use one of RUB, USD, EUR, GBP, CNY, KZT")
+
+// ParseCurrency принимает код в любом регистре и проверяет его по списку известных валют.
+func ParseCurrency(code string) (Currency, error) {
+	c := Currency(strings.ToUpper(strings.TrimSpace(code)))
+	if !c.Valid() {
+		return "", ErrUnknownCurrency
+	}
+	return c, nil
+}
+
+func (c Currency) Valid() bool {
+	_, ok := knownCurrencies[c]
+	return ok
+}
+
+func (c Currency) String() string { return string(c) }
+
+var ErrInvalidRate = Errorf(ErrValidation, "exchange rate must be > 0")
+
+// ExchangeRate — курс на месяц: сколько единиц To стоит одна единица From.
+type ExchangeRate struct {
+	From  Currency
+	To    Currency
+	Month YearMonth
+	Rate  *big.Rat
+}
+
+func (r *ExchangeRate) Validate() error {
+	if !r.From.Valid() || !r.To.Valid() {
+		return ErrUnknownCurrency
+	}
+	if r.From == r.To {
+		return Errorf(ErrValidation, "exchange rate currencies must differ")
+	}
+	if r.Rate == nil || r.Rate.Sign() <= 0 {
+		return ErrInvalidRate
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package domain
+
+import "time"
+
+var ErrInvalidDate = Errorf(ErrValidation, "invalid date format; use DD-MM-YYYY or MM-YYYY")
+
+// Date — календарная дата без времени (UTC).
+type Date struct {
+	time time.Time
+}
+
+func DateFromTime(t time.Time) Date {
+	return Date{time: time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)}
+}
+
+func MustDate(s string) Date {
+	d, err := ParseDate(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// ParseDate принимает DD-MM-YYYY и YYYY-MM-DD.
+func ParseDate(s string) (Date, error) {
+	var t time.Time
+	var err error
+	switch {
+	case len(s) == 10 && s[2] == '-' && s[5] == '-':
+		t, err = time.Parse("02-01-2006", s)
+	case len(s) == 10 && s[4] == '-' && s[7] == '-':
+		t, err = time.Parse("2006-01-02", s)
+	default:
+		return Date{}, ErrInvalidDate
+	}
+	if err != nil {
+		return Date{}, Errorf(ErrValidation, "invalid date %q: %w", s, err)
+	}
+	return DateFromTime(t), nil
+}
+
+func (d Date) String() string           { return d.time.Format("02-01-2006") }
+func (d Date) Time() time.Time          { return d.time }
+func (d Date) YearMonth() YearMonth     { return YearMonthFromTime(d.time) }
+func (d Date) Before(other Date) bool   { return d.time.Before(other.time) }
+func (d Date) AddDays(n int) Date       { return Date{time: d.time.AddDate(0, 0, n)} }
+func (d Date) DaysUntil(other Date) int { return int(other.time.Sub(d.time).Hours() / 24) }
+
+// FirstDay и LastDay — границы месяца.
+func (ym YearMonth) FirstDay() Date { return Date{time: ym.time} }
+func (ym YearMonth) LastDay() Date  { return Date{time: ym.time.AddDate(0, 1, -1)} }
+
+// ParseDateOrYearMonth принимает как месяц (MM-YYYY), так и дату (DD-MM-YYYY); для месяца date == nil.
+func ParseDateOrYearMonth(s string) (ym YearMonth, date *Date, err error) {
+	if len(s) == 7 {
+		ym, err = ParseYearMonth(s)
+		return ym, nil, err
+	}
+	d, err := ParseDate(s)
+	if err != nil {
+		return YearMonth{}, nil, err
+	}
+	return d.YearMonth(), &d, nil
+}
@@ -0,0 +1,35 @@
+package domain
+
+import "fmt"
+
+// Категории ошибок. Проверяются через errors.Is, например errors.Is(err, domain.ErrNotFound).
+var (
+	ErrNotFound    = kind("not found")
+	ErrValidation  = kind("validation error")
+	ErrConflict    = kind("conflict")
+	ErrUnavailable = kind("service unavailable")
+	// ErrPrecondition — объект изменился с тех пор, как его прочитал вызывающий (версия не совпала).
+	ErrPrecondition = kind("precondition failed")
+	// ErrUnauthenticated — вызывающий не предъявил действительных учётных данных.
+	ErrUnauthenticated = kind("unauthenticated")
+	// ErrForbidden — вызывающему не хватает прав на операцию.
+	ErrForbidden = kind("forbidden")
+)
+
+type kind string
+
+func (k kind) Error() string { return string(k) }
+
+// Error — ошибка с категорией: сообщение берётся из Err, а errors.Is срабатывает и на Kind, и на причину.
+type Error struct {
+	Kind error
+	Err  error
+}
+
+func (e *Error) Error() string   { return e.Err.Error() }
+func (e *Error) Unwrap() []error { return []error{e.Kind, e.Err} }
+
+// Errorf создаёт ошибку категории kind; поддерживает %w, как fmt.Errorf.
+func Errorf(kind error, format string, args ...any) error {
+	return &Error{Kind: kind, Err: fmt.Errorf(format, args...)}
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PriceChange — новая цена подписки, действующая с месяца EffectiveFrom.
+type PriceChange struct {
+	SubscriptionID uuid.UUID
+	EffectiveFrom  YearMonth
+	Price          int
+	CreatedAt      time.Time
+}
+
+func (pc *PriceChange) Validate() error {
+	if pc.Price < 0 {
+		return ErrInvalidPrice
+	}
+	return nil
+}
+
+// ValidatePriceChange проверяет, что изменение цены попадает в срок действия подписки.
+func (s *Subscription) ValidatePriceChange(pc *PriceChange) error {
+	if err := pc.Validate(); err != nil {
+		return err
+	}
+	if !s.Start.BeforeOrEqual(pc.EffectiveFrom) || (s.End != nil && !pc.EffectiveFrom.BeforeOrEqual(*s.End)) {
+		return Errorf(ErrValidation, "effective_from must be within subscription period")
+	}
+	return nil
+}
+
+// PriceAt — цена, действующая в месяце m: последнее изменение не позже m, иначе исходная Price.
+// PriceChanges должны быть отсортированы по EffectiveFrom.
+func (s *Subscription) PriceAt(m YearMonth) int {
+	price := s.Price
+	for _, pc := range s.PriceChanges {
+		if !pc.EffectiveFrom.BeforeOrEqual(m) {
+			break
+		}
+		price = pc.Price
+	}
+	return price
+}
+
+// PriceSchedule — график цен: исходная цена с месяца начала и все последующие изменения.
+func (s *Subscription) PriceSchedule() []PriceChange {
+	out := []PriceChange{{SubscriptionID: s.ID, EffectiveFrom: s.Start, Price: s.Price, CreatedAt: s.CreatedAt}}
+	for _, pc := range s.PriceChanges {
+		if pc.EffectiveFrom.Equal(s.Start) {
+			out[0] = pc
+			continue
+		}
+		out = append(out, pc)
+	}
+	return out
+}
+
+func SortPriceChanges(changes []PriceChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].EffectiveFrom.Time().Before(changes[j].EffectiveFrom.Time())
+	})
+}
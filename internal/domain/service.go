@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Service — сервис из каталога, на который ссылаются подписки. Название подписки при создании
+// сопоставляется с каталогом по Name и Aliases без учёта регистра и лишних пробелов (NormalizeServiceName).
+// Каталог у каждого арендатора свой.
+type Service struct {
+	ID           uuid.UUID
+	TenantID     string   // арендатор; задаётся при создании и не меняется
+	Name         string   // каноническое название; его получают подписки сервиса
+	Aliases      []string // другие написания, например «Яндекс Плюс» для «Yandex Plus»
+	Category     string
+	DefaultPrice *int     // цена подписки по умолчанию в Currency
+	Currency     Currency // валюта DefaultPrice
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// NormalizeServiceName приводит название к ключу поиска по каталогу: нижний регистр, пробелы
+// (unicode.IsSpace) по краям убраны, пробелы внутри схлопнуты в один. Заполнение каталога
+// в миграции 0011 повторяет это правило в SQL.
+func NormalizeServiceName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// Keys — ключи поиска сервиса: нормализованные название и псевдонимы без повторов.
+func (s *Service) Keys() []string {
+	keys := []string{NormalizeServiceName(s.Name)}
+	for _, a := range s.Aliases {
+		k := NormalizeServiceName(a)
+		if !slices.Contains(keys, k) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// Normalize убирает лишние пробелы в названиях и псевдонимы, совпадающие с названием или друг с другом.
+func (s *Service) Normalize() {
+	s.Name = strings.Join(strings.Fields(s.Name), " ")
+	seen := []string{NormalizeServiceName(s.Name)}
+	aliases := s.Aliases[:0]
+	for _, a := range s.Aliases {
+		a = strings.Join(strings.Fields(a), " ")
+		if k := NormalizeServiceName(a); k != "" && !slices.Contains(seen, k) {
+			seen = append(seen, k)
+			aliases = append(aliases, a)
+		}
+	}
+	s.Aliases = aliases
+	s.Category = strings.TrimSpace(s.Category)
+}
+
+func (s *Service) Validate() error {
+	if s.Name == "" {
+		return Errorf(ErrValidation, "name is required")
+	}
+	if s.DefaultPrice != nil && *s.DefaultPrice < 0 {
+		return ErrInvalidPrice
+	}
+	if !s.Currency.Valid() {
+		return ErrUnknownCurrency
+	}
+	return nil
+}
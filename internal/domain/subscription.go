@@ -1,15 +1,16 @@
 package domain
 
 import (
-	"errors"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 var (
-	ErrInvalidPrice     = errors.New("price must be >= 0")
-	ErrInvalidDateRange = errors.New("start_date must be <= end_date")
+	ErrInvalidPrice     = Errorf(ErrValidation, "price must be >= 0")
+	ErrInvalidDateRange = Errorf(ErrValidation, "start_date must be <= end_date")
+	ErrInvalidYearMonth = Errorf(ErrValidation, "invalid year-month format; use MM-YYYY")
+	ErrEmptyServiceName = Errorf(ErrValidation, "service_name is required")
 )
 
 type YearMonth struct {
@@ -37,23 +38,27 @@ func ParseYearMonth(mmYYYY string) (YearMonth, error) {
 	} else if len(mmYYYY) == 7 && mmYYYY[4] == '-' {
 		t, err = time.Parse("2006-01", mmYYYY)
 	} else {
-		return YearMonth{}, errors.New("invalid year-month format; use MM-YYYY")
+		return YearMonth{}, ErrInvalidYearMonth
 	}
 	if err != nil {
-		return YearMonth{}, err
+		return YearMonth{}, Errorf(ErrValidation, "invalid year-month %q: %w", mmYYYY, err)
 	}
 	t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
 	return YearMonth{time: t}, nil
 }
 
-func (ym YearMonth) String() string  { return ym.time.Format("01-2006") }
-func (ym YearMonth) Time() time.Time { return ym.time }
+func (ym YearMonth) String() string             { return ym.time.Format("01-2006") }
+func (ym YearMonth) Time() time.Time            { return ym.time }
+func (ym YearMonth) Equal(other YearMonth) bool { return ym.time.Equal(other.time) }
 func (ym YearMonth) BeforeOrEqual(other YearMonth) bool {
 	return !ym.time.After(other.time)
 }
 func (ym YearMonth) AfterOrEqual(other YearMonth) bool {
 	return !ym.time.Before(other.time)
 }
+func (ym YearMonth) AddMonths(n int) YearMonth {
+	return YearMonth{time: ym.time.AddDate(0, n, 0)}
+}
 func (ym YearMonth) MonthsUntil(other YearMonth) int {
 	y1, m1 := ym.time.Year(), ym.time.Month()
 	y2, m2 := other.time.Year(), other.time.Month()
@@ -61,26 +66,144 @@ func (ym YearMonth) MonthsUntil(other YearMonth) int {
 }
 
 type Subscription struct {
-	ID          uuid.UUID
-	ServiceName string
-	Price       int
-	UserID      uuid.UUID
-	Start       YearMonth
-	End         *YearMonth
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	ID            uuid.UUID
+	TenantID      string    // арендатор; задаётся при создании и не меняется
+	ServiceID     uuid.UUID // сервис из каталога
+	ServiceName   string    // каноническое название сервиса
+	Price         int
+	Currency      Currency
+	BillingPeriod BillingPeriod // за какой период берётся Price
+	UserID        uuid.UUID
+	Start         YearMonth
+	End           *YearMonth
+	StartDate     *Date         // задан у подписок с точностью до дня, месяц совпадает со Start
+	EndDate       *Date         // задан у подписок с точностью до дня, месяц совпадает с End
+	PriceChanges  []PriceChange // изменения цены после Start, по возрастанию EffectiveFrom
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	DeletedAt     *time.Time // задан у удалённых подписок до окончательного удаления
+	Version       int64      // увеличивается при каждом изменении, начиная с 1
+}
+
+// ErrVersionMismatch — подписку успели изменить после чтения.
+var ErrVersionMismatch = Errorf(ErrPrecondition, "subscription was modified concurrently; reload and retry")
+
+// CheckVersion сравнивает версию подписки с ожидаемой; 0 означает «любая версия».
+func (s *Subscription) CheckVersion(expected int64) error {
+	if expected != 0 && s.Version != expected {
+		return ErrVersionMismatch
+	}
+	return nil
 }
 
+// Deleted сообщает, удалена ли подписка (soft delete).
+func (s *Subscription) Deleted() bool { return s.DeletedAt != nil }
+
 func (s *Subscription) Validate() error {
+	if s.ServiceName == "" {
+		return ErrEmptyServiceName
+	}
 	if s.Price < 0 {
 		return ErrInvalidPrice
 	}
+	if !s.Currency.Valid() {
+		return ErrUnknownCurrency
+	}
+	if !s.BillingPeriod.Valid() {
+		return ErrUnknownBillingPeriod
+	}
 	if s.End != nil && s.End.time.Before(s.Start.time) {
 		return ErrInvalidDateRange
 	}
+	if s.StartDate != nil || s.EndDate != nil {
+		if s.StartDate == nil || (s.End == nil) != (s.EndDate == nil) {
+			return Errorf(ErrValidation, "start and end dates must have the same precision")
+		}
+		if !s.StartDate.YearMonth().Equal(s.Start) || (s.End != nil && !s.EndDate.YearMonth().Equal(*s.End)) {
+			return Errorf(ErrValidation, "dates do not match subscription months")
+		}
+		if s.EndDate != nil && s.EndDate.Before(*s.StartDate) {
+			return ErrInvalidDateRange
+		}
+	}
 	return nil
 }
 
+// NormalizeDays выравнивает точность границ: если одна граница задана днём, вторая
+// дополняется первым (для начала) или последним (для окончания) днём своего месяца.
+func (s *Subscription) NormalizeDays() {
+	if s.StartDate == nil && s.EndDate == nil {
+		return
+	}
+	if s.StartDate == nil {
+		d := s.Start.FirstDay()
+		s.StartDate = &d
+	}
+	if s.End == nil {
+		s.EndDate = nil
+	} else if s.EndDate == nil {
+		d := s.End.LastDay()
+		s.EndDate = &d
+	}
+}
+
+// DayPrecision сообщает, заданы ли границы подписки с точностью до дня.
+func (s *Subscription) DayPrecision() bool { return s.StartDate != nil }
+
+// FirstDay — первый день действия подписки.
+func (s *Subscription) FirstDay() Date {
+	if s.StartDate != nil {
+		return *s.StartDate
+	}
+	return s.Start.FirstDay()
+}
+
+// LastDay — последний день действия подписки; nil для бессрочной.
+func (s *Subscription) LastDay() *Date {
+	if s.End == nil {
+		return nil
+	}
+	if s.EndDate != nil {
+		d := *s.EndDate
+		return &d
+	}
+	d := s.End.LastDay()
+	return &d
+}
+
+// EndBy завершает подписку днём today с её точностью: месяцем today или самим днём.
+func (s *Subscription) EndBy(today Date) {
+	end := today.YearMonth()
+	s.End = &end
+	if s.DayPrecision() {
+		s.EndDate = &today
+	}
+}
+
+// activeSpan — дни месяца m, в которые подписка действует; ok == false, если таких нет.
+func (s *Subscription) activeSpan(m YearMonth) (lo, hi Date, ok bool) {
+	if !s.ActiveIn(m) {
+		return Date{}, Date{}, false
+	}
+	lo, hi = m.FirstDay(), m.LastDay()
+	if first := s.FirstDay(); lo.Before(first) {
+		lo = first
+	}
+	if last := s.LastDay(); last != nil && last.Before(hi) {
+		hi = *last
+	}
+	return lo, hi, !hi.Before(lo)
+}
+
+// ActiveDaysIn — число дней месяца m, в которые подписка действует.
+func (s *Subscription) ActiveDaysIn(m YearMonth) int {
+	lo, hi, ok := s.activeSpan(m)
+	if !ok {
+		return 0
+	}
+	return lo.DaysUntil(hi) + 1
+}
+
 func (s *Subscription) OverlapMonths(from, to YearMonth) int {
 	start := s.Start
 	end := to
@@ -107,3 +230,8 @@ func (s *Subscription) OverlapMonths(from, to YearMonth) int {
 	}
 	return from.MonthsUntil(to)
 }
+
+// ActiveIn сообщает, действует ли подписка в месяце m.
+func (s *Subscription) ActiveIn(m YearMonth) bool {
+	return s.OverlapMonths(m, m) > 0
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Organization объединяет команды; стоимость подписок сворачивается по её дереву команд.
+type Organization struct {
+	ID        uuid.UUID
+	TenantID  string // арендатор; задаётся при создании и не меняется
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (o *Organization) Normalize() { o.Name = strings.Join(strings.Fields(o.Name), " ") }
+
+func (o *Organization) Validate() error {
+	if o.Name == "" {
+		return Errorf(ErrValidation, "name is required")
+	}
+	return nil
+}
+
+// Team — команда организации. Команды вкладываются друг в друга через ParentID;
+// у команды верхнего уровня ParentID не задан.
+type Team struct {
+	ID        uuid.UUID
+	TenantID  string // арендатор организации
+	OrgID     uuid.UUID
+	ParentID  *uuid.UUID
+	Name      string // уникально в организации без учёта регистра
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (t *Team) Normalize() { t.Name = strings.Join(strings.Fields(t.Name), " ") }
+
+func (t *Team) Validate() error {
+	if t.Name == "" {
+		return Errorf(ErrValidation, "name is required")
+	}
+	if t.ParentID != nil && *t.ParentID == t.ID {
+		return Errorf(ErrValidation, "team cannot be its own parent")
+	}
+	return nil
+}
+
+// CheckParent проверяет по командам организации, что родитель t существует и не лежит внутри
+// самой t (иначе дерево замкнулось бы в цикл). Обход ограничен числом команд, поэтому
+// завершается и на дереве, где цикл уже есть.
+func (t *Team) CheckParent(teams []*Team) error {
+	if t.ParentID == nil {
+		return nil
+	}
+	byID := make(map[uuid.UUID]*Team, len(teams))
+	for _, team := range teams {
+		byID[team.ID] = team
+	}
+	if _, ok := byID[*t.ParentID]; !ok {
+		return Errorf(ErrValidation, "parent team %s not found in the organization", *t.ParentID)
+	}
+	p := t.ParentID
+	for range len(byID) {
+		if *p == t.ID {
+			return Errorf(ErrValidation, "team cannot be moved under its own sub-team")
+		}
+		parent, ok := byID[*p]
+		if !ok || parent.ParentID == nil {
+			return nil
+		}
+		p = parent.ParentID
+	}
+	return Errorf(ErrConflict, "team tree of the organization contains a cycle")
+}
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestTeamCheckParent(t *testing.T) {
+	// root <- a <- b; c и d ссылаются друг на друга (испорченное дерево).
+	root, a, b := &Team{ID: uuid.New()}, &Team{ID: uuid.New()}, &Team{ID: uuid.New()}
+	c, d := &Team{ID: uuid.New()}, &Team{ID: uuid.New()}
+	a.ParentID, b.ParentID, c.ParentID, d.ParentID = &root.ID, &a.ID, &d.ID, &c.ID
+	teams := []*Team{root, a, b, c, d}
+	missing := uuid.New()
+
+	tests := []struct {
+		name   string
+		team   uuid.UUID
+		parent *uuid.UUID
+		want   error
+	}{
+		{"top level", a.ID, nil, nil},
+		{"sibling branch", b.ID, &root.ID, nil},
+		{"under own child", a.ID, &b.ID, ErrValidation},
+		{"under itself via chain", root.ID, &b.ID, ErrValidation},
+		{"unknown parent", a.ID, &missing, ErrValidation},
+		{"existing cycle", a.ID, &c.ID, ErrConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			team := &Team{ID: tt.team, ParentID: tt.parent}
+			err := team.CheckParent(teams)
+			if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultTimezone — часовой пояс пользователя, если он не указан.
+const DefaultTimezone = "UTC"
+
+// User — владелец подписок.
+type User struct {
+	ID        uuid.UUID
+	TenantID  string     // арендатор; задаётся при создании и не меняется
+	Name      string     // отображаемое имя
+	Email     string     // необязателен; уникален без учёта регистра среди неудалённых пользователей арендатора
+	TeamID    *uuid.UUID // команда, в стоимость которой входят подписки пользователя
+	Timezone  string     // название из базы IANA, например Europe/Moscow
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt *time.Time // задан у удалённых пользователей
+}
+
+// Deleted сообщает, удалён ли пользователь.
+func (u *User) Deleted() bool { return u.DeletedAt != nil }
+
+// Normalize убирает лишние пробелы и подставляет часовой пояс по умолчанию.
+func (u *User) Normalize() {
+	u.Name = strings.Join(strings.Fields(u.Name), " ")
+	u.Email = strings.TrimSpace(u.Email)
+	u.Timezone = strings.TrimSpace(u.Timezone)
+	if u.Timezone == "" {
+		u.Timezone = DefaultTimezone
+	}
+}
+
+func (u *User) Validate() error {
+	if u.Name == "" {
+		return Errorf(ErrValidation, "name is required")
+	}
+	if u.Email != "" {
+		addr, err := mail.ParseAddress(u.Email)
+		if err != nil || addr.Address != u.Email {
+			return Errorf(ErrValidation, "invalid email %q", u.Email)
+		}
+	}
+	if _, err := time.LoadLocation(u.Timezone); err != nil {
+		return Errorf(ErrValidation, "unknown timezone %q", u.Timezone)
+	}
+	return nil
+}
@@ -1,6 +1,9 @@
 package domain
 
-import "testing"
+import (
+	"errors"
+	"testing"
+)
 
 func TestParseYearMonth(t *testing.T) {
 	for _, s := range []string{"07-2025", "2025-07"} {
@@ -9,3 +12,27 @@ func TestParseYearMonth(t *testing.T) {
 		}
 	}
 }
+
+func TestParseYearMonthInvalid(t *testing.T) {
+	for _, s := range []string{"", "7-2025", "13-2025", "2025/07"} {
+		if _, err := ParseYearMonth(s); !errors.Is(err, ErrValidation) {
+			t.Fatalf("parse %q: got %v, want validation error", s, err)
+		}
+	}
+}
+
+func TestParseDateOrYearMonth(t *testing.T) {
+	ym, d, err := ParseDateOrYearMonth("07-2025")
+	if err != nil || d != nil || ym.String() != "07-2025" {
+		t.Fatalf("month: got %v %v %v", ym, d, err)
+	}
+	for _, s := range []string{"03-07-2025", "2025-07-03"} {
+		ym, d, err = ParseDateOrYearMonth(s)
+		if err != nil || d == nil || d.String() != "03-07-2025" || ym.String() != "07-2025" {
+			t.Fatalf("date %s: got %v %v %v", s, ym, d, err)
+		}
+	}
+	if _, _, err := ParseDateOrYearMonth("31-02-2025"); !errors.Is(err, ErrValidation) {
+		t.Fatalf("invalid date: got %v", err)
+	}
+}
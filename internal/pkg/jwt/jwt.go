@@ -0,0 +1,230 @@
+// Package jwt проверяет JWT, подписанные HS256 (общий секрет) или RS256 (открытые ключи из JWKS-файла).
+// Токены сервис только принимает, поэтому подписи здесь нет.
+package jwt
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Config — ключи и ожидаемые значения утверждений. Нужен хотя бы один из HS256Secret и JWKSFile;
+// пустые Issuer и Audience не проверяются.
+type Config struct {
+	HS256Secret string
+	JWKSFile    string
+	Issuer      string
+	Audience    string
+	Leeway      time.Duration // допустимое расхождение часов для exp и nbf
+}
+
+// Claims — утверждения проверенного токена, которые использует сервис.
+type Claims struct {
+	Subject   string
+	Issuer    string
+	Audience  []string
+	ExpiresAt time.Time
+	Roles     []string
+	TenantID  string // утверждение tenant_id
+}
+
+type Verifier struct {
+	secret []byte
+	keys   map[string]*rsa.PublicKey // по kid
+	cfg    Config
+}
+
+func NewVerifier(cfg Config) (*Verifier, error) {
+	v := &Verifier{cfg: cfg}
+	if cfg.HS256Secret != "" {
+		v.secret = []byte(cfg.HS256Secret)
+	}
+	if cfg.JWKSFile != "" {
+		data, err := os.ReadFile(cfg.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("read JWKS: %w", err)
+		}
+		if v.keys, err = ParseJWKS(data); err != nil {
+			return nil, err
+		}
+	}
+	if v.secret == nil && len(v.keys) == 0 {
+		return nil, errors.New("jwt: HS256 secret or JWKS file is required")
+	}
+	return v, nil
+}
+
+// ParseJWKS читает RSA-ключи из JWK Set; ключи других типов и не для подписи пропускаются.
+func ParseJWKS(data []byte) (map[string]*rsa.PublicKey, error) {
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parse JWKS: %w", err)
+	}
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("JWKS key %q: modulus: %w", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("JWKS key %q: exponent: %w", k.Kid, err)
+		}
+		exp := new(big.Int).SetBytes(e)
+		if !exp.IsInt64() || exp.Int64() < 3 || exp.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("JWKS key %q: bad exponent", k.Kid)
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}
+	}
+	return keys, nil
+}
+
+// Verify проверяет подпись, срок действия, издателя и аудиторию токена на момент now.
+// Алгоритм определяет, каким ключом проверять подпись, поэтому HS256-токен не пройдёт проверку
+// открытым RSA-ключом как секретом.
+func (v *Verifier) Verify(token string, now time.Time) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Claims{}, errors.New("malformed token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodePart(parts[0], &header); err != nil {
+		return Claims{}, fmt.Errorf("header: %w", err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, fmt.Errorf("signature: %w", err)
+	}
+	if err := v.verifySignature(header.Alg, header.Kid, parts[0]+"."+parts[1], sig); err != nil {
+		return Claims{}, err
+	}
+
+	var payload struct {
+		Sub    string   `json:"sub"`
+		Iss    string   `json:"iss"`
+		Aud    audience `json:"aud"`
+		Exp    *numeric `json:"exp"`
+		Nbf    *numeric `json:"nbf"`
+		Roles  []string `json:"roles"`
+		Tenant string   `json:"tenant_id"`
+	}
+	if err := decodePart(parts[1], &payload); err != nil {
+		return Claims{}, fmt.Errorf("payload: %w", err)
+	}
+	if payload.Exp == nil {
+		return Claims{}, errors.New("exp claim is required")
+	}
+	if !now.Before(payload.Exp.Time().Add(v.cfg.Leeway)) {
+		return Claims{}, errors.New("token expired")
+	}
+	if payload.Nbf != nil && now.Add(v.cfg.Leeway).Before(payload.Nbf.Time()) {
+		return Claims{}, errors.New("token not valid yet")
+	}
+	if v.cfg.Issuer != "" && payload.Iss != v.cfg.Issuer {
+		return Claims{}, errors.New("unexpected issuer")
+	}
+	if v.cfg.Audience != "" && !slices.Contains(payload.Aud, v.cfg.Audience) {
+		return Claims{}, errors.New("unexpected audience")
+	}
+	if payload.Sub == "" {
+		return Claims{}, errors.New("sub claim is required")
+	}
+	return Claims{
+		Subject:   payload.Sub,
+		Issuer:    payload.Iss,
+		Audience:  payload.Aud,
+		ExpiresAt: payload.Exp.Time(),
+		Roles:     payload.Roles,
+		TenantID:  payload.Tenant,
+	}, nil
+}
+
+func (v *Verifier) verifySignature(alg, kid, signed string, sig []byte) error {
+	switch alg {
+	case "HS256":
+		if v.secret == nil {
+			return errors.New("HS256 tokens are not accepted")
+		}
+		mac := hmac.New(sha256.New, v.secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	case "RS256":
+		key, ok := v.keys[kid]
+		if !ok && kid == "" && len(v.keys) == 1 {
+			// Токен без kid при единственном ключе в наборе.
+			for _, k := range v.keys {
+				key, ok = k, true
+			}
+		}
+		if !ok {
+			return fmt.Errorf("unknown key %q", kid)
+		}
+		digest := sha256.Sum256([]byte(signed))
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+			return errors.New("invalid signature")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+}
+
+func decodePart(part string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// audience — утверждение aud: строка или массив строк.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*a = audience{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return errors.New("aud must be a string or an array of strings")
+	}
+	*a = many
+	return nil
+}
+
+// numeric — NumericDate: секунды Unix, возможно дробные.
+type numeric float64
+
+func (n numeric) Time() time.Time {
+	sec := float64(n)
+	return time.Unix(int64(sec), int64((sec-float64(int64(sec)))*1e9)).UTC()
+}
@@ -0,0 +1,108 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func encode(t *testing.T, v any) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func signHS256(t *testing.T, secret string, claims map[string]any) string {
+	signed := encode(t, map[string]string{"alg": "HS256", "typ": "JWT"}) + "." + encode(t, claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	signed := encode(t, map[string]string{"alg": "RS256", "kid": kid}) + "." + encode(t, claims)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks, _ := json.Marshal(map[string]any{"keys": []map[string]string{{
+		"kty": "RSA", "kid": "k1", "use": "sig",
+		"n": base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+		"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+	}}})
+	keys, err := ParseJWKS(jwks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := &Verifier{secret: []byte("s3cret"), keys: keys, cfg: Config{Issuer: "idp", Audience: "subscriptions", Leeway: time.Minute}}
+
+	claims := func(overrides map[string]any) map[string]any {
+		c := map[string]any{"sub": "alice", "iss": "idp", "aud": []string{"subscriptions"}, "exp": now.Add(time.Hour).Unix(), "roles": []string{"admin"}, "tenant_id": "acme"}
+		for k, val := range overrides {
+			if val == nil {
+				delete(c, k)
+			} else {
+				c[k] = val
+			}
+		}
+		return c
+	}
+	hs := signHS256(t, "s3cret", claims(nil))
+	tampered := strings.Split(hs, ".")
+	tampered[1] = encode(t, claims(map[string]any{"sub": "mallory"}))
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{"hs256", hs, ""},
+		{"rs256", signRS256(t, rsaKey, "k1", claims(map[string]any{"aud": "subscriptions"})), ""},
+		{"expired within leeway", signHS256(t, "s3cret", claims(map[string]any{"exp": now.Add(-30 * time.Second).Unix()})), ""},
+		{"expired", signHS256(t, "s3cret", claims(map[string]any{"exp": now.Add(-time.Hour).Unix()})), "token expired"},
+		{"no exp", signHS256(t, "s3cret", claims(map[string]any{"exp": nil})), "exp claim is required"},
+		{"not yet valid", signHS256(t, "s3cret", claims(map[string]any{"nbf": now.Add(time.Hour).Unix()})), "not valid yet"},
+		{"wrong secret", signHS256(t, "other", claims(nil)), "invalid signature"},
+		{"tampered payload", strings.Join(tampered, "."), "invalid signature"},
+		{"wrong issuer", signHS256(t, "s3cret", claims(map[string]any{"iss": "evil"})), "unexpected issuer"},
+		{"wrong audience", signHS256(t, "s3cret", claims(map[string]any{"aud": "other"})), "unexpected audience"},
+		{"unknown kid", signRS256(t, rsaKey, "k2", claims(nil)), "unknown key"},
+		{"alg none", encode(t, map[string]string{"alg": "none"}) + "." + encode(t, claims(nil)) + ".", "unsupported algorithm"},
+		{"malformed", "not-a-token", "malformed token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := v.Verify(tt.token, now)
+			if tt.wantErr == "" {
+				if err != nil || c.Subject != "alice" || len(c.Roles) != 1 || c.TenantID != "acme" {
+					t.Fatalf("Verify = %+v, %v", c, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Verify error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+)
+
+// ActiveSnapshot — подписки, действующие в месяце, и их стоимость за этот месяц.
+type ActiveSnapshot struct {
+	Month         domain.YearMonth
+	Mode          SummaryMode
+	Subscriptions []*domain.Subscription
+	// Costs — общая стоимость по валютам (суммы в разных валютах не складываются).
+	Costs []SummaryResult
+}
+
+// ActiveAt возвращает подписки пользователя, действующие в месяце at (MM-YYYY), по названию сервиса
+// и их стоимость за месяц так же, как её считает Summary за период из одного месяца.
+func (s *Service) ActiveAt(ctx context.Context, userID uuid.UUID, at string, mode *string) (ActiveSnapshot, error) {
+	if err := authorizeUser(ctx, userID, false); err != nil {
+		return ActiveSnapshot{}, err
+	}
+	f, err := SummaryInput{From: at, To: at, UserID: &userID, Mode: mode}.filter()
+	if err != nil {
+		return ActiveSnapshot{}, err
+	}
+	snap := ActiveSnapshot{Month: f.From, Mode: f.Mode, Costs: []SummaryResult{}}
+	lf := ListFilter{UserIDs: []uuid.UUID{userID}, ActiveIn: &f.From, Sort: ListSort{Field: SortServiceName}}
+	err = s.repo.Export(ctx, lf, func(sub *domain.Subscription) error {
+		snap.Subscriptions = append(snap.Subscriptions, sub)
+		return nil
+	})
+	if err != nil {
+		return ActiveSnapshot{}, err
+	}
+
+	currencies, err := s.repo.SummaryCurrencies(ctx, f)
+	if err != nil {
+		return ActiveSnapshot{}, err
+	}
+	for _, c := range currencies {
+		fc := f
+		fc.Currency = &c
+		total, err := s.repo.Summary(ctx, fc)
+		if err != nil {
+			return ActiveSnapshot{}, err
+		}
+		snap.Costs = append(snap.Costs, SummaryResult{Currency: c, Total: total})
+	}
+	return snap, nil
+}
@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+)
+
+// AnonymousActor — исполнитель изменений, когда вызывающий не представился.
+const AnonymousActor = "anonymous"
+
+// AuditMeta — данные о вызывающем, которые хранилище записывает в журнал аудита вместе с изменением.
+type AuditMeta struct {
+	Actor     string
+	RequestID string
+}
+
+type auditMetaKey struct{}
+
+// WithAuditMeta кладёт в контекст исполнителя и идентификатор запроса для журнала аудита.
+func WithAuditMeta(ctx context.Context, m AuditMeta) context.Context {
+	return context.WithValue(ctx, auditMetaKey{}, m)
+}
+
+// AuditMetaFrom достаёт данные для журнала аудита; без них исполнитель — AnonymousActor.
+func AuditMetaFrom(ctx context.Context) AuditMeta {
+	m, _ := ctx.Value(auditMetaKey{}).(AuditMeta)
+	if m.Actor == "" {
+		m.Actor = AnonymousActor
+	}
+	return m
+}
+
+// NewAuditRecord собирает запись журнала для изменения before -> after с данными вызывающего из ctx.
+// Хранилища вызывают её внутри той же транзакции, что и само изменение.
+func NewAuditRecord(ctx context.Context, op domain.AuditOperation, before, after *domain.Subscription) domain.AuditRecord {
+	m := AuditMetaFrom(ctx)
+	return domain.NewAuditRecord(op, m.Actor, m.RequestID, before, after)
+}
+
+// History возвращает журнал изменений подписки от старых записей к новым; доступен и после удаления.
+func (s *Service) History(ctx context.Context, id uuid.UUID) ([]domain.AuditRecord, error) {
+	if err := s.authorize(ctx, id, false); err != nil {
+		return nil, err
+	}
+	recs, err := s.repo.History(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(recs) == 0 {
+		return nil, domain.Errorf(domain.ErrNotFound, "subscription not found")
+	}
+	return recs, nil
+}
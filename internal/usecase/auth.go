@@ -0,0 +1,171 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+)
+
+// APIKeyPrefix начинает каждый выданный API-ключ, чтобы его было легко отличить от JWT и найти в логах.
+const APIKeyPrefix = "sk_"
+
+// APIKeyRepo хранит API-ключи.
+type APIKeyRepo interface {
+	Create(ctx context.Context, k *domain.APIKey) error
+	// GetByHash ищет ключ по SHA-256 хешу, в том числе отозванный.
+	GetByHash(ctx context.Context, hash []byte) (*domain.APIKey, error)
+	// List возвращает ключи арендатора вызова (TenantFrom) от новых к старым.
+	List(ctx context.Context) ([]*domain.APIKey, error)
+	// Revoke отзывает ключ арендатора вызова; повторный отзыв не меняет время отзыва.
+	Revoke(ctx context.Context, id uuid.UUID, at time.Time) error
+}
+
+type (
+	principalKey struct{}
+	tenantKey    struct{}
+)
+
+// WithPrincipal кладёт в контекст аутентифицированного вызывающего.
+func WithPrincipal(ctx context.Context, p domain.Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom достаёт вызывающего из контекста. ok=false — вызов внутренний (CLI, фоновые задачи)
+// или аутентификация отключена.
+func PrincipalFrom(ctx context.Context) (domain.Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(domain.Principal)
+	return p, ok
+}
+
+// WithTenant задаёт арендатора внутреннего вызова (CLI); у аутентифицированного вызывающего
+// арендатор всегда берётся из Principal.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFrom возвращает арендатора, данными которого ограничен вызов: арендатора вызывающего,
+// заданного WithTenant или domain.DefaultTenant.
+func TenantFrom(ctx context.Context) string {
+	if p, ok := PrincipalFrom(ctx); ok {
+		return p.Tenant()
+	}
+	if t, ok := ctx.Value(tenantKey{}).(string); ok && t != "" {
+		return t
+	}
+	return domain.DefaultTenant
+}
+
+// requireRole пропускает внутренние вызовы и вызывающих с ролью role.
+func requireRole(ctx context.Context, role string) error {
+	if p, ok := PrincipalFrom(ctx); ok && !p.HasRole(role) {
+		return domain.Errorf(domain.ErrForbidden, "%s role required", role)
+	}
+	return nil
+}
+
+// CreateAPIKey выпускает ключ и возвращает его вместе с секретом; секрет больше нигде не сохраняется.
+// Ключ действует в арендаторе вызова; ключ для другого арендатора выпускается только внутренним вызовом,
+// и его пользователь ищется среди пользователей этого арендатора.
+func (s *Service) CreateAPIKey(ctx context.Context, in APIKeyInput) (*domain.APIKey, string, error) {
+	if err := requireRole(ctx, domain.RoleAdmin); err != nil {
+		return nil, "", err
+	}
+	tenant := TenantFrom(ctx)
+	if in.TenantID != "" && in.TenantID != tenant {
+		if _, ok := PrincipalFrom(ctx); ok {
+			return nil, "", domain.Errorf(domain.ErrForbidden, "api keys can only be issued for the caller's tenant")
+		}
+		tenant = in.TenantID
+		ctx = WithTenant(ctx, tenant)
+	}
+	k := &domain.APIKey{ID: uuid.New(), Name: in.Name, UserID: in.UserID, TenantID: tenant, Roles: in.Roles, CreatedAt: time.Now().UTC()}
+	k.Normalize()
+	if err := k.Validate(); err != nil {
+		return nil, "", err
+	}
+	if k.UserID != nil {
+		if err := s.requireUser(ctx, *k.UserID); err != nil {
+			return nil, "", err
+		}
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, "", err
+	}
+	secret := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	k.Prefix, k.Hash = secret[:len(APIKeyPrefix)+6], hashAPIKey(secret)
+	if err := s.apiKeys.Create(ctx, k); err != nil {
+		return nil, "", err
+	}
+	return k, secret, nil
+}
+
+func (s *Service) ListAPIKeys(ctx context.Context) ([]*domain.APIKey, error) {
+	if err := requireRole(ctx, domain.RoleAdmin); err != nil {
+		return nil, err
+	}
+	return s.apiKeys.List(ctx)
+}
+
+func (s *Service) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	if err := requireRole(ctx, domain.RoleAdmin); err != nil {
+		return err
+	}
+	return s.apiKeys.Revoke(ctx, id, time.Now().UTC())
+}
+
+// AuthenticateAPIKey находит вызывающего по секрету ключа. Отозванный ключ и ключ удалённого
+// пользователя (см. CheckPrincipal) недействительны.
+func (s *Service) AuthenticateAPIKey(ctx context.Context, secret string) (domain.Principal, error) {
+	invalid := domain.Errorf(domain.ErrUnauthenticated, "invalid API key")
+	k, err := s.apiKeys.GetByHash(ctx, hashAPIKey(secret))
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.Principal{}, invalid
+	}
+	if err != nil {
+		return domain.Principal{}, err
+	}
+	if k.Revoked() {
+		return domain.Principal{}, invalid
+	}
+	p := k.Principal()
+	if err := s.CheckPrincipal(ctx, p); err != nil {
+		return domain.Principal{}, err
+	}
+	return p, nil
+}
+
+// CheckPrincipal проверяет, что пользователь, от имени которого действует вызывающий, существует
+// и не удалён. Её вызывают все аутентификаторы: учётные данные удалённого пользователя недействительны.
+func (s *Service) CheckPrincipal(ctx context.Context, p domain.Principal) error {
+	if p.UserID == nil {
+		return nil
+	}
+	_, err := s.users.Get(WithTenant(ctx, p.Tenant()), *p.UserID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.Errorf(domain.ErrUnauthenticated, "user %s of the credentials does not exist", *p.UserID)
+	}
+	return err
+}
+
+func hashAPIKey(secret string) []byte {
+	sum := sha256.Sum256([]byte(secret))
+	return sum[:]
+}
+
+// DTOs
+
+type APIKeyInput struct {
+	Name   string
+	UserID *uuid.UUID // пользователь, от имени которого действует ключ
+	// TenantID — арендатор ключа; по умолчанию арендатор вызова.
+	TenantID string
+	Roles    []string
+}
@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+)
+
+// Права на подписки. Вызов без Principal (CLI, фоновые задачи, отключённая аутентификация) ничем
+// не ограничен. Роль admin читает и меняет все подписки, finance — читает все; в остальном
+// вызывающий работает только с подписками своего пользователя (Principal.UserID).
+
+// userScope возвращает пользователя, подписками которого ограничен вызывающий для чтения или,
+// если write, для изменения; nil — ограничения нет.
+func userScope(ctx context.Context, write bool) (*uuid.UUID, error) {
+	p, ok := PrincipalFrom(ctx)
+	if !ok || p.HasRole(domain.RoleAdmin) || !write && p.HasRole(domain.RoleFinance) {
+		return nil, nil
+	}
+	if p.UserID == nil {
+		return nil, domain.Errorf(domain.ErrForbidden, "caller is not linked to a user")
+	}
+	return p.UserID, nil
+}
+
+// authorizeUser проверяет, что вызывающему доступны подписки пользователя userID.
+func authorizeUser(ctx context.Context, userID uuid.UUID, write bool) error {
+	scope, err := userScope(ctx, write)
+	if err != nil {
+		return err
+	}
+	if scope != nil && *scope != userID {
+		return errOtherUser()
+	}
+	return nil
+}
+
+// authorizeSubscription проверяет доступ к sub. Подписку, которую вызывающий не может даже читать,
+// он не должен отличать от несуществующей.
+func authorizeSubscription(ctx context.Context, sub *domain.Subscription, write bool) error {
+	if authorizeUser(ctx, sub.UserID, false) != nil {
+		return domain.Errorf(domain.ErrNotFound, "subscription not found")
+	}
+	if write {
+		return authorizeUser(ctx, sub.UserID, true)
+	}
+	return nil
+}
+
+// authorize — authorizeSubscription по ID подписки (в том числе удалённой); вызывающему без
+// ограничений подписка не читается.
+func (s *Service) authorize(ctx context.Context, id uuid.UUID, write bool) error {
+	if scope, err := userScope(ctx, write); err == nil && scope == nil {
+		return nil
+	}
+	sub, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	return authorizeSubscription(ctx, sub, write)
+}
+
+// scopeUserIDs ограничивает фильтр по пользователям (пустой — все) пользователем вызывающего.
+// Фильтр по чужим пользователям запрещён, а не молча сужается.
+func scopeUserIDs(ctx context.Context, ids []uuid.UUID) ([]uuid.UUID, error) {
+	scope, err := userScope(ctx, false)
+	if err != nil || scope == nil {
+		return ids, err
+	}
+	for _, id := range ids {
+		if id != *scope {
+			return nil, errOtherUser()
+		}
+	}
+	return []uuid.UUID{*scope}, nil
+}
+
+// requireReadAll пропускает только вызывающих, которые читают подписки всех пользователей
+// (отчёты по командам и организациям).
+func requireReadAll(ctx context.Context) error {
+	scope, err := userScope(ctx, false)
+	if err == nil && scope != nil {
+		return errOtherUser()
+	}
+	return err
+}
+
+func errOtherUser() error {
+	return domain.Errorf(domain.ErrForbidden, "access to subscriptions of other users is denied")
+}
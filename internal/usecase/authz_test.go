@@ -0,0 +1,217 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/adapters/repo/memory"
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+type authzFixture struct {
+	svc          *usecase.Service
+	owner, other uuid.UUID
+	sub          *domain.Subscription
+}
+
+func newAuthzFixture(t *testing.T) authzFixture {
+	t.Helper()
+	svc := usecase.NewService(memory.NewRepos())
+	fx := authzFixture{svc: svc, owner: mustUser(t, svc), other: mustUser(t, svc)}
+	fx.sub = mustCreate(t, svc, usecase.CreateInput{ServiceName: "Netflix", Price: 500, UserID: fx.owner, StartDate: "01-2024"})
+	return fx
+}
+
+func TestSubscriptionAccess(t *testing.T) {
+	principals := map[string]func(fx authzFixture) *domain.Principal{
+		"internal": func(authzFixture) *domain.Principal { return nil },
+		"admin": func(authzFixture) *domain.Principal {
+			return &domain.Principal{Subject: "admin", Roles: []string{domain.RoleAdmin}}
+		},
+		"finance": func(authzFixture) *domain.Principal {
+			return &domain.Principal{Subject: "finance", Roles: []string{domain.RoleFinance}}
+		},
+		"owner": func(fx authzFixture) *domain.Principal { return &domain.Principal{Subject: "owner", UserID: &fx.owner} },
+		"stranger": func(fx authzFixture) *domain.Principal {
+			return &domain.Principal{Subject: "stranger", UserID: &fx.other}
+		},
+		"unlinked": func(authzFixture) *domain.Principal { return &domain.Principal{Subject: "unlinked"} },
+	}
+	ops := map[string]func(ctx context.Context, fx authzFixture) error{
+		"get": func(ctx context.Context, fx authzFixture) error {
+			_, err := fx.svc.Get(ctx, fx.sub.ID, false)
+			return err
+		},
+		"list": func(ctx context.Context, fx authzFixture) error {
+			_, err := fx.svc.List(ctx, usecase.ListFilter{UserIDs: []uuid.UUID{fx.owner}})
+			return err
+		},
+		"update": func(ctx context.Context, fx authzFixture) error {
+			price := 600
+			_, err := fx.svc.Update(ctx, fx.sub.ID, usecase.UpdateInput{Price: &price})
+			return err
+		},
+		"delete": func(ctx context.Context, fx authzFixture) error {
+			return fx.svc.Delete(ctx, fx.sub.ID, 0)
+		},
+		"summary": func(ctx context.Context, fx authzFixture) error {
+			_, err := fx.svc.Summary(ctx, usecase.SummaryInput{From: "01-2024", To: "12-2024", UserID: &fx.owner})
+			return err
+		},
+		"create": func(ctx context.Context, fx authzFixture) error {
+			_, err := fx.svc.Create(ctx, usecase.CreateInput{ServiceName: "Spotify", Price: 200, UserID: fx.owner, StartDate: "01-2024"})
+			return err
+		},
+		"get user": func(ctx context.Context, fx authzFixture) error {
+			_, err := fx.svc.GetUser(ctx, fx.owner)
+			return err
+		},
+		"list users": func(ctx context.Context, fx authzFixture) error {
+			_, err := fx.svc.ListUsers(ctx, usecase.UserFilter{})
+			return err
+		},
+		"update user": func(ctx context.Context, fx authzFixture) error {
+			_, err := fx.svc.UpdateUser(ctx, fx.owner, usecase.UserInput{Name: "Renamed"})
+			return err
+		},
+		"user subscriptions": func(ctx context.Context, fx authzFixture) error {
+			_, err := fx.svc.UserSubscriptions(ctx, fx.owner, usecase.ListFilter{})
+			return err
+		},
+		"missing user subscriptions": func(ctx context.Context, fx authzFixture) error {
+			_, err := fx.svc.UserSubscriptions(ctx, uuid.New(), usecase.ListFilter{})
+			return err
+		},
+		"delete user": func(ctx context.Context, fx authzFixture) error {
+			return fx.svc.DeleteUser(ctx, fx.owner, usecase.DeleteCascade)
+		},
+		"change team": func(ctx context.Context, fx authzFixture) error {
+			org, err := fx.svc.CreateOrg(context.Background(), usecase.OrgInput{Name: "Acme"})
+			if err != nil {
+				return err
+			}
+			team, err := fx.svc.CreateTeam(context.Background(), usecase.TeamInput{OrgID: org.ID, Name: "Finance"})
+			if err != nil {
+				return err
+			}
+			_, err = fx.svc.UpdateUser(ctx, fx.owner, usecase.UserInput{Name: "Test User", TeamID: &team.ID})
+			return err
+		},
+	}
+	tests := []struct {
+		principal string
+		op        string
+		want      error // nil — операция разрешена
+	}{
+		{"internal", "get", nil},
+		{"internal", "update", nil},
+		{"internal", "delete", nil},
+		{"admin", "get", nil},
+		{"admin", "list", nil},
+		{"admin", "update", nil},
+		{"admin", "delete", nil},
+		{"admin", "summary", nil},
+		{"admin", "create", nil},
+		{"admin", "get user", nil},
+		{"admin", "list users", nil},
+		{"admin", "update user", nil},
+		{"admin", "change team", nil},
+		{"admin", "delete user", nil},
+		{"admin", "user subscriptions", nil},
+		{"admin", "missing user subscriptions", domain.ErrNotFound},
+		{"finance", "get", nil},
+		{"finance", "list", nil},
+		{"finance", "summary", nil},
+		{"finance", "update", domain.ErrForbidden},
+		{"finance", "delete", domain.ErrForbidden},
+		{"finance", "create", domain.ErrForbidden},
+		{"finance", "get user", nil},
+		{"finance", "list users", nil},
+		{"finance", "user subscriptions", nil},
+		{"owner", "get", nil},
+		{"owner", "list", nil},
+		{"owner", "update", nil},
+		{"owner", "delete", nil},
+		{"owner", "summary", nil},
+		{"owner", "create", nil},
+		{"owner", "get user", nil},
+		{"owner", "list users", domain.ErrForbidden},
+		{"owner", "update user", nil},
+		{"owner", "change team", domain.ErrForbidden},
+		{"owner", "delete user", domain.ErrForbidden},
+		{"owner", "user subscriptions", nil},
+		{"stranger", "get", domain.ErrNotFound},
+		{"stranger", "list", domain.ErrForbidden},
+		{"stranger", "update", domain.ErrNotFound},
+		{"stranger", "delete", domain.ErrNotFound},
+		{"stranger", "summary", domain.ErrForbidden},
+		{"stranger", "create", domain.ErrForbidden},
+		{"stranger", "get user", domain.ErrNotFound},
+		{"stranger", "list users", domain.ErrForbidden},
+		{"stranger", "update user", domain.ErrForbidden},
+		{"stranger", "delete user", domain.ErrForbidden},
+		{"stranger", "user subscriptions", domain.ErrNotFound},
+		{"stranger", "missing user subscriptions", domain.ErrNotFound},
+		{"unlinked", "get", domain.ErrNotFound},
+		{"unlinked", "list", domain.ErrForbidden},
+		{"unlinked", "update", domain.ErrNotFound},
+		{"unlinked", "summary", domain.ErrForbidden},
+		{"unlinked", "create", domain.ErrForbidden},
+		{"unlinked", "get user", domain.ErrNotFound},
+		{"unlinked", "list users", domain.ErrForbidden},
+		{"unlinked", "user subscriptions", domain.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.principal+"/"+tt.op, func(t *testing.T) {
+			fx := newAuthzFixture(t)
+			ctx := context.Background()
+			if p := principals[tt.principal](fx); p != nil {
+				ctx = usecase.WithPrincipal(ctx, *p)
+			}
+			err := ops[tt.op](ctx, fx)
+			if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnfilteredReadsAreScopedToCaller(t *testing.T) {
+	fx := newAuthzFixture(t)
+	mustCreate(t, fx.svc, usecase.CreateInput{ServiceName: "Spotify", Price: 200, UserID: fx.other, StartDate: "01-2024"})
+	in := usecase.SummaryInput{From: "01-2024", To: "01-2024"}
+
+	tests := []struct {
+		name      string
+		principal domain.Principal
+		wantItems int
+		wantTotal int64
+	}{
+		{"owner", domain.Principal{Subject: "owner", UserID: &fx.owner}, 1, 500},
+		{"stranger", domain.Principal{Subject: "stranger", UserID: &fx.other}, 1, 200},
+		{"finance", domain.Principal{Subject: "finance", Roles: []string{domain.RoleFinance}}, 2, 700},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := usecase.WithPrincipal(context.Background(), tt.principal)
+			page, err := fx.svc.List(ctx, usecase.ListFilter{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(page.Items) != tt.wantItems {
+				t.Fatalf("list returned %d items, want %d", len(page.Items), tt.wantItems)
+			}
+			res, err := fx.svc.Summary(ctx, in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Total != tt.wantTotal {
+				t.Fatalf("summary = %d, want %d", res.Total, tt.wantTotal)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+)
+
+// MaxBatchSize — наибольшее число операций в одном пакете.
+const MaxBatchSize = 1000
+
+type BatchOpKind string
+
+const (
+	BatchCreate BatchOpKind = "create"
+	BatchUpdate BatchOpKind = "update"
+	BatchDelete BatchOpKind = "delete"
+)
+
+// ErrBatchAborted — операция не применена, потому что в пакете «всё или ничего» не прошла другая.
+var ErrBatchAborted = domain.Errorf(domain.ErrConflict, "batch aborted: another operation failed")
+
+// BatchChange — подготовленное изменение для SubscriptionRepo.ApplyBatch. Before — состояние,
+// из которого получено изменение (для update и delete); его Version — ожидаемая версия в хранилище.
+// After — новое состояние (для create и update); при успехе хранилище записывает в After.Version новую версию,
+// а для delete — записывает в After удалённую подписку с новой версией.
+type BatchChange struct {
+	Kind   BatchOpKind
+	Before *domain.Subscription
+	After  *domain.Subscription
+}
+
+// Batch применяет пакет операций. В режиме Atomic пакет применяется целиком или не применяется вовсе
+// (остальные операции получают ErrBatchAborted), иначе каждая операция применяется независимо.
+// Ошибка возвращается только для пакета в целом; ошибки операций — в BatchResult.Err.
+func (s *Service) Batch(ctx context.Context, in BatchInput) ([]BatchResult, error) {
+	if len(in.Ops) == 0 {
+		return nil, domain.Errorf(domain.ErrValidation, "batch is empty")
+	}
+	if len(in.Ops) > MaxBatchSize {
+		return nil, domain.Errorf(domain.ErrValidation, "batch is limited to %d operations", MaxBatchSize)
+	}
+
+	results := make([]BatchResult, len(in.Ops))
+	changes := make([]BatchChange, 0, len(in.Ops))
+	idx := make([]int, 0, len(in.Ops)) // индекс операции для каждого изменения
+	seen := make(map[uuid.UUID]bool)
+	failed := false
+	for i, op := range in.Ops {
+		ch, err := s.prepareBatchOp(ctx, op, seen)
+		if err != nil {
+			results[i].Err = err
+			failed = true
+			continue
+		}
+		changes = append(changes, ch)
+		idx = append(idx, i)
+	}
+	if failed && in.Atomic {
+		return abortRest(results), nil
+	}
+
+	if len(changes) > 0 {
+		errs, err := s.repo.ApplyBatch(ctx, changes, in.Atomic)
+		if err != nil {
+			return nil, err
+		}
+		for k := range changes {
+			if errs[k] != nil {
+				results[idx[k]].Err = errs[k]
+				failed = true
+			}
+		}
+		if failed && in.Atomic {
+			return abortRest(results), nil
+		}
+		for k, ch := range changes {
+			if errs[k] == nil {
+				results[idx[k]].Subscription = ch.After
+			}
+		}
+	}
+	return results, nil
+}
+
+func (s *Service) prepareBatchOp(ctx context.Context, op BatchOp, seen map[uuid.UUID]bool) (BatchChange, error) {
+	if op.Kind == BatchCreate {
+		sub, err := s.buildSubscription(ctx, op.Create, true)
+		return BatchChange{Kind: BatchCreate, After: sub}, err
+	}
+	if op.Kind != BatchUpdate && op.Kind != BatchDelete {
+		return BatchChange{}, domain.Errorf(domain.ErrValidation, "op must be one of: create, update, delete")
+	}
+	if seen[op.ID] {
+		return BatchChange{}, domain.Errorf(domain.ErrValidation, "subscription %s occurs more than once in the batch", op.ID)
+	}
+	seen[op.ID] = true
+	before, err := s.getLive(ctx, op.ID, true)
+	if err != nil {
+		return BatchChange{}, err
+	}
+	if err := before.CheckVersion(op.IfMatch); err != nil {
+		return BatchChange{}, err
+	}
+	if op.Kind == BatchDelete {
+		return BatchChange{Kind: BatchDelete, Before: before}, nil
+	}
+	after := *before // applyUpdate заменяет указатели, а не меняет то, на что они указывают
+	upd := op.Update
+	upd.IfMatch = before.Version
+	if err := applyUpdate(&after, upd); err != nil {
+		return BatchChange{}, err
+	}
+	if upd.ServiceName != nil {
+		if err := s.attachService(ctx, &after, true); err != nil {
+			return BatchChange{}, err
+		}
+	}
+	return BatchChange{Kind: BatchUpdate, Before: before, After: &after}, nil
+}
+
+// abortRest помечает ещё не завершившиеся с ошибкой операции как отменённые.
+func abortRest(results []BatchResult) []BatchResult {
+	for i := range results {
+		if results[i].Err == nil {
+			results[i].Err = ErrBatchAborted
+		}
+	}
+	return results
+}
+
+// DTOs
+
+type BatchInput struct {
+	Atomic bool
+	Ops    []BatchOp
+}
+
+// BatchOp — одна операция пакета: для create используется Create, для update — ID и Update,
+// для delete — ID. IfMatch — ожидаемая версия для update и delete (0 — любая).
+type BatchOp struct {
+	Kind    BatchOpKind
+	ID      uuid.UUID
+	IfMatch int64
+	Create  CreateInput
+	Update  UpdateInput
+}
+
+// BatchResult — итог операции: применённое состояние подписки (для delete — последнее перед удалением) или ошибка.
+type BatchResult struct {
+	Subscription *domain.Subscription
+	Err          error
+}
@@ -0,0 +1,177 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+)
+
+// ServiceRepo хранит каталоги сервисов арендаторов; все методы видят только каталог арендатора
+// вызова (TenantFrom). Ключи поиска (domain.Service.Keys) уникальны в каталоге: Create и Update
+// возвращают ErrConflict, если название или псевдоним уже занят другим сервисом.
+type ServiceRepo interface {
+	// Create сохраняет сервис; сервис другого арендатора сохранить нельзя (domain.ErrForbidden).
+	Create(ctx context.Context, svc *domain.Service) error
+	Get(ctx context.Context, id uuid.UUID) (*domain.Service, error)
+	// FindByKey ищет сервис по ключу domain.NormalizeServiceName среди названий и псевдонимов.
+	FindByKey(ctx context.Context, key string) (*domain.Service, error)
+	Update(ctx context.Context, svc *domain.Service) error
+	// Delete удаляет сервис; если на него ссылаются подписки — ErrConflict.
+	Delete(ctx context.Context, id uuid.UUID) error
+	// List возвращает каталог по названию.
+	List(ctx context.Context) ([]*domain.Service, error)
+}
+
+func (s *Service) CreateService(ctx context.Context, in ServiceInput) (*domain.Service, error) {
+	if err := requireRole(ctx, domain.RoleAdmin); err != nil {
+		return nil, err
+	}
+	return s.createService(ctx, in)
+}
+
+// createService добавляет сервис в каталог без проверки роли: неизвестный сервис добавляется
+// и при создании подписки обычным пользователем.
+func (s *Service) createService(ctx context.Context, in ServiceInput) (*domain.Service, error) {
+	now := time.Now().UTC()
+	svc := &domain.Service{ID: uuid.New(), TenantID: TenantFrom(ctx), CreatedAt: now}
+	if err := in.apply(svc, now); err != nil {
+		return nil, err
+	}
+	if err := s.services.Create(ctx, svc); err != nil {
+		return nil, err
+	}
+	return svc, nil
+}
+
+func (s *Service) GetService(ctx context.Context, id uuid.UUID) (*domain.Service, error) {
+	return s.services.Get(ctx, id)
+}
+
+func (s *Service) ListServices(ctx context.Context) ([]*domain.Service, error) {
+	return s.services.List(ctx)
+}
+
+// UpdateService заменяет описание сервиса. Новое каноническое название получают и его подписки;
+// сервис и подписки принадлежат арендатору вызова.
+func (s *Service) UpdateService(ctx context.Context, id uuid.UUID, in ServiceInput) (*domain.Service, error) {
+	if err := requireRole(ctx, domain.RoleAdmin); err != nil {
+		return nil, err
+	}
+	svc, err := s.services.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	oldName := svc.Name
+	if err := in.apply(svc, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	if err := s.services.Update(ctx, svc); err != nil {
+		return nil, err
+	}
+	if svc.Name != oldName {
+		if _, err := s.repo.RenameService(ctx, svc.ID, svc.Name); err != nil {
+			return nil, err
+		}
+	}
+	return svc, nil
+}
+
+// DeleteService удаляет сервис, на который не ссылается ни одна подписка, в том числе удалённая.
+func (s *Service) DeleteService(ctx context.Context, id uuid.UUID) error {
+	if err := requireRole(ctx, domain.RoleAdmin); err != nil {
+		return err
+	}
+	n, err := s.repo.Count(ctx, ListFilter{ServiceID: &id, IncludeDeleted: true})
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return domain.Errorf(domain.ErrConflict, "service is used by %d subscriptions", n)
+	}
+	return s.services.Delete(ctx, id)
+}
+
+// buildSubscription проверяет владельца, собирает новую подписку и связывает её с каталогом:
+// по in.ServiceID, если он задан, иначе по названию. С create неизвестное название добавляется
+// в каталог, без него подписка остаётся без ServiceID (для проверки без сохранения).
+func (s *Service) buildSubscription(ctx context.Context, in CreateInput, create bool) (*domain.Subscription, error) {
+	if err := authorizeUser(ctx, in.UserID, true); err != nil {
+		return nil, err
+	}
+	if err := s.requireUser(ctx, in.UserID); err != nil {
+		return nil, err
+	}
+	var svc *domain.Service
+	if in.ServiceID != nil {
+		var err error
+		if svc, err = s.services.Get(ctx, *in.ServiceID); err != nil {
+			return nil, err
+		}
+		in.ServiceName = svc.Name
+	}
+	sub, err := newSubscription(in)
+	if err != nil {
+		return nil, err
+	}
+	sub.TenantID = TenantFrom(ctx)
+	if svc != nil {
+		sub.ServiceID = svc.ID
+		return sub, nil
+	}
+	if err := s.attachService(ctx, sub, create); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// attachService находит сервис по sub.ServiceName и заменяет название каноническим.
+func (s *Service) attachService(ctx context.Context, sub *domain.Subscription, create bool) error {
+	key := domain.NormalizeServiceName(sub.ServiceName)
+	svc, err := s.services.FindByKey(ctx, key)
+	if errors.Is(err, domain.ErrNotFound) {
+		if !create {
+			return nil
+		}
+		svc, err = s.createService(ctx, ServiceInput{Name: sub.ServiceName})
+		if errors.Is(err, domain.ErrConflict) { // сервис с таким названием успели создать параллельно
+			svc, err = s.services.FindByKey(ctx, key)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	sub.ServiceID, sub.ServiceName = svc.ID, svc.Name
+	return nil
+}
+
+// DTOs
+
+type ServiceInput struct {
+	Name         string
+	Aliases      []string
+	Category     string
+	DefaultPrice *int
+	Currency     string // валюта DefaultPrice, по умолчанию RUB
+}
+
+func (in ServiceInput) apply(svc *domain.Service, now time.Time) error {
+	svc.Name = in.Name
+	svc.Aliases = append([]string(nil), in.Aliases...)
+	svc.Category = in.Category
+	svc.DefaultPrice = in.DefaultPrice
+	svc.Currency = domain.DefaultCurrency
+	if in.Currency != "" {
+		c, err := domain.ParseCurrency(in.Currency)
+		if err != nil {
+			return err
+		}
+		svc.Currency = c
+	}
+	svc.UpdatedAt = now
+	svc.Normalize()
+	return svc.Validate()
+}
@@ -0,0 +1,108 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+)
+
+// importChunk — сколько строк импорта применяется одним пакетом (см. SubscriptionRepo.ApplyBatch).
+const importChunk = 500
+
+// MaxImportErrors — сколько ошибок строк попадает в отчёт; остальные только учитываются в Failed.
+const MaxImportErrors = 1000
+
+// ImportSource отдаёт строки импорта по одной и возвращает io.EOF в конце данных.
+// Ошибка категории domain.ErrValidation относится к одной строке (Line должен быть заполнен),
+// и импорт продолжается; любая другая ошибка прерывает импорт.
+type ImportSource interface {
+	Next() (ImportRow, error)
+}
+
+type ImportRow struct {
+	Line  int // номер строки в исходном файле
+	Input CreateInput
+}
+
+// ImportError — ошибка одной строки импорта.
+type ImportError struct {
+	Line int
+	Err  error
+}
+
+type ImportReport struct {
+	Rows     int
+	Imported int // при DryRun — число строк, прошедших проверку
+	Failed   int
+	Errors   []ImportError
+}
+
+// Import создаёт подписки из src, читая его потоком и применяя строки пакетами по importChunk.
+// Строки с ошибками пропускаются и попадают в отчёт. Неизвестные сервисы добавляются в каталог.
+// С dryRun строки только проверяются, а каталог не меняется.
+func (s *Service) Import(ctx context.Context, src ImportSource, dryRun bool) (ImportReport, error) {
+	if err := requireRole(ctx, domain.RoleAdmin); err != nil {
+		return ImportReport{}, err
+	}
+	var rep ImportReport
+	changes := make([]BatchChange, 0, importChunk)
+	lines := make([]int, 0, importChunk)
+	flush := func() error {
+		if len(changes) == 0 {
+			return nil
+		}
+		if dryRun {
+			rep.Imported += len(changes)
+		} else {
+			errs, err := s.repo.ApplyBatch(ctx, changes, false)
+			if err != nil {
+				return err
+			}
+			for i, err := range errs {
+				if err != nil {
+					rep.addError(lines[i], err)
+				} else {
+					rep.Imported++
+				}
+			}
+		}
+		changes, lines = changes[:0], lines[:0]
+		return nil
+	}
+
+	for {
+		row, err := src.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil && !errors.Is(err, domain.ErrValidation) {
+			return rep, err
+		}
+		rep.Rows++
+		if err == nil {
+			var sub *domain.Subscription
+			if sub, err = s.buildSubscription(ctx, row.Input, !dryRun); err == nil {
+				changes = append(changes, BatchChange{Kind: BatchCreate, After: sub})
+				lines = append(lines, row.Line)
+			}
+		}
+		if err != nil {
+			rep.addError(row.Line, err)
+		}
+		if len(changes) == importChunk {
+			if err := flush(); err != nil {
+				return rep, err
+			}
+		}
+	}
+	return rep, flush()
+}
+
+func (r *ImportReport) addError(line int, err error) {
+	r.Failed++
+	if len(r.Errors) < MaxImportErrors {
+		r.Errors = append(r.Errors, ImportError{Line: line, Err: err})
+	}
+}
@@ -0,0 +1,194 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+)
+
+// MaxListLimit — наибольший размер страницы списка; он же размер по умолчанию.
+const MaxListLimit = 100
+
+// SortField — поле, по которому упорядочивается список.
+type SortField string
+
+const (
+	SortCreatedAt   SortField = "created_at"
+	SortPrice       SortField = "price"
+	SortStartDate   SortField = "start_date"
+	SortServiceName SortField = "service_name"
+)
+
+// ListSort — порядок списка. Подписки с равным значением поля упорядочиваются по ID в том же направлении.
+type ListSort struct {
+	Field SortField
+	Desc  bool
+}
+
+// DefaultListSort — порядок по умолчанию: сначала новые.
+var DefaultListSort = ListSort{Field: SortCreatedAt, Desc: true}
+
+// ParseListSort разбирает «поле» или «-поле» (по убыванию); пустая строка — DefaultListSort.
+func ParseListSort(s string) (ListSort, error) {
+	if s == "" {
+		return DefaultListSort, nil
+	}
+	sort := ListSort{Field: SortField(strings.TrimPrefix(s, "-")), Desc: strings.HasPrefix(s, "-")}
+	switch sort.Field {
+	case SortCreatedAt, SortPrice, SortStartDate, SortServiceName:
+		return sort, nil
+	}
+	return ListSort{}, domain.Errorf(domain.ErrValidation, "sort must be one of: created_at, price, start_date, service_name, optionally prefixed with -")
+}
+
+// OrDefault возвращает DefaultListSort для незаданного порядка.
+func (s ListSort) OrDefault() ListSort {
+	if s.Field == "" {
+		return DefaultListSort
+	}
+	return s
+}
+
+func (s ListSort) String() string {
+	if s.Desc {
+		return "-" + string(s.Field)
+	}
+	return string(s.Field)
+}
+
+// ListCursor — позиция в списке: страница начинается с подписок, идущих в порядке Sort после
+// последней подписки предыдущей страницы с ключом (значение поля сортировки, ID). В отличие от
+// смещения, вставки между запросами страниц не приводят к пропускам и повторам.
+type ListCursor struct {
+	Sort ListSort
+	// Key — значение поля сортировки: time.Time для created_at и start_date, int для price, string для service_name.
+	Key any
+	ID  uuid.UUID
+}
+
+// CursorAfter возвращает курсор, указывающий на место сразу после s в порядке sort.
+func CursorAfter(sort ListSort, s *domain.Subscription) ListCursor {
+	return ListCursor{Sort: sort, Key: SortKey(sort.Field, s), ID: s.ID}
+}
+
+// SortKey — значение поля сортировки подписки в том виде, в каком оно хранится.
+func SortKey(f SortField, s *domain.Subscription) any {
+	switch f {
+	case SortPrice:
+		return s.Price
+	case SortStartDate:
+		return s.FirstDay().Time()
+	case SortServiceName:
+		return s.ServiceName
+	default:
+		return s.CreatedAt
+	}
+}
+
+type cursorToken struct {
+	Sort string          `json:"s"`
+	Key  json.RawMessage `json:"k"`
+	ID   uuid.UUID       `json:"i"`
+}
+
+// Encode возвращает непрозрачный токен курсора для клиента.
+func (c ListCursor) Encode() string {
+	key, _ := json.Marshal(c.Key)
+	b, _ := json.Marshal(cursorToken{Sort: c.Sort.String(), Key: key, ID: c.ID})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor разбирает токен, выданный ListCursor.Encode.
+func DecodeCursor(token string) (*ListCursor, error) {
+	invalid := domain.Errorf(domain.ErrValidation, "invalid cursor")
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, invalid
+	}
+	var t cursorToken
+	if err := json.Unmarshal(b, &t); err != nil || t.ID == uuid.Nil || t.Sort == "" {
+		return nil, invalid
+	}
+	c := ListCursor{ID: t.ID}
+	if c.Sort, err = ParseListSort(t.Sort); err != nil {
+		return nil, invalid
+	}
+	switch c.Sort.Field {
+	case SortPrice:
+		var v int
+		err = json.Unmarshal(t.Key, &v)
+		c.Key = v
+	case SortServiceName:
+		var v string
+		err = json.Unmarshal(t.Key, &v)
+		c.Key = v
+	default:
+		var v time.Time
+		err = json.Unmarshal(t.Key, &v)
+		c.Key = v
+	}
+	if err != nil {
+		return nil, invalid
+	}
+	return &c, nil
+}
+
+// ListPage — страница списка. NextCursor пуст на последней странице; Total заполняется,
+// только если запрошен ListFilter.WithTotal.
+type ListPage struct {
+	Items      []*domain.Subscription
+	Limit      int
+	NextCursor string
+	Total      *int
+}
+
+// List возвращает страницу подписок. Страница задаётся курсором или, для совместимости, смещением.
+// Вызывающий без права читать все подписки видит только подписки своего пользователя.
+func (s *Service) List(ctx context.Context, f ListFilter) (ListPage, error) {
+	var err error
+	if f.UserIDs, err = scopeUserIDs(ctx, f.UserIDs); err != nil {
+		return ListPage{}, err
+	}
+	f.Sort = f.Sort.OrDefault()
+	if f.Cursor != nil {
+		if f.Offset > 0 {
+			return ListPage{}, domain.Errorf(domain.ErrValidation, "cursor and offset cannot be used together")
+		}
+		if f.Cursor.Sort != f.Sort {
+			return ListPage{}, domain.Errorf(domain.ErrValidation, "cursor was issued for sort=%s", f.Cursor.Sort)
+		}
+	}
+	if f.Limit <= 0 || f.Limit > MaxListLimit {
+		f.Limit = MaxListLimit
+	}
+	page := ListPage{Limit: f.Limit}
+
+	// Лишняя строка показывает, есть ли следующая страница.
+	q := f
+	q.Limit++
+	items, err := s.repo.List(ctx, q)
+	if err != nil {
+		return ListPage{}, err
+	}
+	if len(items) > f.Limit {
+		items = items[:f.Limit]
+		last := items[len(items)-1]
+		page.NextCursor = CursorAfter(f.Sort, last).Encode()
+	}
+	page.Items = items
+
+	if f.WithTotal {
+		n, err := s.repo.Count(ctx, f)
+		if err != nil {
+			return ListPage{}, err
+		}
+		page.Total = &n
+	}
+	return page, nil
+}
@@ -0,0 +1,60 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+)
+
+// ChangePrice задаёт новую цену подписки с месяца EffectiveFrom, не затрагивая предыдущие месяцы.
+func (s *Service) ChangePrice(ctx context.Context, id uuid.UUID, in PriceChangeInput) (*domain.PriceChange, error) {
+	sub, err := s.getLive(ctx, id, true)
+	if err != nil {
+		return nil, err
+	}
+	from, err := domain.ParseYearMonth(in.EffectiveFrom)
+	if err != nil {
+		return nil, err
+	}
+	pc := &domain.PriceChange{SubscriptionID: id, EffectiveFrom: from, Price: in.Price, CreatedAt: time.Now().UTC()}
+	if err := sub.ValidatePriceChange(pc); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SavePriceChange(ctx, pc); err != nil {
+		return nil, err
+	}
+	return pc, nil
+}
+
+// PriceSchedule возвращает график цен подписки, начиная с исходной цены.
+func (s *Service) PriceSchedule(ctx context.Context, id uuid.UUID) ([]domain.PriceChange, error) {
+	sub, err := s.getLive(ctx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	if sub.PriceChanges, err = s.repo.ListPriceChanges(ctx, id); err != nil {
+		return nil, err
+	}
+	return sub.PriceSchedule(), nil
+}
+
+func (s *Service) DeletePriceChange(ctx context.Context, id uuid.UUID, effectiveFrom string) error {
+	from, err := domain.ParseYearMonth(effectiveFrom)
+	if err != nil {
+		return err
+	}
+	if _, err := s.getLive(ctx, id, true); err != nil {
+		return err
+	}
+	return s.repo.DeletePriceChange(ctx, id, from)
+}
+
+// DTOs
+
+type PriceChangeInput struct {
+	Price         int
+	EffectiveFrom string // MM-YYYY
+}
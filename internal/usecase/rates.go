@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+)
+
+// ExchangeRateProvider отдаёт курс from→to, действовавший в месяце month.
+type ExchangeRateProvider interface {
+	Rate(ctx context.Context, from, to domain.Currency, month domain.YearMonth) (*big.Rat, error)
+}
+
+// ExchangeRateRepo — хранилище курсов, одновременно служащее провайдером.
+// Если на месяц курса нет, используется последний известный до него; обратный курс выводится из прямого.
+type ExchangeRateRepo interface {
+	ExchangeRateProvider
+	SaveRates(ctx context.Context, rates []domain.ExchangeRate) error
+}
+
+// StaticRates — фиксированные курсы без привязки к месяцу, выраженные в единицах Base. Удобны в тестах.
+type StaticRates struct {
+	Base  domain.Currency
+	Rates map[domain.Currency]*big.Rat
+}
+
+func (s StaticRates) Rate(_ context.Context, from, to domain.Currency, _ domain.YearMonth) (*big.Rat, error) {
+	f, err := s.value(from)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.value(to)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Rat).Quo(f, t), nil
+}
+
+func (s StaticRates) value(c domain.Currency) (*big.Rat, error) {
+	if c == s.Base {
+		return big.NewRat(1, 1), nil
+	}
+	if v, ok := s.Rates[c]; ok && v.Sign() > 0 {
+		return v, nil
+	}
+	return nil, domain.Errorf(domain.ErrValidation, "no exchange rate %s/%s", c, s.Base)
+}
+
+// ErrNoRate — ошибка реализаций ExchangeRateRepo, когда курса на месяц нет.
+func ErrNoRate(from, to domain.Currency, month domain.YearMonth) error {
+	return domain.Errorf(domain.ErrValidation, "no exchange rate %s/%s for %s", from, to, month)
+}
+
+// UploadRates проверяет и сохраняет курсы; существующие курсы на те же месяцы перезаписываются.
+func (s *Service) UploadRates(ctx context.Context, in []RateInput) (int, error) {
+	if err := requireRole(ctx, domain.RoleAdmin); err != nil {
+		return 0, err
+	}
+	if s.rateRepo == nil {
+		return 0, domain.Errorf(domain.ErrUnavailable, "exchange rates storage is not configured")
+	}
+	rates := make([]domain.ExchangeRate, 0, len(in))
+	for i, ri := range in {
+		r, err := ri.rate()
+		if err != nil {
+			return 0, domain.Errorf(domain.ErrValidation, "rate #%d: %w", i+1, err)
+		}
+		rates = append(rates, r)
+	}
+	if len(rates) == 0 {
+		return 0, domain.Errorf(domain.ErrValidation, "no rates to upload")
+	}
+	if err := s.rateRepo.SaveRates(ctx, rates); err != nil {
+		return 0, err
+	}
+	return len(rates), nil
+}
+
+// converter переводит суммы в целевую валюту, кешируя курсы в пределах одного расчёта.
+type converter struct {
+	rates  ExchangeRateProvider
+	target domain.Currency
+	cache  map[rateKey]*big.Rat
+}
+
+type rateKey struct {
+	from  domain.Currency
+	month string
+}
+
+func (s *Service) newConverter(target domain.Currency) (*converter, error) {
+	if s.rates == nil {
+		return nil, domain.Errorf(domain.ErrUnavailable, "exchange rates are not configured")
+	}
+	return &converter{rates: s.rates, target: target, cache: make(map[rateKey]*big.Rat)}, nil
+}
+
+func (c *converter) convert(ctx context.Context, amount int64, from domain.Currency, month domain.YearMonth) (*big.Rat, error) {
+	v := new(big.Rat).SetInt64(amount)
+	if from == c.target || amount == 0 {
+		return v, nil
+	}
+	k := rateKey{from: from, month: month.String()}
+	rate, ok := c.cache[k]
+	if !ok {
+		var err error
+		if rate, err = c.rates.Rate(ctx, from, c.target, month); err != nil {
+			return nil, err
+		}
+		c.cache[k] = rate
+	}
+	return v.Mul(v, rate), nil
+}
+
+// DTOs
+
+type RateInput struct {
+	From  string
+	To    string
+	Month string // MM-YYYY
+	Rate  string // десятичная дробь, например "92.35"
+}
+
+func (in RateInput) rate() (domain.ExchangeRate, error) {
+	from, err := domain.ParseCurrency(in.From)
+	if err != nil {
+		return domain.ExchangeRate{}, err
+	}
+	to, err := domain.ParseCurrency(in.To)
+	if err != nil {
+		return domain.ExchangeRate{}, err
+	}
+	month, err := domain.ParseYearMonth(in.Month)
+	if err != nil {
+		return domain.ExchangeRate{}, err
+	}
+	v, ok := new(big.Rat).SetString(in.Rate)
+	if !ok {
+		return domain.ExchangeRate{}, domain.Errorf(domain.ErrValidation, "invalid rate %q", in.Rate)
+	}
+	r := domain.ExchangeRate{From: from, To: to, Month: month, Rate: v}
+	if err := r.Validate(); err != nil {
+		return domain.ExchangeRate{}, err
+	}
+	return r, nil
+}
@@ -2,143 +2,355 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/oziev02/subscriptions-service/internal/domain"
 )
 
+// SubscriptionRepo хранит подписки. Create, Update, Delete, Restore, Purge и изменения графика цен
+// в той же транзакции пишут запись в журнал аудита (см. NewAuditRecord).
+//
+// Все методы, кроме Purge, видят и меняют только подписки арендатора вызова
+// (TenantFrom): подписки других арендаторов для них не существуют.
 type SubscriptionRepo interface {
+	// Create сохраняет подписку с версией 1 и записывает её в s.Version. Подписку другого
+	// арендатора сохранить нельзя (domain.ErrForbidden).
 	Create(ctx context.Context, s *domain.Subscription) error
+	// Get возвращает подписку, в том числе удалённую (с DeletedAt).
 	Get(ctx context.Context, id uuid.UUID) (*domain.Subscription, error)
+	// Update меняет только неудалённую подписку, если её версия в хранилище всё ещё s.Version
+	// (иначе domain.ErrVersionMismatch), и записывает в s.Version новую версию. Проверка атомарна.
 	Update(ctx context.Context, s *domain.Subscription) error
-	Delete(ctx context.Context, id uuid.UUID) error
+	// Delete помечает подписку удалённой, если её версия равна version (0 — любая);
+	// повторное удаление — ErrNotFound.
+	Delete(ctx context.Context, id uuid.UUID, version int64) error
+	// Restore снимает пометку об удалении; для неудалённой подписки — ErrConflict.
+	Restore(ctx context.Context, id uuid.UUID) (*domain.Subscription, error)
+	// Purge окончательно удаляет подписки всех арендаторов, удалённые раньше deletedBefore,
+	// и возвращает их количество.
+	Purge(ctx context.Context, deletedBefore time.Time) (int, error)
+	// ApplyBatch применяет подготовленные изменения, включая журнал аудита, и возвращает ошибку
+	// для каждого изменения. С atomic при любой ошибке не применяется ни одно изменение.
+	ApplyBatch(ctx context.Context, changes []BatchChange, atomic bool) ([]error, error)
+	// List возвращает до filter.Limit подписок (0 — MaxListLimit) в порядке filter.Sort,
+	// начиная после filter.Cursor или пропустив filter.Offset.
 	List(ctx context.Context, filter ListFilter) ([]*domain.Subscription, error)
-	Summary(ctx context.Context, from, to domain.YearMonth, userID *uuid.UUID, serviceName *string) (int64, error)
+	// RenameService записывает новое название сервиса во все его подписки, включая удалённые, как их
+	// изменение (с новой версией и записью в журнале) и возвращает число подписок.
+	RenameService(ctx context.Context, serviceID uuid.UUID, name string) (int, error)
+	// Count возвращает число подписок, подходящих под фильтр, без учёта страницы.
+	Count(ctx context.Context, filter ListFilter) (int, error)
+	// Export передаёт в fn по очереди все подписки, подходящие под фильтр (без Limit и Offset),
+	// в порядке List, не загружая их в память целиком. Ошибка fn прерывает выгрузку и возвращается.
+	Export(ctx context.Context, filter ListFilter, fn func(*domain.Subscription) error) error
+	Summary(ctx context.Context, f SummaryFilter) (int64, error)
+	MonthlySummary(ctx context.Context, f SummaryFilter) ([]MonthlyCost, error)
+	GroupedSummary(ctx context.Context, f SummaryFilter, groupBy SummaryGroupBy, limit int) ([]GroupCost, error)
+	// SummaryCurrencies возвращает валюты подписок, попадающих в период с учётом фильтров.
+	SummaryCurrencies(ctx context.Context, f SummaryFilter) ([]domain.Currency, error)
+
+	// SavePriceChange добавляет изменение цены или заменяет изменение с тем же EffectiveFrom.
+	// Изменения графика цен меняют только неудалённые подписки и увеличивают их версию.
+	SavePriceChange(ctx context.Context, pc *domain.PriceChange) error
+	ListPriceChanges(ctx context.Context, subscriptionID uuid.UUID) ([]domain.PriceChange, error)
+	DeletePriceChange(ctx context.Context, subscriptionID uuid.UUID, effectiveFrom domain.YearMonth) error
+
+	// History возвращает записи журнала аудита подписки по возрастанию ID.
+	History(ctx context.Context, subscriptionID uuid.UUID) ([]domain.AuditRecord, error)
 }
 
+// ListFilter — условия списка и выгрузки. Все заданные условия должны выполняться одновременно;
+// границы диапазонов включительные, кроме CreatedTo.
 type ListFilter struct {
-	UserID      *uuid.UUID
-	ServiceName *string
-	Limit       int
-	Offset      int
+	UserIDs          []uuid.UUID // любая из
+	ServiceName      *string     // подстрока без учёта регистра
+	ServiceNameExact *string
+	ServiceID        *uuid.UUID
+	PriceMin         *int
+	PriceMax         *int
+	StartFrom        *domain.Date
+	StartTo          *domain.Date
+	EndFrom          *domain.Date // бессрочные подписки не подходят под условия на окончание
+	EndTo            *domain.Date
+	ActiveIn         *domain.YearMonth
+	OpenEnded        bool // только бессрочные
+	CreatedFrom      *time.Time
+	CreatedTo        *time.Time // не включая
+
+	Sort   ListSort // по умолчанию DefaultListSort
+	Limit  int
+	Offset int
+	Cursor *ListCursor
+	// WithTotal — посчитать общее число подписок под фильтром.
+	WithTotal bool
+	// IncludeDeleted — показывать и удалённые подписки.
+	IncludeDeleted bool
+}
+
+// Repos — хранилища, с которыми работает сервис.
+type Repos struct {
+	Subscriptions SubscriptionRepo
+	Services      ServiceRepo
+	Users         UserRepo
+	Orgs          OrgRepo
+	Teams         TeamRepo
+	APIKeys       APIKeyRepo
+	Rates         ExchangeRateRepo
+	// RateProvider, если задан, используется для конвертации вместо Rates (например, StaticRates в тестах).
+	RateProvider ExchangeRateProvider
 }
 
 type Service struct {
-	repo SubscriptionRepo
+	repo     SubscriptionRepo
+	services ServiceRepo
+	users    UserRepo
+	orgs     OrgRepo
+	teams    TeamRepo
+	apiKeys  APIKeyRepo
+	rateRepo ExchangeRateRepo
+	rates    ExchangeRateProvider
 }
 
-func NewService(r SubscriptionRepo) *Service { return &Service{repo: r} }
+func NewService(r Repos) *Service {
+	s := &Service{repo: r.Subscriptions, services: r.Services, users: r.Users, orgs: r.Orgs, teams: r.Teams, apiKeys: r.APIKeys, rateRepo: r.Rates, rates: r.RateProvider}
+	if s.rates == nil && r.Rates != nil {
+		s.rates = r.Rates
+	}
+	return s
+}
 
 func (s *Service) Create(ctx context.Context, in CreateInput) (*domain.Subscription, error) {
-	start, err := domain.ParseYearMonth(in.StartDate)
+	sub, err := s.buildSubscription(ctx, in, true)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.repo.Create(ctx, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// newSubscription собирает и проверяет новую подписку по вводу.
+func newSubscription(in CreateInput) (*domain.Subscription, error) {
+	start, startDate, err := domain.ParseDateOrYearMonth(in.StartDate)
+	if err != nil {
+		return nil, err
+	}
+	currency := domain.DefaultCurrency
+	if in.Currency != "" {
+		if currency, err = domain.ParseCurrency(in.Currency); err != nil {
+			return nil, err
+		}
+	}
+	period := domain.DefaultBillingPeriod
+	if in.BillingPeriod != "" {
+		if period, err = domain.ParseBillingPeriod(in.BillingPeriod); err != nil {
+			return nil, err
+		}
+	}
 	var endPtr *domain.YearMonth
+	var endDate *domain.Date
 	if in.EndDate != nil && *in.EndDate != "" {
-		e, err := domain.ParseYearMonth(*in.EndDate)
+		e, d, err := domain.ParseDateOrYearMonth(*in.EndDate)
 		if err != nil {
 			return nil, err
 		}
-		endPtr = &e
+		endPtr, endDate = &e, d
 	}
 	sub := &domain.Subscription{
-		ID:          uuid.New(),
-		ServiceName: in.ServiceName,
-		Price:       in.Price,
-		UserID:      in.UserID,
-		Start:       start,
-		End:         endPtr,
-		CreatedAt:   time.Now().UTC(),
-		UpdatedAt:   time.Now().UTC(),
+		ID:            uuid.New(),
+		ServiceName:   in.ServiceName,
+		Price:         in.Price,
+		Currency:      currency,
+		BillingPeriod: period,
+		UserID:        in.UserID,
+		Start:         start,
+		End:           endPtr,
+		StartDate:     startDate,
+		EndDate:       endDate,
+		CreatedAt:     time.Now().UTC(),
+		UpdatedAt:     time.Now().UTC(),
 	}
+	sub.NormalizeDays()
 	if err := sub.Validate(); err != nil {
 		return nil, err
 	}
-	if err := s.repo.Create(ctx, sub); err != nil {
+	return sub, nil
+}
+
+// Get возвращает подписку; удалённая находится только с includeDeleted.
+func (s *Service) Get(ctx context.Context, id uuid.UUID, includeDeleted bool) (*domain.Subscription, error) {
+	if !includeDeleted {
+		return s.getLive(ctx, id, false)
+	}
+	sub, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := authorizeSubscription(ctx, sub, false); err != nil {
 		return nil, err
 	}
 	return sub, nil
 }
 
-func (s *Service) Get(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
-	return s.repo.Get(ctx, id)
+// getLive возвращает неудалённую подписку, доступную вызывающему (для изменения, если write);
+// удалённая для изменений считается несуществующей.
+func (s *Service) getLive(ctx context.Context, id uuid.UUID, write bool) (*domain.Subscription, error) {
+	sub, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sub.Deleted() {
+		return nil, domain.Errorf(domain.ErrNotFound, "subscription not found")
+	}
+	if err := authorizeSubscription(ctx, sub, write); err != nil {
+		return nil, err
+	}
+	return sub, nil
 }
 
+// updateAttempts — сколько раз Update без IfMatch перечитывает подписку при параллельном изменении.
+const updateAttempts = 3
+
+// Update применяет изменения к текущему состоянию подписки. С IfMatch изменение отклоняется,
+// если подписка уже не той версии; без него изменение повторяется поверх свежего состояния.
 func (s *Service) Update(ctx context.Context, id uuid.UUID, in UpdateInput) (*domain.Subscription, error) {
-	sub, err := s.repo.Get(ctx, id)
+	for attempt := 1; ; attempt++ {
+		sub, err := s.update(ctx, id, in)
+		if errors.Is(err, domain.ErrPrecondition) && in.IfMatch == 0 && attempt < updateAttempts {
+			continue
+		}
+		return sub, err
+	}
+}
+
+func (s *Service) update(ctx context.Context, id uuid.UUID, in UpdateInput) (*domain.Subscription, error) {
+	sub, err := s.getLive(ctx, id, true)
 	if err != nil {
 		return nil, err
 	}
+	if err := applyUpdate(sub, in); err != nil {
+		return nil, err
+	}
+	if in.ServiceName != nil {
+		if err := s.attachService(ctx, sub, true); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.repo.Update(ctx, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// applyUpdate проверяет версию и применяет изменения к sub; sub.Version остаётся прочитанной версией.
+func applyUpdate(sub *domain.Subscription, in UpdateInput) error {
+	if err := sub.CheckVersion(in.IfMatch); err != nil {
+		return err
+	}
 	if in.ServiceName != nil {
 		sub.ServiceName = *in.ServiceName
 	}
 	if in.Price != nil {
 		sub.Price = *in.Price
 	}
+	if in.Currency != nil {
+		c, err := domain.ParseCurrency(*in.Currency)
+		if err != nil {
+			return err
+		}
+		sub.Currency = c
+	}
+	if in.BillingPeriod != nil {
+		p, err := domain.ParseBillingPeriod(*in.BillingPeriod)
+		if err != nil {
+			return err
+		}
+		sub.BillingPeriod = p
+	}
 	if in.StartDate != nil {
-		st, err := domain.ParseYearMonth(*in.StartDate)
+		st, d, err := domain.ParseDateOrYearMonth(*in.StartDate)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		sub.Start = st
+		sub.Start, sub.StartDate = st, d
 	}
 	if in.EndDateSet {
 		if in.EndDate == nil || *in.EndDate == "" {
-			sub.End = nil
+			sub.End, sub.EndDate = nil, nil
 		} else {
-			e, err := domain.ParseYearMonth(*in.EndDate)
+			e, d, err := domain.ParseDateOrYearMonth(*in.EndDate)
 			if err != nil {
-				return nil, err
+				return err
 			}
-			sub.End = &e
+			sub.End, sub.EndDate = &e, d
 		}
 	}
+	sub.NormalizeDays()
 	sub.UpdatedAt = time.Now().UTC()
-	if err := sub.Validate(); err != nil {
-		return nil, err
-	}
-	if err := s.repo.Update(ctx, sub); err != nil {
-		return nil, err
-	}
-	return sub, nil
+	return sub.Validate()
 }
 
-func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
-	return s.repo.Delete(ctx, id)
+// Delete удаляет подписку с возможностью восстановления до окончательного удаления (см. PurgeDeleted).
+// ifMatch, если не 0, — версия, которую видел вызывающий.
+func (s *Service) Delete(ctx context.Context, id uuid.UUID, ifMatch int64) error {
+	if err := s.authorize(ctx, id, true); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, id, ifMatch)
 }
 
-func (s *Service) List(ctx context.Context, f ListFilter) ([]*domain.Subscription, error) {
-	return s.repo.List(ctx, f)
+func (s *Service) Restore(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
+	if err := s.authorize(ctx, id, true); err != nil {
+		return nil, err
+	}
+	return s.repo.Restore(ctx, id)
 }
 
-func (s *Service) Summary(ctx context.Context, fromStr, toStr string, userID *uuid.UUID, serviceName *string) (int64, error) {
-	from, err := domain.ParseYearMonth(fromStr)
-	if err != nil {
+// PurgeDeleted окончательно удаляет подписки, удалённые больше retention назад.
+func (s *Service) PurgeDeleted(ctx context.Context, retention time.Duration) (int, error) {
+	if err := requireRole(ctx, domain.RoleAdmin); err != nil {
 		return 0, err
 	}
-	to, err := domain.ParseYearMonth(toStr)
-	if err != nil {
-		return 0, err
+	if retention < 0 {
+		return 0, domain.Errorf(domain.ErrValidation, "retention must be >= 0")
+	}
+	return s.repo.Purge(ctx, time.Now().UTC().Add(-retention))
+}
+
+// Export выгружает все подписки, подходящие под фильтр, передавая их в fn по одной.
+func (s *Service) Export(ctx context.Context, f ListFilter, fn func(*domain.Subscription) error) error {
+	var err error
+	if f.UserIDs, err = scopeUserIDs(ctx, f.UserIDs); err != nil {
+		return err
 	}
-	return s.repo.Summary(ctx, from, to, userID, serviceName)
+	return s.repo.Export(ctx, f, fn)
 }
 
 // DTOs
 
 type CreateInput struct {
-	ServiceName string
-	Price       int
-	UserID      uuid.UUID
-	StartDate   string  // MM-YYYY
-	EndDate     *string // optional
+	ServiceName   string
+	ServiceID     *uuid.UUID // сервис из каталога; если задан, ServiceName не нужен
+	Price         int
+	Currency      string // ISO 4217, по умолчанию RUB
+	BillingPeriod string // weekly | monthly | quarterly | yearly, по умолчанию monthly
+	UserID        uuid.UUID
+	StartDate     string  // MM-YYYY или DD-MM-YYYY
+	EndDate       *string // optional
 }
 
+// UpdateInput — изменения подписки: nil-поля не меняются, EndDateSet отличает снятие end_date
+// (EndDate nil или "") от отсутствия изменения. PUT заполняет все поля, PATCH — только переданные.
 type UpdateInput struct {
-	ServiceName *string
-	Price       *int
-	StartDate   *string
-	EndDate     *string
-	EndDateSet  bool
+	ServiceName   *string
+	Price         *int
+	Currency      *string
+	BillingPeriod *string
+	StartDate     *string
+	EndDate       *string
+	EndDateSet    bool
+	IfMatch       int64 // ожидаемая версия подписки; 0 — без проверки
 }
@@ -0,0 +1,318 @@
+package usecase
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+)
+
+// SummaryMode — как распределять стоимость подписок с периодом оплаты длиннее или короче месяца.
+type SummaryMode string
+
+const (
+	// ModeBilled — сумма списывается в месяцы фактических списаний (по умолчанию).
+	ModeBilled SummaryMode = "billed"
+	// ModeAmortized — в каждый активный месяц идёт месячный эквивалент цены.
+	ModeAmortized SummaryMode = "amortized"
+)
+
+// SummaryFilter — границы периода (включительно) и фильтры для расчёта стоимости.
+type SummaryFilter struct {
+	From        domain.YearMonth
+	To          domain.YearMonth
+	UserID      *uuid.UUID
+	UserIDs     []uuid.UUID // если не nil — только подписки этих пользователей (пустой срез — никаких)
+	ServiceName *string
+	ServiceID   *uuid.UUID
+	Currency    *domain.Currency
+	Mode        SummaryMode
+	// IncludeDeleted — учитывать и удалённые (soft delete) подписки.
+	IncludeDeleted bool
+}
+
+type MonthlyCost struct {
+	Month         domain.YearMonth
+	Total         int64
+	Subscriptions int
+}
+
+type SummaryGroupBy string
+
+const (
+	GroupByServiceName SummaryGroupBy = "service_name"
+	GroupByUserID      SummaryGroupBy = "user_id"
+)
+
+// GroupCost — итог по одной группе; Months — сумма активных месяцев всех подписок группы.
+type GroupCost struct {
+	Key           string
+	Total         int64
+	Months        int
+	Subscriptions int
+}
+
+// Результаты расчётов всегда в одной валюте: суммы в разных валютах не складываются.
+type SummaryResult struct {
+	Currency domain.Currency
+	Total    int64
+}
+
+type MonthlyResult struct {
+	Currency domain.Currency
+	Months   []MonthlyCost
+}
+
+type GroupedResult struct {
+	Currency domain.Currency
+	Groups   []GroupCost
+}
+
+func (s *Service) Summary(ctx context.Context, in SummaryInput) (SummaryResult, error) {
+	if in.TargetCurrency != nil {
+		f, target, err := s.convertFilter(ctx, in)
+		if err != nil {
+			return SummaryResult{}, err
+		}
+		amounts, _, err := s.convertedMonths(ctx, f, target)
+		if err != nil {
+			return SummaryResult{}, err
+		}
+		total := new(big.Rat)
+		for _, a := range amounts {
+			total.Add(total, a)
+		}
+		return SummaryResult{Currency: target, Total: domain.RoundRat(total)}, nil
+	}
+	f, err := s.summaryFilter(ctx, in)
+	if err != nil {
+		return SummaryResult{}, err
+	}
+	total, err := s.repo.Summary(ctx, f)
+	if err != nil {
+		return SummaryResult{}, err
+	}
+	return SummaryResult{Currency: *f.Currency, Total: total}, nil
+}
+
+// MonthlySummary разбивает стоимость периода по месяцам; месяцы без активных подписок тоже попадают в ответ.
+func (s *Service) MonthlySummary(ctx context.Context, in SummaryInput) (MonthlyResult, error) {
+	if in.TargetCurrency != nil {
+		f, target, err := s.convertFilter(ctx, in)
+		if err != nil {
+			return MonthlyResult{}, err
+		}
+		if !f.From.BeforeOrEqual(f.To) {
+			return MonthlyResult{}, domain.Errorf(domain.ErrValidation, "from must be <= to")
+		}
+		amounts, months, err := s.convertedMonths(ctx, f, target)
+		if err != nil {
+			return MonthlyResult{}, err
+		}
+		for i := range months {
+			months[i].Total = domain.RoundRat(amounts[i])
+		}
+		return MonthlyResult{Currency: target, Months: months}, nil
+	}
+	f, err := s.summaryFilter(ctx, in)
+	if err != nil {
+		return MonthlyResult{}, err
+	}
+	if !f.From.BeforeOrEqual(f.To) {
+		return MonthlyResult{}, domain.Errorf(domain.ErrValidation, "from must be <= to")
+	}
+	months, err := s.repo.MonthlySummary(ctx, f)
+	if err != nil {
+		return MonthlyResult{}, err
+	}
+	return MonthlyResult{Currency: *f.Currency, Months: months}, nil
+}
+
+// GroupedSummary считает стоимость периода по группам, отсортированным по убыванию суммы.
+// limit > 0 оставляет только первые limit групп.
+func (s *Service) GroupedSummary(ctx context.Context, in SummaryInput, groupBy SummaryGroupBy, limit int) (GroupedResult, error) {
+	switch groupBy {
+	case GroupByServiceName, GroupByUserID:
+	default:
+		return GroupedResult{}, domain.Errorf(domain.ErrValidation, "group_by must be one of: service_name, user_id")
+	}
+	if limit < 0 {
+		return GroupedResult{}, domain.Errorf(domain.ErrValidation, "limit must be >= 0")
+	}
+	if in.TargetCurrency != nil {
+		return GroupedResult{}, domain.Errorf(domain.ErrValidation, "target_currency is not supported with group_by")
+	}
+	f, err := s.summaryFilter(ctx, in)
+	if err != nil {
+		return GroupedResult{}, err
+	}
+	groups, err := s.repo.GroupedSummary(ctx, f, groupBy, limit)
+	if err != nil {
+		return GroupedResult{}, err
+	}
+	return GroupedResult{Currency: *f.Currency, Groups: groups}, nil
+}
+
+// convertedMonths считает помесячные суммы по каждой валюте отдельно и переводит их в target
+// по курсу соответствующего месяца. Возвращает точные суммы и строки с количеством подписок.
+func (s *Service) convertedMonths(ctx context.Context, f SummaryFilter, target domain.Currency) ([]*big.Rat, []MonthlyCost, error) {
+	conv, err := s.newConverter(target)
+	if err != nil {
+		return nil, nil, err
+	}
+	currencies := []domain.Currency{}
+	if f.Currency != nil {
+		currencies = append(currencies, *f.Currency)
+	} else if currencies, err = s.repo.SummaryCurrencies(ctx, f); err != nil {
+		return nil, nil, err
+	}
+
+	var months []MonthlyCost
+	var amounts []*big.Rat
+	for m := f.From; m.BeforeOrEqual(f.To); m = m.AddMonths(1) {
+		months = append(months, MonthlyCost{Month: m})
+		amounts = append(amounts, new(big.Rat))
+	}
+	for _, c := range currencies {
+		fc := f
+		fc.Currency = &c
+		rows, err := s.repo.MonthlySummary(ctx, fc)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i, row := range rows {
+			v, err := conv.convert(ctx, row.Total, c, row.Month)
+			if err != nil {
+				return nil, nil, err
+			}
+			amounts[i].Add(amounts[i], v)
+			months[i].Subscriptions += row.Subscriptions
+		}
+	}
+	return amounts, months, nil
+}
+
+// summaryFilter разбирает ввод и фиксирует валюту расчёта. Если валюта не задана явно,
+// она определяется по данным; подписки в нескольких валютах без фильтра — ошибка валидации.
+func (s *Service) summaryFilter(ctx context.Context, in SummaryInput) (SummaryFilter, error) {
+	f, err := s.scopedFilter(ctx, in)
+	if err != nil {
+		return SummaryFilter{}, err
+	}
+	return s.fixCurrency(ctx, f)
+}
+
+// fixCurrency задаёт валюту расчёта f, если она не задана, по валютам подписок под фильтром.
+func (s *Service) fixCurrency(ctx context.Context, f SummaryFilter) (SummaryFilter, error) {
+	if f.Currency != nil {
+		return f, nil
+	}
+	currencies, err := s.repo.SummaryCurrencies(ctx, f)
+	if err != nil {
+		return SummaryFilter{}, err
+	}
+	c := domain.DefaultCurrency
+	switch len(currencies) {
+	case 0:
+	case 1:
+		c = currencies[0]
+	default:
+		codes := make([]string, len(currencies))
+		for i, cur := range currencies {
+			codes[i] = cur.String()
+		}
+		return SummaryFilter{}, domain.Errorf(domain.ErrValidation,
+			"subscriptions in period use several currencies (%s); specify currency", strings.Join(codes, ", "))
+	}
+	f.Currency = &c
+	return f, nil
+}
+
+// MaxSummaryMonths — наибольшая длина периода расчёта в месяцах.
+const MaxSummaryMonths = 120
+
+type SummaryInput struct {
+	From        string // MM-YYYY
+	To          string // MM-YYYY
+	UserID      *uuid.UUID
+	TeamID      *uuid.UUID // команда вместе с вложенными командами
+	ServiceName *string
+	ServiceID   *uuid.UUID
+	Currency    *string
+	// TargetCurrency — перевести суммы во всех валютах в эту валюту по курсам соответствующих месяцев.
+	TargetCurrency *string
+	Mode           *string // billed | amortized
+	IncludeDeleted bool
+}
+
+// scopedFilter разбирает ввод, ограничивает его подписками, доступными вызывающему, и заменяет
+// команду in.TeamID списком её участников с вложенными командами.
+func (s *Service) scopedFilter(ctx context.Context, in SummaryInput) (SummaryFilter, error) {
+	f, err := in.filter()
+	if err != nil {
+		return SummaryFilter{}, err
+	}
+	scope, err := userScope(ctx, false)
+	if err != nil {
+		return SummaryFilter{}, err
+	}
+	if scope != nil {
+		if in.TeamID != nil || f.UserID != nil && *f.UserID != *scope {
+			return SummaryFilter{}, errOtherUser()
+		}
+		f.UserID = scope
+	}
+	if in.TeamID == nil {
+		return f, nil
+	}
+	if f.UserIDs, err = s.teamMembers(ctx, *in.TeamID); err != nil {
+		return SummaryFilter{}, err
+	}
+	return f, nil
+}
+
+func (s *Service) convertFilter(ctx context.Context, in SummaryInput) (SummaryFilter, domain.Currency, error) {
+	f, err := s.scopedFilter(ctx, in)
+	if err != nil {
+		return SummaryFilter{}, "", err
+	}
+	target, err := domain.ParseCurrency(*in.TargetCurrency)
+	if err != nil {
+		return SummaryFilter{}, "", err
+	}
+	return f, target, nil
+}
+
+func (in SummaryInput) filter() (SummaryFilter, error) {
+	from, err := domain.ParseYearMonth(in.From)
+	if err != nil {
+		return SummaryFilter{}, err
+	}
+	to, err := domain.ParseYearMonth(in.To)
+	if err != nil {
+		return SummaryFilter{}, err
+	}
+	if from.MonthsUntil(to) > MaxSummaryMonths {
+		return SummaryFilter{}, domain.Errorf(domain.ErrValidation, "period must not exceed %d months", MaxSummaryMonths)
+	}
+	f := SummaryFilter{From: from, To: to, UserID: in.UserID, ServiceName: in.ServiceName, ServiceID: in.ServiceID, Mode: ModeBilled, IncludeDeleted: in.IncludeDeleted}
+	if in.Mode != nil {
+		switch m := SummaryMode(*in.Mode); m {
+		case ModeBilled, ModeAmortized:
+			f.Mode = m
+		default:
+			return SummaryFilter{}, domain.Errorf(domain.ErrValidation, "mode must be one of: billed, amortized")
+		}
+	}
+	if in.Currency != nil {
+		c, err := domain.ParseCurrency(*in.Currency)
+		if err != nil {
+			return SummaryFilter{}, err
+		}
+		f.Currency = &c
+	}
+	return f, nil
+}
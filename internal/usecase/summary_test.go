@@ -0,0 +1,196 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/adapters/repo/memory"
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+func strPtr(s string) *string { return &s }
+
+func mustCreate(t *testing.T, svc *usecase.Service, in usecase.CreateInput) *domain.Subscription {
+	t.Helper()
+	sub, err := svc.Create(context.Background(), in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sub
+}
+
+func mustUser(t *testing.T, svc *usecase.Service) uuid.UUID {
+	t.Helper()
+	u, err := svc.CreateUser(context.Background(), usecase.UserInput{Name: "Test User"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.ID
+}
+
+func TestSummaryConvertsWithMonthlyRates(t *testing.T) {
+	ctx := context.Background()
+	rates := memory.NewExchangeRateRepo()
+	repos := memory.NewRepos()
+	repos.Rates = rates
+	svc := usecase.NewService(repos)
+	user := mustUser(t, svc)
+	mustCreate(t, svc, usecase.CreateInput{ServiceName: "Yandex Plus", Price: 400, UserID: user, StartDate: "03-2024", EndDate: strPtr("04-2024")})
+	mustCreate(t, svc, usecase.CreateInput{ServiceName: "Netflix", Price: 10, Currency: "USD", UserID: user, StartDate: "03-2024", EndDate: strPtr("04-2024")})
+
+	if _, err := svc.UploadRates(ctx, []usecase.RateInput{
+		{From: "USD", To: "RUB", Month: "03-2024", Rate: "90"},
+		{From: "RUB", To: "USD", Month: "04-2024", Rate: "0.01"}, // обратная пара, курс 100
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	in := usecase.SummaryInput{From: "03-2024", To: "04-2024", TargetCurrency: strPtr("RUB")}
+	res, err := svc.Summary(ctx, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(400*2 + 10*90 + 10*100); res.Total != want || res.Currency != domain.RUB {
+		t.Fatalf("summary = %+v, want %d RUB", res, want)
+	}
+
+	monthly, err := svc.MonthlySummary(ctx, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(monthly.Months) != 2 || monthly.Months[0].Total != 1300 || monthly.Months[1].Total != 1400 || monthly.Months[1].Subscriptions != 2 {
+		t.Fatalf("monthly = %+v", monthly.Months)
+	}
+
+	// курс на 05-2024 не загружен — используется последний известный (04-2024)
+	in = usecase.SummaryInput{From: "05-2024", To: "05-2024", Currency: strPtr("RUB"), TargetCurrency: strPtr("USD")}
+	mustCreate(t, svc, usecase.CreateInput{ServiceName: "Kinopoisk", Price: 250, UserID: user, StartDate: "05-2024"})
+	if res, err = svc.Summary(ctx, in); err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 3 || res.Currency != domain.USD {
+		t.Fatalf("summary = %+v, want 3 USD", res)
+	}
+}
+
+func TestSummaryWithStaticRates(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepos()
+	repos.RateProvider = usecase.StaticRates{Base: domain.RUB, Rates: map[domain.Currency]*big.Rat{
+		domain.USD: big.NewRat(90, 1),
+		domain.EUR: big.NewRat(100, 1),
+	}}
+	svc := usecase.NewService(repos)
+	user := mustUser(t, svc)
+	mustCreate(t, svc, usecase.CreateInput{ServiceName: "A", Price: 9, Currency: "USD", UserID: user, StartDate: "01-2025"})
+	mustCreate(t, svc, usecase.CreateInput{ServiceName: "B", Price: 10, Currency: "EUR", UserID: user, StartDate: "01-2025"})
+
+	res, err := svc.Summary(ctx, usecase.SummaryInput{From: "01-2025", To: "01-2025", TargetCurrency: strPtr("EUR")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 9 USD = 8.1 EUR
+	if res.Total != 18 {
+		t.Fatalf("total = %d, want 18", res.Total)
+	}
+
+	if _, err := svc.Summary(ctx, usecase.SummaryInput{From: "01-2025", To: "01-2025", TargetCurrency: strPtr("GBP")}); err == nil {
+		t.Fatal("expected error for missing GBP rate")
+	}
+}
+
+func TestSummaryProratesDayPrecision(t *testing.T) {
+	ctx := context.Background()
+	svc := usecase.NewService(memory.NewRepos())
+	user := mustUser(t, svc)
+	sub := mustCreate(t, svc, usecase.CreateInput{ServiceName: "Netflix", Price: 310, UserID: user, StartDate: "01-2025", EndDate: strPtr("03-03-2025")})
+	if !sub.DayPrecision() || sub.StartDate.String() != "01-01-2025" {
+		t.Fatalf("expected day precision from 01-01-2025, got %+v", sub)
+	}
+	// обычная подписка с точностью до месяца рядом — без пропорции
+	mustCreate(t, svc, usecase.CreateInput{ServiceName: "Spotify", Price: 100, UserID: user, StartDate: "03-2025"})
+
+	res, err := svc.MonthlySummary(ctx, usecase.SummaryInput{From: "02-2025", To: "03-2025", UserID: &user})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Months[0].Total != 310 || res.Months[1].Total != 30+100 {
+		t.Fatalf("months = %+v", res.Months)
+	}
+
+	// обновление месяцем окончания сохраняет точность до дня: конец — последний день месяца
+	upd, err := svc.Update(ctx, sub.ID, usecase.UpdateInput{EndDate: strPtr("04-2025"), EndDateSet: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if upd.EndDate == nil || upd.EndDate.String() != "30-04-2025" {
+		t.Fatalf("end date = %v, want 30-04-2025", upd.EndDate)
+	}
+}
+
+func TestSummaryUsesPriceInForce(t *testing.T) {
+	ctx := context.Background()
+	svc := usecase.NewService(memory.NewRepos())
+	user := mustUser(t, svc)
+	sub := mustCreate(t, svc, usecase.CreateInput{ServiceName: "Yandex Plus", Price: 300, UserID: user, StartDate: "01-2025"})
+	if _, err := svc.ChangePrice(ctx, sub.ID, usecase.PriceChangeInput{Price: 400, EffectiveFrom: "04-2025"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.ChangePrice(ctx, sub.ID, usecase.PriceChangeInput{Price: 1, EffectiveFrom: "12-2024"}); err == nil {
+		t.Fatal("expected error for price change before start")
+	}
+
+	res, err := svc.Summary(ctx, usecase.SummaryInput{From: "01-2025", To: "06-2025"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(300*3 + 400*3); res.Total != want {
+		t.Fatalf("total = %d, want %d", res.Total, want)
+	}
+
+	schedule, err := svc.PriceSchedule(ctx, sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schedule) != 2 || schedule[0].Price != 300 || schedule[1].EffectiveFrom.String() != "04-2025" {
+		t.Fatalf("schedule = %+v", schedule)
+	}
+
+	if err := svc.DeletePriceChange(ctx, sub.ID, "04-2025"); err != nil {
+		t.Fatal(err)
+	}
+	if res, err = svc.Summary(ctx, usecase.SummaryInput{From: "01-2025", To: "06-2025"}); err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 300*6 {
+		t.Fatalf("total after delete = %d, want %d", res.Total, 300*6)
+	}
+}
+
+func TestSummaryPeriodIsLimited(t *testing.T) {
+	ctx := context.Background()
+	svc := usecase.NewService(memory.NewRepos())
+	tests := []struct {
+		from, to string
+		wantErr  bool
+	}{
+		{"01-2015", "12-2024", false},
+		{"01-2015", "01-2025", true},
+		{"01-0001", "12-9999", true},
+	}
+	for _, tt := range tests {
+		in := usecase.SummaryInput{From: tt.from, To: tt.to}
+		_, err := svc.Summary(ctx, in)
+		if _, merr := svc.MonthlySummary(ctx, in); (merr != nil) != (err != nil) {
+			t.Fatalf("%s..%s: summary err %v, monthly err %v", tt.from, tt.to, err, merr)
+		}
+		if tt.wantErr != errors.Is(err, domain.ErrValidation) || !tt.wantErr && err != nil {
+			t.Fatalf("%s..%s: err = %v, want error %v", tt.from, tt.to, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,384 @@
+package usecase
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+)
+
+// OrgRepo хранит организации. Все методы видят только организации арендатора вызова (TenantFrom);
+// организацию другого арендатора Create не сохраняет (domain.ErrForbidden).
+type OrgRepo interface {
+	Create(ctx context.Context, o *domain.Organization) error
+	Get(ctx context.Context, id uuid.UUID) (*domain.Organization, error)
+	Update(ctx context.Context, o *domain.Organization) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// List возвращает организации по названию.
+	List(ctx context.Context) ([]*domain.Organization, error)
+}
+
+// TeamRepo хранит команды; как и OrgRepo, видит только команды арендатора вызова. Create и Update возвращают ErrConflict, если в организации уже есть
+// команда с таким названием. Update в одной транзакции с изменением проверяет родителя
+// (domain.Team.CheckParent) по дереву организации, которое на это время заблокировано.
+type TeamRepo interface {
+	Create(ctx context.Context, t *domain.Team) error
+	Get(ctx context.Context, id uuid.UUID) (*domain.Team, error)
+	Update(ctx context.Context, t *domain.Team) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// List возвращает все команды организации по названию.
+	List(ctx context.Context, orgID uuid.UUID) ([]*domain.Team, error)
+}
+
+func (s *Service) CreateOrg(ctx context.Context, in OrgInput) (*domain.Organization, error) {
+	if err := requireRole(ctx, domain.RoleAdmin); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	o := &domain.Organization{ID: uuid.New(), TenantID: TenantFrom(ctx), Name: in.Name, CreatedAt: now, UpdatedAt: now}
+	o.Normalize()
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.orgs.Create(ctx, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func (s *Service) GetOrg(ctx context.Context, id uuid.UUID) (*domain.Organization, error) {
+	return s.orgs.Get(ctx, id)
+}
+
+func (s *Service) ListOrgs(ctx context.Context) ([]*domain.Organization, error) {
+	return s.orgs.List(ctx)
+}
+
+func (s *Service) UpdateOrg(ctx context.Context, id uuid.UUID, in OrgInput) (*domain.Organization, error) {
+	if err := requireRole(ctx, domain.RoleAdmin); err != nil {
+		return nil, err
+	}
+	o, err := s.orgs.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	o.Name, o.UpdatedAt = in.Name, time.Now().UTC()
+	o.Normalize()
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.orgs.Update(ctx, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// DeleteOrg удаляет организацию без команд.
+func (s *Service) DeleteOrg(ctx context.Context, id uuid.UUID) error {
+	if err := requireRole(ctx, domain.RoleAdmin); err != nil {
+		return err
+	}
+	teams, err := s.teams.List(ctx, id)
+	if err != nil {
+		return err
+	}
+	if len(teams) > 0 {
+		return domain.Errorf(domain.ErrConflict, "organization has %d teams", len(teams))
+	}
+	return s.orgs.Delete(ctx, id)
+}
+
+// CreateTeam создаёт команду; вложенная команда должна принадлежать той же организации, что и родитель.
+func (s *Service) CreateTeam(ctx context.Context, in TeamInput) (*domain.Team, error) {
+	if err := requireRole(ctx, domain.RoleAdmin); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	t := &domain.Team{ID: uuid.New(), TenantID: TenantFrom(ctx), OrgID: in.OrgID, CreatedAt: now}
+	if _, err := s.orgs.Get(ctx, in.OrgID); err != nil {
+		return nil, err
+	}
+	if err := s.applyTeam(ctx, t, in, now); err != nil {
+		return nil, err
+	}
+	if err := s.teams.Create(ctx, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (s *Service) GetTeam(ctx context.Context, id uuid.UUID) (*domain.Team, error) {
+	return s.teams.Get(ctx, id)
+}
+
+func (s *Service) ListTeams(ctx context.Context, orgID uuid.UUID) ([]*domain.Team, error) {
+	if _, err := s.orgs.Get(ctx, orgID); err != nil {
+		return nil, err
+	}
+	return s.teams.List(ctx, orgID)
+}
+
+// UpdateTeam переименовывает команду или переносит её в другую ветку той же организации.
+func (s *Service) UpdateTeam(ctx context.Context, id uuid.UUID, in TeamInput) (*domain.Team, error) {
+	if err := requireRole(ctx, domain.RoleAdmin); err != nil {
+		return nil, err
+	}
+	t, err := s.teams.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if in.OrgID != uuid.Nil && in.OrgID != t.OrgID {
+		return nil, domain.Errorf(domain.ErrValidation, "team cannot be moved to another organization")
+	}
+	in.OrgID = t.OrgID
+	if err := s.applyTeam(ctx, t, in, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	if err := s.teams.Update(ctx, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// DeleteTeam удаляет команду без вложенных команд и участников.
+func (s *Service) DeleteTeam(ctx context.Context, id uuid.UUID) error {
+	if err := requireRole(ctx, domain.RoleAdmin); err != nil {
+		return err
+	}
+	team, err := s.teams.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	teams, err := s.teams.List(ctx, team.OrgID)
+	if err != nil {
+		return err
+	}
+	if n := len(subtree(teams, id)) - 1; n > 0 {
+		return domain.Errorf(domain.ErrConflict, "team has %d sub-teams", n)
+	}
+	users, err := s.users.List(ctx, UserFilter{TeamIDs: []uuid.UUID{id}, Limit: 1})
+	if err != nil {
+		return err
+	}
+	if len(users) > 0 {
+		return domain.Errorf(domain.ErrConflict, "team has members")
+	}
+	return s.teams.Delete(ctx, id)
+}
+
+// applyTeam переносит ввод в t и проверяет родителя (domain.Team.CheckParent). Проверка здесь
+// даёт понятную ошибку заранее; TeamRepo.Update повторяет её атомарно с изменением.
+func (s *Service) applyTeam(ctx context.Context, t *domain.Team, in TeamInput, now time.Time) error {
+	t.Name, t.ParentID, t.UpdatedAt = in.Name, in.ParentID, now
+	t.Normalize()
+	if err := t.Validate(); err != nil {
+		return err
+	}
+	if t.ParentID == nil {
+		return nil
+	}
+	teams, err := s.teams.List(ctx, t.OrgID)
+	if err != nil {
+		return err
+	}
+	return t.CheckParent(teams)
+}
+
+// subtree возвращает команду root и все вложенные в неё команды из списка команд организации.
+func subtree(teams []*domain.Team, root uuid.UUID) []*domain.Team {
+	children := make(map[uuid.UUID][]*domain.Team)
+	var res []*domain.Team
+	for _, t := range teams {
+		if t.ParentID != nil {
+			children[*t.ParentID] = append(children[*t.ParentID], t)
+		}
+		if t.ID == root {
+			res = append(res, t)
+		}
+	}
+	seen := map[uuid.UUID]bool{root: true} // защита от цикла в дереве
+	for i := 0; i < len(res); i++ {
+		for _, c := range children[res[i].ID] {
+			if !seen[c.ID] {
+				seen[c.ID] = true
+				res = append(res, c)
+			}
+		}
+	}
+	return res
+}
+
+// teamMembers возвращает ID участников команды teamID и всех вложенных команд.
+func (s *Service) teamMembers(ctx context.Context, teamID uuid.UUID) ([]uuid.UUID, error) {
+	team, err := s.teams.Get(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	teams, err := s.teams.List(ctx, team.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	users, err := s.users.List(ctx, UserFilter{TeamIDs: teamIDs(subtree(teams, teamID))})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uuid.UUID, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.ID)
+	}
+	return ids, nil
+}
+
+func teamIDs(teams []*domain.Team) []uuid.UUID {
+	ids := make([]uuid.UUID, len(teams))
+	for i, t := range teams {
+		ids[i] = t.ID
+	}
+	return ids
+}
+
+// CostNode — узел дерева стоимости: организация или команда. Own — стоимость подписок участников
+// самой команды, Total — вместе со всеми вложенными командами.
+type CostNode struct {
+	Kind          string // organization | team
+	ID            uuid.UUID
+	Name          string
+	Members       int // участники самой команды
+	Subscriptions int // подписки в Total
+	Own           int64
+	Total         int64
+	Children      []*CostNode // по убыванию Total
+}
+
+type CostTree struct {
+	Currency domain.Currency
+	Root     *CostNode
+}
+
+// OrgCostTree считает дерево стоимости всей организации за период in (фильтры по пользователю
+// и команде в in не учитываются). Суммы не конвертируются: в периоде должна быть одна валюта
+// или in.Currency.
+func (s *Service) OrgCostTree(ctx context.Context, orgID uuid.UUID, in SummaryInput) (CostTree, error) {
+	org, err := s.orgs.Get(ctx, orgID)
+	if err != nil {
+		return CostTree{}, err
+	}
+	teams, err := s.teams.List(ctx, orgID)
+	if err != nil {
+		return CostTree{}, err
+	}
+	root := &CostNode{Kind: "organization", ID: org.ID, Name: org.Name}
+	return s.costTree(ctx, root, teams, in)
+}
+
+// TeamCostTree — OrgCostTree для команды и вложенных в неё команд.
+func (s *Service) TeamCostTree(ctx context.Context, teamID uuid.UUID, in SummaryInput) (CostTree, error) {
+	team, err := s.teams.Get(ctx, teamID)
+	if err != nil {
+		return CostTree{}, err
+	}
+	teams, err := s.teams.List(ctx, team.OrgID)
+	if err != nil {
+		return CostTree{}, err
+	}
+	teams = subtree(teams, teamID)
+	root := &CostNode{Kind: "team", ID: team.ID, Name: team.Name}
+	return s.costTree(ctx, root, teams[1:], in)
+}
+
+// costTree строит дерево под root из teams: команды без родителя среди teams становятся детьми root.
+// Стоимость считается одним сгруппированным по пользователям запросом и сворачивается снизу вверх.
+func (s *Service) costTree(ctx context.Context, root *CostNode, teams []*domain.Team, in SummaryInput) (CostTree, error) {
+	if err := requireReadAll(ctx); err != nil {
+		return CostTree{}, err
+	}
+	if in.TargetCurrency != nil {
+		return CostTree{}, domain.Errorf(domain.ErrValidation, "target_currency is not supported for the cost tree")
+	}
+	nodes := map[uuid.UUID]*CostNode{root.ID: root}
+	for _, t := range teams {
+		nodes[t.ID] = &CostNode{Kind: "team", ID: t.ID, Name: t.Name}
+	}
+	for _, t := range teams {
+		parent := root
+		if t.ParentID != nil && nodes[*t.ParentID] != nil {
+			parent = nodes[*t.ParentID]
+		}
+		parent.Children = append(parent.Children, nodes[t.ID])
+	}
+	ids := teamIDs(teams)
+	if root.Kind == "team" {
+		ids = append(ids, root.ID)
+	}
+	users, err := s.users.List(ctx, UserFilter{TeamIDs: ids})
+	if err != nil {
+		return CostTree{}, err
+	}
+	userTeam := make(map[string]*CostNode, len(users))
+	members := make([]uuid.UUID, 0, len(users))
+	for _, u := range users {
+		n := nodes[*u.TeamID]
+		n.Members++
+		userTeam[u.ID.String()] = n
+		members = append(members, u.ID)
+	}
+
+	in.UserID, in.TeamID = nil, nil
+	f, err := in.filter()
+	if err != nil {
+		return CostTree{}, err
+	}
+	f.UserIDs = members
+	if f, err = s.fixCurrency(ctx, f); err != nil {
+		return CostTree{}, err
+	}
+	groups, err := s.repo.GroupedSummary(ctx, f, GroupByUserID, 0)
+	if err != nil {
+		return CostTree{}, err
+	}
+	for _, g := range groups {
+		if n := userTeam[g.Key]; n != nil {
+			n.Own += g.Total
+			n.Subscriptions += g.Subscriptions
+		}
+	}
+	rollUp(root)
+	return CostTree{Currency: *f.Currency, Root: root}, nil
+}
+
+func rollUp(n *CostNode) {
+	n.Total = n.Own
+	for _, c := range n.Children {
+		rollUp(c)
+		n.Total += c.Total
+		n.Subscriptions += c.Subscriptions
+	}
+	sortCostNodes(n.Children)
+}
+
+// sortCostNodes упорядочивает узлы по убыванию Total, при равенстве — по названию.
+func sortCostNodes(nodes []*CostNode) {
+	slices.SortFunc(nodes, func(a, b *CostNode) int {
+		if c := cmp.Compare(b.Total, a.Total); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+}
+
+// DTOs
+
+type OrgInput struct {
+	Name string
+}
+
+type TeamInput struct {
+	OrgID    uuid.UUID
+	ParentID *uuid.UUID // nil — команда верхнего уровня
+	Name     string
+}
@@ -0,0 +1,207 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/adapters/repo/memory"
+	"github.com/oziev02/subscriptions-service/internal/domain"
+	"github.com/oziev02/subscriptions-service/internal/usecase"
+)
+
+func tenantCtx(tenant string) context.Context {
+	return usecase.WithPrincipal(context.Background(), domain.Principal{Subject: tenant, TenantID: tenant, Roles: []string{domain.RoleAdmin}})
+}
+
+func tenantUser(t *testing.T, ctx context.Context, svc *usecase.Service) uuid.UUID {
+	t.Helper()
+	u, err := svc.CreateUser(ctx, usecase.UserInput{Name: "Test User"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.ID
+}
+
+func TestCrossTenantAccess(t *testing.T) {
+	acme, globex := tenantCtx("acme"), tenantCtx("globex")
+	svc := usecase.NewService(memory.NewRepos())
+	org, err := svc.CreateOrg(acme, usecase.OrgInput{Name: "Acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	team, err := svc.CreateTeam(acme, usecase.TeamInput{OrgID: org.ID, Name: "Platform"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := svc.CreateUser(acme, usecase.UserInput{Name: "Alice", TeamID: &team.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := u.ID
+	sub, err := svc.Create(acme, usecase.CreateInput{ServiceName: "Netflix", Price: 500, UserID: user, StartDate: "01-2024"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.TenantID != "acme" {
+		t.Fatalf("tenant = %q, want acme", sub.TenantID)
+	}
+
+	price := 1
+	tests := []struct {
+		name string
+		op   func(ctx context.Context) error
+	}{
+		{"get", func(ctx context.Context) error { _, err := svc.Get(ctx, sub.ID, false); return err }},
+		{"get deleted", func(ctx context.Context) error { _, err := svc.Get(ctx, sub.ID, true); return err }},
+		{"update", func(ctx context.Context) error {
+			_, err := svc.Update(ctx, sub.ID, usecase.UpdateInput{Price: &price})
+			return err
+		}},
+		{"delete", func(ctx context.Context) error { return svc.Delete(ctx, sub.ID, 0) }},
+		{"restore", func(ctx context.Context) error { _, err := svc.Restore(ctx, sub.ID); return err }},
+		{"change price", func(ctx context.Context) error {
+			_, err := svc.ChangePrice(ctx, sub.ID, usecase.PriceChangeInput{Price: 1, EffectiveFrom: "03-2024"})
+			return err
+		}},
+		{"history", func(ctx context.Context) error { _, err := svc.History(ctx, sub.ID); return err }},
+		{"get user", func(ctx context.Context) error { _, err := svc.GetUser(ctx, user); return err }},
+		{"update user", func(ctx context.Context) error {
+			_, err := svc.UpdateUser(ctx, user, usecase.UserInput{Name: "Mallory"})
+			return err
+		}},
+		{"delete user", func(ctx context.Context) error { return svc.DeleteUser(ctx, user, usecase.DeleteCascade) }},
+		{"get org", func(ctx context.Context) error { _, err := svc.GetOrg(ctx, org.ID); return err }},
+		{"update team", func(ctx context.Context) error {
+			_, err := svc.UpdateTeam(ctx, team.ID, usecase.TeamInput{Name: "Hijacked"})
+			return err
+		}},
+		{"org cost tree", func(ctx context.Context) error {
+			_, err := svc.OrgCostTree(ctx, org.ID, usecase.SummaryInput{From: "01-2024", To: "12-2024"})
+			return err
+		}},
+		{"team summary", func(ctx context.Context) error {
+			_, err := svc.Summary(ctx, usecase.SummaryInput{From: "01-2024", To: "12-2024", TeamID: &team.ID})
+			return err
+		}},
+		{"batch delete", func(ctx context.Context) error {
+			res, err := svc.Batch(ctx, usecase.BatchInput{Ops: []usecase.BatchOp{{Kind: usecase.BatchDelete, ID: sub.ID}}})
+			if err != nil {
+				return err
+			}
+			return res[0].Err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.op(globex); !errors.Is(err, domain.ErrNotFound) {
+				t.Fatalf("err = %v, want not found", err)
+			}
+		})
+	}
+
+	page, err := svc.List(globex, usecase.ListFilter{IncludeDeleted: true})
+	if err != nil || len(page.Items) != 0 {
+		t.Fatalf("list from another tenant: %d items, err %v", len(page.Items), err)
+	}
+	if _, err := svc.Create(globex, usecase.CreateInput{ServiceName: "Netflix", Price: 1, UserID: user, StartDate: "01-2024"}); !errors.Is(err, domain.ErrValidation) {
+		t.Fatalf("subscription for a user of another tenant: err = %v, want validation", err)
+	}
+	if users, err := svc.ListUsers(globex, usecase.UserFilter{}); err != nil || len(users) != 0 {
+		t.Fatalf("users from another tenant: %d, err %v", len(users), err)
+	}
+	if orgs, err := svc.ListOrgs(globex); err != nil || len(orgs) != 0 {
+		t.Fatalf("organizations from another tenant: %d, err %v", len(orgs), err)
+	}
+	res, err := svc.Summary(globex, usecase.SummaryInput{From: "01-2024", To: "12-2024"})
+	if err != nil || res.Total != 0 {
+		t.Fatalf("summary from another tenant: %+v, err %v", res, err)
+	}
+
+	got, err := svc.Get(acme, sub.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != 1 || got.Price != 500 {
+		t.Fatalf("subscription changed by another tenant: %+v", got)
+	}
+	if res, _ := svc.Summary(acme, usecase.SummaryInput{From: "01-2024", To: "01-2024"}); res.Total != 500 {
+		t.Fatalf("summary of own tenant = %d, want 500", res.Total)
+	}
+	if got, err := svc.GetUser(acme, user); err != nil || got.Name != "Alice" {
+		t.Fatalf("user changed by another tenant: %+v, err %v", got, err)
+	}
+}
+
+func TestServiceCatalogIsPerTenant(t *testing.T) {
+	acme, globex := tenantCtx("acme"), tenantCtx("globex")
+	svc := usecase.NewService(memory.NewRepos())
+	in := usecase.CreateInput{ServiceName: "Netflix", Price: 500, UserID: tenantUser(t, acme, svc), StartDate: "01-2024"}
+	acmeSub, err := svc.Create(acme, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in.UserID = tenantUser(t, globex, svc)
+	globexSub, err := svc.Create(globex, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acmeSub.ServiceID == globexSub.ServiceID {
+		t.Fatal("tenants share a catalog service")
+	}
+	if _, err := svc.GetService(globex, acmeSub.ServiceID); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("service of another tenant: err = %v, want not found", err)
+	}
+
+	if _, err := svc.UpdateService(globex, globexSub.ServiceID, usecase.ServiceInput{Name: "Netflix Premium"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := svc.Get(acme, acmeSub.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ServiceName != "Netflix" || got.Version != 1 {
+		t.Fatalf("subscription of another tenant renamed: %+v", got)
+	}
+	if got, _ := svc.Get(globex, globexSub.ID, false); got.ServiceName != "Netflix Premium" {
+		t.Fatalf("own subscription name = %q, want Netflix Premium", got.ServiceName)
+	}
+}
+
+func TestExchangeRatesArePerTenant(t *testing.T) {
+	acme, globex := tenantCtx("acme"), tenantCtx("globex")
+	svc := usecase.NewService(memory.NewRepos())
+	in := usecase.CreateInput{ServiceName: "Netflix", Price: 10, Currency: "USD", UserID: tenantUser(t, acme, svc), StartDate: "01-2024", EndDate: strPtr("01-2024")}
+	if _, err := svc.Create(acme, in); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.UploadRates(acme, []usecase.RateInput{{From: "USD", To: "RUB", Month: "01-2024", Rate: "90"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.UploadRates(globex, []usecase.RateInput{{From: "USD", To: "RUB", Month: "01-2024", Rate: "1"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	sum := usecase.SummaryInput{From: "01-2024", To: "01-2024", TargetCurrency: strPtr("RUB")}
+	res, err := svc.Summary(acme, sum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 900 {
+		t.Fatalf("total = %d, want 900: rates of another tenant applied", res.Total)
+	}
+
+	in.UserID = tenantUser(t, globex, svc)
+	in.Currency = "EUR"
+	if _, err := svc.Create(globex, in); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.UploadRates(acme, []usecase.RateInput{{From: "EUR", To: "RUB", Month: "01-2024", Rate: "100"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Summary(globex, sum); !errors.Is(err, domain.ErrValidation) {
+		t.Fatalf("rate of another tenant: err = %v, want validation error", err)
+	}
+}
@@ -0,0 +1,219 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/oziev02/subscriptions-service/internal/domain"
+)
+
+// UserRepo хранит пользователей. Все методы видят только пользователей арендатора вызова
+// (TenantFrom). Create и Update возвращают ErrConflict, если email уже занят другим неудалённым
+// пользователем арендатора. Удалённые пользователи для Get, Update и List не существуют.
+type UserRepo interface {
+	// Create сохраняет пользователя; пользователя другого арендатора сохранить нельзя (domain.ErrForbidden).
+	Create(ctx context.Context, u *domain.User) error
+	Get(ctx context.Context, id uuid.UUID) (*domain.User, error)
+	Update(ctx context.Context, u *domain.User) error
+	// Delete помечает пользователя удалённым. В той же транзакции, под блокировкой пользователя,
+	// release получает его неудалённые подписки и возвращает их изменения, которые хранилище
+	// применяет вместе с журналом аудита; ошибка release отменяет удаление. Пока пользователь
+	// заблокирован, подписку для него не создать, а удалённому — не создать вовсе.
+	Delete(ctx context.Context, id uuid.UUID, release UserRelease) error
+	// List возвращает пользователей по имени.
+	List(ctx context.Context, f UserFilter) ([]*domain.User, error)
+}
+
+// UserRelease решает, что сделать с неудалёнными подписками удаляемого пользователя.
+type UserRelease func(subs []*domain.Subscription) ([]BatchChange, error)
+
+// UserDeletePolicy — что делать с подписками удаляемого пользователя.
+type UserDeletePolicy string
+
+const (
+	// DeleteRefuse — не удалять пользователя, у которого есть подписки.
+	DeleteRefuse UserDeletePolicy = "refuse"
+	// DeleteEnd — завершить подписки текущим днём, ещё не начавшиеся — удалить.
+	DeleteEnd UserDeletePolicy = "end"
+	// DeleteCascade — удалить все подписки пользователя.
+	DeleteCascade UserDeletePolicy = "cascade"
+)
+
+func ParseUserDeletePolicy(s string) (UserDeletePolicy, error) {
+	switch p := UserDeletePolicy(s); p {
+	case "":
+		return DeleteRefuse, nil
+	case DeleteRefuse, DeleteEnd, DeleteCascade:
+		return p, nil
+	}
+	return "", domain.Errorf(domain.ErrValidation, "policy must be one of: refuse, end, cascade")
+}
+
+func (s *Service) CreateUser(ctx context.Context, in UserInput) (*domain.User, error) {
+	if err := requireRole(ctx, domain.RoleAdmin); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	u := &domain.User{ID: uuid.New(), TenantID: TenantFrom(ctx), CreatedAt: now}
+	if in.ID != nil {
+		u.ID = *in.ID
+	}
+	if err := s.applyUser(ctx, u, in, now); err != nil {
+		return nil, err
+	}
+	if err := s.users.Create(ctx, u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// GetUser возвращает пользователя. Вызывающему без ролей admin и finance виден только его
+// пользователь; другой для него не существует.
+func (s *Service) GetUser(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	if authorizeUser(ctx, id, false) != nil {
+		return nil, domain.Errorf(domain.ErrNotFound, "user not found")
+	}
+	return s.users.Get(ctx, id)
+}
+
+// ListUsers возвращает пользователей арендатора вызова; список доступен ролям admin и finance.
+func (s *Service) ListUsers(ctx context.Context, f UserFilter) ([]*domain.User, error) {
+	if scope, err := userScope(ctx, false); err != nil {
+		return nil, err
+	} else if scope != nil {
+		return nil, domain.Errorf(domain.ErrForbidden, "listing users requires the admin or finance role")
+	}
+	if f.Limit <= 0 || f.Limit > MaxListLimit {
+		f.Limit = MaxListLimit
+	}
+	return s.users.List(ctx, f)
+}
+
+// UpdateUser заменяет данные пользователя целиком. Свой профиль можно изменить без роли admin,
+// но не команду: от неё зависят расходы команд и доступ к их сводкам.
+func (s *Service) UpdateUser(ctx context.Context, id uuid.UUID, in UserInput) (*domain.User, error) {
+	if err := authorizeUser(ctx, id, true); err != nil {
+		return nil, err
+	}
+	u, err := s.users.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !sameID(u.TeamID, in.TeamID) {
+		if err := requireRole(ctx, domain.RoleAdmin); err != nil {
+			return nil, domain.Errorf(domain.ErrForbidden, "changing the team requires the admin role")
+		}
+	}
+	if err := s.applyUser(ctx, u, in, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	if err := s.users.Update(ctx, u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// UserSubscriptions возвращает страницу подписок пользователя; f.UserIDs заменяется на id.
+// Как и в GetUser, чужой пользователь для вызывающего без ролей admin и finance не существует.
+func (s *Service) UserSubscriptions(ctx context.Context, id uuid.UUID, f ListFilter) (ListPage, error) {
+	if authorizeUser(ctx, id, false) != nil {
+		return ListPage{}, domain.Errorf(domain.ErrNotFound, "user not found")
+	}
+	if _, err := s.users.Get(ctx, id); err != nil {
+		return ListPage{}, err
+	}
+	f.UserIDs = []uuid.UUID{id}
+	return s.List(ctx, f)
+}
+
+// DeleteUser удаляет пользователя, поступая с его неудалёнными подписками по policy. Проверка,
+// изменения подписок и удаление выполняются одной транзакцией; изменения попадают в историю подписок.
+// Удаление доступно только роли admin: удалённый пользователь больше не может войти.
+func (s *Service) DeleteUser(ctx context.Context, id uuid.UUID, policy UserDeletePolicy) error {
+	if err := requireRole(ctx, domain.RoleAdmin); err != nil {
+		return err
+	}
+	return s.users.Delete(ctx, id, func(subs []*domain.Subscription) ([]BatchChange, error) {
+		if len(subs) > 0 && policy == DeleteRefuse {
+			return nil, domain.Errorf(domain.ErrConflict, "user has %d subscriptions; delete them or use policy end or cascade", len(subs))
+		}
+		return releaseChanges(subs, policy), nil
+	})
+}
+
+// releaseChanges завершает или удаляет подписки удаляемого пользователя.
+func releaseChanges(subs []*domain.Subscription, policy UserDeletePolicy) []BatchChange {
+	now := time.Now().UTC()
+	today := domain.DateFromTime(now)
+	changes := make([]BatchChange, 0, len(subs))
+	for _, before := range subs {
+		if policy == DeleteCascade {
+			changes = append(changes, BatchChange{Kind: BatchDelete, Before: before})
+			continue
+		}
+		if today.Before(before.FirstDay()) { // ещё не началась — завершить нельзя
+			changes = append(changes, BatchChange{Kind: BatchDelete, Before: before})
+			continue
+		}
+		after := *before
+		after.EndBy(today)
+		if last := before.LastDay(); last != nil && !after.LastDay().Before(*last) {
+			continue // заканчивается не позже
+		}
+		after.UpdatedAt = now
+		changes = append(changes, BatchChange{Kind: BatchUpdate, Before: before, After: &after})
+	}
+	return changes
+}
+
+// applyUser переносит ввод в u и проверяет его, в том числе что команда существует.
+func (s *Service) applyUser(ctx context.Context, u *domain.User, in UserInput, now time.Time) error {
+	u.Name, u.Email, u.TeamID, u.Timezone = in.Name, in.Email, in.TeamID, in.Timezone
+	u.UpdatedAt = now
+	u.Normalize()
+	if err := u.Validate(); err != nil {
+		return err
+	}
+	if u.TeamID != nil {
+		if _, err := s.teams.Get(ctx, *u.TeamID); errors.Is(err, domain.ErrNotFound) {
+			return domain.Errorf(domain.ErrValidation, "team %s does not exist", *u.TeamID)
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sameID сравнивает необязательные ссылки.
+func sameID(a, b *uuid.UUID) bool {
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
+
+// requireUser проверяет, что пользователь существует и не удалён.
+func (s *Service) requireUser(ctx context.Context, id uuid.UUID) error {
+	_, err := s.users.Get(ctx, id)
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.Errorf(domain.ErrValidation, "user %s does not exist", id)
+	}
+	return err
+}
+
+// DTOs
+
+type UserInput struct {
+	ID       *uuid.UUID // только при создании; по умолчанию генерируется
+	Name     string
+	Email    string
+	TeamID   *uuid.UUID
+	Timezone string
+}
+
+// UserFilter — условия списка пользователей; Limit 0 — без ограничения.
+type UserFilter struct {
+	TeamIDs []uuid.UUID // любой из
+	Limit   int
+	Offset  int
+}